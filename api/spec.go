@@ -0,0 +1,55 @@
+// Package api embeds the OpenAPI contract, so a binary serves and validates
+// requests against exactly the spec it was built with.
+package api
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// langEnum is the Lang schema's enum, written for the default language set.
+var langEnum = regexp.MustCompile(`(?m)^(\s+enum: )\[[^\]]*\]( # SUPPORTED_LANGS)$`)
+
+// Spec is the contract as served by one deployment.
+type Spec struct {
+	YAML   []byte         // served at /openapi.yaml
+	Doc    *openapi3.T    // parsed and validated
+	Router routers.Router // finds a request's operation, whatever its host
+}
+
+// Load parses the embedded spec with the Lang enum set to langs (the
+// configured SUPPORTED_LANGS).
+func Load(langs []string) (*Spec, error) {
+	if len(langEnum.FindAllIndex(spec, -1)) != 1 {
+		return nil, fmt.Errorf("openapi.yaml: want exactly one Lang enum marked # SUPPORTED_LANGS")
+	}
+	y := langEnum.ReplaceAll(spec, []byte("${1}["+strings.Join(langs, ", ")+"]${2}"))
+
+	doc, err := openapi3.NewLoader().LoadFromData(y)
+	if err != nil {
+		return nil, fmt.Errorf("openapi.yaml: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("openapi.yaml: %w", err)
+	}
+
+	// Route on paths alone: servers lists the local URL, not every host the
+	// API answers on.
+	routed := *doc
+	routed.Servers = openapi3.Servers{{URL: "/"}}
+	router, err := gorillamux.NewRouter(&routed)
+	if err != nil {
+		return nil, fmt.Errorf("openapi.yaml: %w", err)
+	}
+	return &Spec{YAML: y, Doc: doc, Router: router}, nil
+}
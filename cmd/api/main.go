@@ -1,16 +1,27 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/rs/zerolog/log"
 
+	"cupid_hotel/api"
+	"cupid_hotel/internal/adapters/cupid"
 	server "cupid_hotel/internal/adapters/http_server"
 	"cupid_hotel/internal/adapters/observability"
+	"cupid_hotel/internal/adapters/ratelimit"
 	redisad "cupid_hotel/internal/adapters/redis"
+	"cupid_hotel/internal/adapters/webhook"
 	"cupid_hotel/internal/app"
+	"cupid_hotel/internal/domain"
 	"cupid_hotel/internal/shared"
 	mysqlrepo "cupid_hotel/internal/storage/mysql"
 )
@@ -23,6 +34,10 @@ func main() {
 
 	observability.Serve()
 
+	// SIGINT/SIGTERM start a graceful shutdown; a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// db
 	db, err := sql.Open("mysql", cfg.MySQLDSN)
 	if err != nil {
@@ -37,17 +52,109 @@ func main() {
 	repo := mysqlrepo.New(db)
 	cache := redisad.New(cfg.RedisAddr, cfg.RedisPass, cfg.RedisDB)
 	q := app.NewQueryService(repo, cache, cfg.CacheTTL)
+	q.SetLanguages(cfg.Languages())
+	hooks := app.NewWebhookService(repo)
+
+	// API keys: one token bucket per key, per replica unless shared in Redis
+	var auth *app.AuthService
+	if cfg.APIAuth {
+		var limiter domain.RateLimiter = ratelimit.NewLocal()
+		if cfg.RateLimitRedis {
+			limiter = redisad.NewLimiter(cache)
+		}
+		auth = app.NewAuthService(repo, limiter, domain.Quota{Rate: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst})
+		auth.SetClientQuota(domain.Quota{Rate: cfg.ClientRPS, Burst: cfg.ClientBurst})
+	} else {
+		log.Warn().Msg("API_AUTH=false: serving without API keys")
+	}
+
+	// webhooks: ingestion fills the outbox; any API replica may drain it.
+	// Deliveries cut short by shutdown are retried once their lease expires.
+	dispatched := make(chan struct{})
+	if cfg.WebhookDispatch {
+		d := app.NewWebhookDispatcher(repo, webhook.New(cfg.WebhookTimeout), app.DispatcherConfig{
+			MaxAttempts:  cfg.WebhookMaxAttempts,
+			DisableAfter: cfg.WebhookDisableAfter,
+		})
+		go func() {
+			defer close(dispatched)
+			d.Run(ctx)
+		}()
+	} else {
+		close(dispatched)
+	}
+
+	// on-demand ingestion (/admin) needs Cupid credentials
+	var jobs *app.IngestJobService
+	if client, err := cupid.New(cfg.CupidBase, cfg.CupidKey, 5); err != nil {
+		log.Warn().Err(err).Msg("Cupid client unavailable: /admin ingestion routes disabled")
+	} else {
+		ing := app.NewIngestionService(client, repo, cache)
+		ing.SetLanguages(cfg.Languages())
+		jobs = app.NewIngestJobService(ing, repo, app.IngestJobConfig{ReviewCount: cfg.ReviewCount, Workers: cfg.Workers})
+		// jobs whose replica died stop heartbeating; mark them interrupted
+		go jobs.SweepStale(ctx)
+	}
+
+	// readiness: MySQL and the schema are required; without Redis reads skip
+	// the cache and rate limiting fails open, so it only degrades the service
+	health := app.NewHealthService(cfg.ReadyTimeout,
+		app.Check{Name: "mysql", Probe: func(ctx context.Context) (string, error) { return "", repo.Ping(ctx) }},
+		app.Check{Name: "schema", Probe: func(ctx context.Context) (string, error) {
+			v, err := repo.CheckSchema(ctx)
+			return fmt.Sprintf("version %d (binary expects %d)", v, mysqlrepo.SchemaVersion), err
+		}},
+		app.Check{Name: "redis", Optional: true, Probe: func(ctx context.Context) (string, error) { return "", cache.Ping(ctx) }},
+	)
+
+	// the served spec lists the configured languages
+	spec, err := api.Load(q.Languages().Supported)
+	if err != nil {
+		log.Fatal().Err(err).Msg("load OpenAPI spec failed")
+	}
 
 	// http
 	srv := server.New()
 	reg := observability.InitRegistry()
 	srv.Mount("/metrics", observability.MetricsHandler(reg))
-	srv.MountHandlers(&server.Handlers{Q: q})
+	srv.MountHandlers(&server.Handlers{Q: q, W: hooks, A: auth, J: jobs, H: health, Spec: spec, TrustedProxies: cfg.TrustedProxies})
 
 	log.Info().Str("addr", cfg.HTTPAddr).Msg("API listening")
 	httpSrv := &http.Server{Addr: cfg.HTTPAddr, Handler: srv.Mux()}
-
-	if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	served := make(chan error, 1)
+	go func() { served <- httpSrv.ListenAndServe() }()
+	select {
+	case err := <-served:
 		log.Fatal().Err(err).Msg("http server failed")
+	case <-ctx.Done():
+	}
+	stop()
+
+	// drain: no new connections; requests and ingest jobs in flight get the
+	// grace period, jobs not finished by then are stored as interrupted
+	log.Info().Dur("grace", cfg.ShutdownGrace).Msg("shutting down")
+	sctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := httpSrv.Shutdown(sctx); err != nil {
+			log.Warn().Err(err).Msg("grace period over: closing open connections")
+			_ = httpSrv.Close()
+		}
+	}()
+	if jobs != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := jobs.Shutdown(sctx); err != nil {
+				log.Warn().Err(err).Msg("grace period over: ingest jobs cancelled in flight")
+			}
+		}()
 	}
+	wg.Wait()
+	<-dispatched
+	_ = db.Close()
+	log.Info().Msg("API stopped")
 }
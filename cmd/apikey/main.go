@@ -0,0 +1,53 @@
+// Command apikey mints an API key directly in MySQL, e.g. the first admin key
+// (POST /v2/keys itself needs one):
+//
+//	apikey -name ops -scopes admin
+//
+// The key is printed once; only its hash is stored.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"cupid_hotel/internal/app"
+	"cupid_hotel/internal/domain"
+	"cupid_hotel/internal/shared"
+	mysqlrepo "cupid_hotel/internal/storage/mysql"
+)
+
+func main() {
+	name := flag.String("name", "", "consumer name (required)")
+	scopes := flag.String("scopes", domain.ScopeRead, "comma-separated scopes: "+strings.Join(domain.Scopes, ", "))
+	rps := flag.Float64("rps", 0, "requests per second (0 = RATE_LIMIT_RPS)")
+	burst := flag.Int("burst", 0, "bucket size (0 = RATE_LIMIT_BURST)")
+	flag.Parse()
+
+	cfg := shared.Load()
+	db, err := sql.Open("mysql", cfg.MySQLDSN)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+
+	auth := app.NewAuthService(mysqlrepo.New(db), nil, domain.Quota{Rate: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst})
+	k, token, err := auth.CreateKey(context.Background(), *name, strings.Split(*scopes, ","), domain.Quota{Rate: *rps, Burst: *burst})
+	if err != nil {
+		fail(err)
+	}
+	q := auth.QuotaOf(k)
+	fmt.Fprintf(os.Stderr, "created key %d (%s) for %q, scopes %s, %g req/s burst %d\n",
+		k.ID, k.Prefix, k.Name, strings.Join(k.Scopes, ","), q.Rate, q.Burst)
+	fmt.Println(token)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "apikey:", err)
+	os.Exit(1)
+}
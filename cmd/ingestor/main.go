@@ -5,36 +5,50 @@ import (
 	"cupid_hotel/internal/adapters/observability"
 	redisad "cupid_hotel/internal/adapters/redis"
 	"database/sql"
-	"sync"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/rs/zerolog/log"
-	"golang.org/x/sync/semaphore"
 
 	"cupid_hotel/internal/adapters/cupid"
 	"cupid_hotel/internal/app"
+	"cupid_hotel/internal/domain"
 	"cupid_hotel/internal/shared"
 	mysqlrepo "cupid_hotel/internal/storage/mysql"
 )
 
+// exitInterrupted is the exit status of a run stopped by a signal before
+// every hotel was ingested (128 + SIGINT, as shells report Ctrl-C).
+const exitInterrupted = 130
+
 func main() {
-	ctx := context.Background()
+	resume := flag.String("resume", "", "ingest only the hotels the interrupted run `job` left out")
+	flag.Parse()
 	cfg := shared.Load()
 
 	// 1) initialize global logger (console in dev, JSON otherwise)
 	log.Logger = observability.NewLogger(cfg.AppEnv)
 
+	// SIGINT/SIGTERM stop dispatching; hotels in flight get the grace period.
+	// A second signal kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	log.Info().
 		Str("base", cfg.CupidBase).
 		Int("workers", cfg.Workers).
 		Int("reviews", cfg.ReviewCount).
+		Strs("langs", cfg.SupportedLangs).
 		Msg("ingestor starting")
 
 	db, err := sql.Open("mysql", cfg.MySQLDSN)
 	if err != nil {
 		log.Fatal().Err(err).Msg("sql.Open failed")
 	}
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		log.Fatal().Err(err).Msg("db.Ping failed")
 	}
 	log.Info().Msg("db ping ok")
@@ -47,30 +61,50 @@ func main() {
 	}
 	cache := redisad.New(cfg.RedisAddr, cfg.RedisPass, cfg.RedisDB)
 	ing := app.NewIngestionService(client, repo, cache)
-	sem := semaphore.NewWeighted(int64(cfg.Workers))
-	var wg sync.WaitGroup
-
-	for _, id := range shared.PropertyIDs {
-		id := id
+	ing.SetLanguages(cfg.Languages())
 
-		// acquire before launching the goroutine; release inside it
-		if err := sem.Acquire(ctx, int64(1)); err != nil {
-			log.Fatal().Err(err).Msg("semaphore acquire failed")
+	// the run is an ingest job: its progress is stored as it goes, which
+	// is the checkpoint an interrupted run resumes from
+	jobs := app.NewIngestJobService(ing, repo, app.IngestJobConfig{ReviewCount: cfg.ReviewCount, Workers: cfg.Workers})
+	ids, opts := shared.PropertyIDs, domain.IngestOptions{}
+	if *resume != "" {
+		prev, err := jobs.Job(ctx, *resume)
+		if err != nil {
+			log.Fatal().Err(err).Str("job", *resume).Msg("load ingest job to resume failed")
 		}
+		ids, opts = prev.Remaining(), prev.Options
+		if len(ids) == 0 {
+			log.Info().Str("job", prev.ID).Msg("nothing left to resume")
+			return
+		}
+		log.Info().Str("job", prev.ID).Int("hotels", len(prev.PropertyIDs)).Int("left", len(ids)).Msg("resuming")
+	}
 
-		wg.Add(1)
-		go func(hotelID int64) {
-			defer wg.Done()
-			defer sem.Release(int64(1))
-
-			if err := ing.IngestHotel(ctx, hotelID, cfg.ReviewCount); err != nil {
-				log.Warn().Int64("id", hotelID).Err(err).Msg("ingest failed")
-				return
-			}
-			log.Info().Int64("id", hotelID).Msg("ingest ok")
-		}(id)
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-finished:
+			return
+		case <-ctx.Done():
+		}
+		stop()
+		log.Warn().Dur("grace", cfg.ShutdownGrace).Msg("interrupted: no new hotels, waiting for those in flight")
+		sctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
+		defer cancel()
+		if err := jobs.Shutdown(sctx); err != nil {
+			log.Warn().Err(err).Msg("grace period over: hotels in flight cancelled")
+		}
+	}()
+	j, err := jobs.Run(ctx, ids, opts)
+	close(finished)
+	if err != nil {
+		log.Fatal().Err(err).Msg("ingestion failed")
 	}
 
-	wg.Wait()
-	log.Info().Msg("ingestion completed")
+	if j.Status == domain.JobInterrupted {
+		log.Warn().Str("job", j.ID).Int("done", len(j.Reports)).Int("left", len(j.Remaining())).
+			Msgf("ingestion interrupted; resume with -resume %s", j.ID)
+		os.Exit(exitInterrupted)
+	}
+	log.Info().Str("job", j.ID).Str("status", j.Status).Int("hotels", len(j.PropertyIDs)).Msg("ingestion completed")
 }
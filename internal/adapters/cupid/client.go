@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"golang.org/x/time/rate"
+
+	"cupid_hotel/internal/domain"
 )
 
 type Client struct {
@@ -72,10 +74,12 @@ func (c *Client) GetReviews(ctx context.Context, id int64, count int) ([]map[str
 
 // ---- Internals ----
 
+// Errors for the statuses callers act on; they wrap the domain upstream kinds
+// so the app can tell them apart without importing this package.
 var (
-	ErrNotFound     = errors.New("cupid: not found")
-	ErrUnauthorized = errors.New("cupid: unauthorized")
-	ErrForbidden    = errors.New("cupid: forbidden")
+	ErrNotFound     = fmt.Errorf("cupid: %w", domain.ErrUpstreamNotFound)
+	ErrUnauthorized = fmt.Errorf("cupid: %w", domain.ErrUpstreamUnauthorized)
+	ErrForbidden    = fmt.Errorf("cupid: %w", domain.ErrUpstreamForbidden)
 )
 
 func (c *Client) getFirst(ctx context.Context, urls []string, out any) error {
@@ -3,6 +3,7 @@ package cupid_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"cupid_hotel/internal/adapters/cupid"
+	"cupid_hotel/internal/domain"
 )
 
 func TestClient_GetProperty_RetriesThenSuccess(t *testing.T) {
@@ -58,7 +60,10 @@ func TestClient_GetProperty_404(t *testing.T) {
 	defer cancel()
 
 	_, err = cl.GetProperty(ctx, 1)
-	if err == nil {
-		t.Fatalf("expected error for 404")
+	if !errors.Is(err, domain.ErrUpstreamNotFound) {
+		t.Fatalf("expected domain.ErrUpstreamNotFound for 404, got %v", err)
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("an upstream 404 must not read as our own not found: %v", err)
 	}
 }
@@ -0,0 +1,134 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"cupid_hotel/internal/domain"
+)
+
+// Operator endpoints live outside the versioned API: they answer with DTOs
+// only and always need an admin key.
+
+func (h *Handlers) adminRoutes(r chi.Router) {
+	if h.A != nil {
+		r.Use(h.authenticate)
+	}
+	if h.Spec != nil {
+		r.Use(validateParams(h.Spec))
+	}
+	r.Use(requireScope(domain.ScopeAdmin))
+	r.Post("/hotels/{id}/refresh", h.refreshHotel)
+	r.Post("/ingest-jobs", h.createIngestJob)
+	r.Get("/ingest-jobs/{id}", h.getIngestJob)
+	r.Delete("/ingest-jobs/{id}", h.cancelIngestJob)
+}
+
+// refreshHotel re-ingests one hotel from Cupid. It answers with the
+// step-by-step report, or with ?async=true at once with a job to poll.
+func (h *Handlers) refreshHotel(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	async := false
+	if v := r.URL.Query().Get("async"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeInvalid(w, r, "async", "async must be true or false")
+			return
+		}
+		async = b
+	}
+
+	if async {
+		j, err := h.J.StartRefresh(r.Context(), id)
+		if err != nil {
+			writeError(w, r, err, "ingest job")
+			return
+		}
+		w.Header().Set("Location", "/admin/ingest-jobs/"+j.ID)
+		writePrivateJSON(w, http.StatusAccepted, toIngestJobDTO(j))
+		return
+	}
+	rep, err := h.J.Refresh(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "hotel")
+		return
+	}
+	writePrivateJSON(w, http.StatusOK, toIngestReportDTO(rep))
+}
+
+type createIngestJobRequest struct {
+	PropertyIDs []int64  `json:"property_ids"`
+	ReviewCount int      `json:"review_count"` // 0 = service default
+	Languages   []string `json:"languages"`    // omitted = every supported language
+	Parts       []string `json:"parts"`        // omitted = every part
+}
+
+func (h *Handlers) createIngestJob(w http.ResponseWriter, r *http.Request) {
+	var req createIngestJobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalid(w, r, "body", `body must be {"property_ids":[1641879],"review_count":10,"languages":["fr"],"parts":["property","reviews","translations"]}`)
+		return
+	}
+	j, err := h.J.Start(r.Context(), req.PropertyIDs, domain.IngestOptions{
+		ReviewCount: req.ReviewCount,
+		Langs:       req.Languages,
+		Parts:       req.Parts,
+	})
+	if err != nil {
+		writeError(w, r, err, "ingest job")
+		return
+	}
+	w.Header().Set("Location", "/admin/ingest-jobs/"+j.ID)
+	writePrivateJSON(w, http.StatusAccepted, toIngestJobDTO(j))
+}
+
+// getIngestJob reports progress and per-hotel results; ?outcome= keeps the
+// reports with one of the listed outcomes (e.g. failed,partial).
+func (h *Handlers) getIngestJob(w http.ResponseWriter, r *http.Request) {
+	var outcomes []string
+	if v := r.URL.Query().Get("outcome"); v != "" {
+		for _, o := range strings.Split(v, ",") {
+			if !slices.Contains(ingestOutcomes, o) {
+				writeInvalid(w, r, "outcome", "outcome must list any of "+strings.Join(ingestOutcomes, ", "))
+				return
+			}
+			outcomes = append(outcomes, o)
+		}
+	}
+	j, err := h.J.Job(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err, "ingest job")
+		return
+	}
+	dto := toIngestJobDTO(j)
+	if outcomes != nil {
+		dto.Reports = slices.DeleteFunc(dto.Reports, func(rep ingestReportDTO) bool { return !slices.Contains(outcomes, rep.Outcome) })
+	}
+	writePrivateJSON(w, http.StatusOK, dto)
+}
+
+// cancelIngestJob stops dispatching: hotels in flight finish, the rest are
+// left out. The job turns cancelled once they have; finished jobs are
+// returned as they are, with 200.
+func (h *Handlers) cancelIngestJob(w http.ResponseWriter, r *http.Request) {
+	j, err := h.J.Cancel(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err, "ingest job")
+		return
+	}
+	status := http.StatusAccepted
+	if j.Finished() {
+		status = http.StatusOK
+	}
+	writePrivateJSON(w, status, toIngestJobDTO(j))
+}
+
+var ingestOutcomes = []string{domain.IngestOK, domain.IngestPartial, domain.IngestDeactivated, domain.IngestFailed}
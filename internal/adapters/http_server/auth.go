@@ -0,0 +1,152 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"cupid_hotel/internal/domain"
+)
+
+// ---- authentication and quotas ----
+
+type apiKeyCtx struct{}
+
+// authenticate resolves the caller's API key (Authorization: Bearer, or
+// X-API-Key) and takes one request from its quota. Every response carries
+// RateLimit-Limit/-Remaining/-Reset; denied requests get 429 + Retry-After.
+// Requests without a known key first draw on their client IP's bucket.
+func (h *Handlers) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := presentedKey(r)
+		if !h.A.Known(token) {
+			if d, ok := h.A.LimitClient(r.Context(), h.clientIP(r)); ok && !d.Allowed {
+				w.Header().Set("Retry-After", ceilSeconds(d.RetryAfter))
+				writeError(w, r, domain.ErrRateLimited, "")
+				return
+			}
+		}
+		k, err := h.A.Authenticate(r.Context(), token)
+		if err != nil {
+			writeError(w, r, err, "API key")
+			return
+		}
+		if d, ok := h.A.Limit(r.Context(), k); ok {
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			w.Header().Set("RateLimit-Reset", ceilSeconds(d.Reset))
+			if !d.Allowed {
+				w.Header().Set("Retry-After", ceilSeconds(d.RetryAfter))
+				writeError(w, r, domain.ErrRateLimited, "")
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyCtx{}, k)))
+	})
+}
+
+// requireScope rejects keys without scope. Without authentication (no
+// AuthService) there is no key and every route is open.
+func requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if k, ok := r.Context().Value(apiKeyCtx{}).(domain.APIKey); ok && !k.Allows(scope) {
+				writeError(w, r, fmt.Errorf("%w: this API key lacks the %q scope", domain.ErrForbidden, scope), "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func presentedKey(r *http.Request) string {
+	if t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(t)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// ceilSeconds rounds up so clients never retry early; RateLimit-Reset is 0
+// only when the bucket is already full.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(max(0, int(math.Ceil(d.Seconds()))))
+}
+
+// ---- key management (admin scope) ----
+
+func (h *Handlers) apiKeyRoutes(r chi.Router) {
+	r.Post("/", h.createAPIKey)
+	r.Get("/", h.listAPIKeys)
+	r.Delete("/{id}", h.revokeAPIKey)
+}
+
+type createAPIKeyRequest struct {
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	RatePerSec float64  `json:"rate_per_sec"` // 0 = service default
+	Burst      int      `json:"burst"`        // 0 = service default
+}
+
+func (h *Handlers) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&req); err != nil {
+		writeInvalid(w, r, "body", `body must be {"name":"...","scopes":["read"],"rate_per_sec":10,"burst":20}`)
+		return
+	}
+	k, token, err := h.A.CreateKey(r.Context(), req.Name, req.Scopes, domain.Quota{Rate: req.RatePerSec, Burst: req.Burst})
+	if err != nil {
+		writeError(w, r, err, "API key")
+		return
+	}
+	dto := h.toAPIKeyDTO(k)
+	dto.Key = token
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+strconv.FormatInt(k.ID, 10))
+	writePrivateJSON(w, http.StatusCreated, dto)
+}
+
+func (h *Handlers) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.A.ListKeys(r.Context())
+	if err != nil {
+		writeError(w, r, err, "API keys")
+		return
+	}
+	items := make([]apiKeyDTO, len(keys))
+	for i, k := range keys {
+		items[i] = h.toAPIKeyDTO(k)
+	}
+	writePrivateJSON(w, http.StatusOK, apiKeysDTO{Items: items})
+}
+
+func (h *Handlers) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.A.RevokeKey(r.Context(), id); err != nil {
+		writeError(w, r, err, "API key")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toAPIKeyDTO reports the effective quota, defaults filled in.
+func (h *Handlers) toAPIKeyDTO(k domain.APIKey) apiKeyDTO {
+	q := h.A.QuotaOf(k)
+	return apiKeyDTO{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     nonNil(k.Scopes),
+		RatePerSec: q.Rate,
+		Burst:      q.Burst,
+		CreatedAt:  k.CreatedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cupid_hotel/internal/domain"
+)
+
+// strongETag derives a validator from a stored version without building the
+// body. parts name the representation (resource, API version, language,
+// query), so each variant of the same version gets its own tag.
+func strongETag(r *http.Request, v domain.Version, parts ...string) string {
+	parts = append([]string{v.Tag, strconv.Itoa(apiVersion(r))}, parts...)
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// setValidators writes ETag and, when known, Last-Modified.
+func setValidators(w http.ResponseWriter, etag string, modified time.Time) {
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified evaluates If-None-Match (a list of tags or "*", weak
+// comparison) and, only when that header is absent, If-Modified-Since
+// (RFC 9110 §13.2.2). A zero modified disables the date check.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Values("If-None-Match"); len(inm) > 0 {
+		return etagListMatches(strings.Join(inm, ","), etag)
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have second precision.
+	return !modified.Truncate(time.Second).After(t)
+}
+
+// etagListMatches reports whether any entity tag of an If-None-Match list
+// matches etag; W/ prefixes are ignored (weak comparison).
+func etagListMatches(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(list, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,640 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"cupid_hotel/internal/domain"
+)
+
+// /v1 serializes domain types as-is (Go field names, raw payloads). /v2 is the
+// stable public contract: the DTOs below, snake_case, no upstream raw JSON.
+// Handlers are shared; present picks the representation for the route's version.
+
+type apiVersionKey struct{}
+
+// withAPIVersion tags requests under a route group with its API version.
+func withAPIVersion(v int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, v)))
+		})
+	}
+}
+
+func apiVersion(r *http.Request) int {
+	if v, ok := r.Context().Value(apiVersionKey{}).(int); ok {
+		return v
+	}
+	return 1
+}
+
+// present returns the value to serialize for the request's API version.
+func present(r *http.Request, v any) any {
+	if apiVersion(r) < 2 {
+		return v
+	}
+	switch t := v.(type) {
+	case domain.HotelView:
+		return toHotelDTO(t)
+	case domain.HotelsPage:
+		items := make([]hotelDTO, len(t.Items))
+		for i, hv := range t.Items {
+			items[i] = toHotelDTO(hv)
+		}
+		return hotelsPageDTO{Items: items, NextCursor: t.NextCursor}
+	case domain.HotelBatch:
+		items := make([]hotelLookupDTO, len(t.Items))
+		for i, it := range t.Items {
+			items[i] = hotelLookupDTO{ID: it.ID, Found: it.Found}
+			if it.Hotel != nil {
+				dto := toHotelDTO(*it.Hotel)
+				items[i].Hotel = &dto
+			}
+		}
+		return hotelBatchDTO{Items: items}
+	case domain.HotelFacets:
+		return hotelFacetsDTO{
+			Country: toFacetDTOs(t.Country),
+			City:    toFacetDTOs(t.City),
+			Stars:   toFacetDTOs(t.Stars),
+			Amenity: toFacetDTOs(t.Amenity),
+		}
+	case []domain.HotelImage:
+		items := make([]imageDTO, len(t))
+		for i, im := range t {
+			items[i] = toImageDTO(im)
+		}
+		return imagesDTO{Items: items}
+	case []domain.Amenity:
+		return amenitiesDTO{Items: toAmenityDTOs(t)}
+	case []domain.Brand:
+		items := make([]brandDTO, len(t))
+		for i, b := range t {
+			items[i] = toBrandDTO(b)
+		}
+		return brandsDTO{Items: items}
+	case domain.SearchPage:
+		items := make([]searchHitDTO, len(t.Items))
+		for i, hit := range t.Items {
+			hl := hit.Highlights
+			if hl == nil {
+				hl = map[string]string{}
+			}
+			items[i] = searchHitDTO{Hotel: toHotelDTO(hit.Hotel), Score: hit.Score, Highlights: hl}
+		}
+		return searchPageDTO{Items: items, NextCursor: t.NextCursor}
+	case domain.NearbyPage:
+		items := make([]nearbyHitDTO, len(t.Items))
+		for i, hit := range t.Items {
+			items[i] = nearbyHitDTO{Hotel: toHotelDTO(hit.Hotel), DistanceKm: hit.DistanceKm}
+		}
+		return nearbyPageDTO{Items: items}
+	case domain.SimilarPage:
+		items := make([]similarHitDTO, len(t.Items))
+		for i, hit := range t.Items {
+			items[i] = similarHitDTO{Hotel: toHotelDTO(hit.Hotel), Score: hit.Score, DistanceKm: hit.DistanceKm}
+		}
+		return similarPageDTO{Items: items}
+	case domain.ReviewsPage:
+		items := make([]reviewDTO, len(t.Items))
+		for i, rv := range t.Items {
+			items[i] = toReviewDTO(rv)
+		}
+		return reviewsPageDTO{Items: items, NextCursor: t.NextCursor}
+	case domain.Review:
+		return toReviewDTO(t)
+	case domain.ChangesPage:
+		items := make([]changeDTO, len(t.Items))
+		for i, c := range t.Items {
+			items[i] = changeDTO{Seq: c.Seq, Entity: c.Entity, Op: c.Op, HotelID: c.PropertyID, Lang: c.Lang, ChangedAt: c.At.UTC()}
+		}
+		return changesPageDTO{Items: items, Next: t.Next, HasMore: t.HasMore}
+	case domain.ReviewSummary:
+		return toReviewSummaryDTO(t)
+	}
+	return v
+}
+
+/********** hotels **********/
+
+type coordsDTO struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type hotelDTO struct {
+	ID          int64      `json:"id"`
+	Name        *string    `json:"name"`
+	Description *string    `json:"description"`
+	Policies    *string    `json:"policies"`
+	Stars       *int       `json:"stars"`
+	Country     *string    `json:"country"`
+	City        *string    `json:"city"`
+	Address     *string    `json:"address"`
+	Coords      *coordsDTO `json:"coords"`
+	Amenities   []string   `json:"amenities"` // raw provider labels
+	// Canonical taxonomy codes with labels in the served language.
+	CanonicalAmenities []amenityDTO `json:"canonical_amenities"`
+	Images             []string     `json:"images"`
+	MainPhoto          *string      `json:"main_photo"` // primary image URL
+	Brand              *brandRefDTO `json:"brand"`
+	Language           string       `json:"language"`
+	// Which language served each translated field; single and batch lookups only.
+	FieldLanguages map[string]string `json:"field_languages,omitempty"`
+}
+
+type imageDTO struct {
+	URL       string  `json:"url"`
+	Caption   *string `json:"caption"`
+	Category  *string `json:"category"`
+	Width     *int    `json:"width"`
+	Height    *int    `json:"height"`
+	SortOrder int     `json:"sort_order"`
+	IsPrimary bool    `json:"is_primary"`
+}
+
+type imagesDTO struct {
+	Items []imageDTO `json:"items"`
+}
+
+func toImageDTO(im domain.HotelImage) imageDTO {
+	return imageDTO{
+		URL:       im.URL,
+		Caption:   im.Caption,
+		Category:  im.Category,
+		Width:     im.Width,
+		Height:    im.Height,
+		SortOrder: im.SortOrder,
+		IsPrimary: im.IsPrimary,
+	}
+}
+
+// brandRefDTO is the brand embedded in a hotel; brandDTO adds the listing count.
+type brandRefDTO struct {
+	ID   int64   `json:"id"`
+	Name *string `json:"name"`
+}
+
+type brandDTO struct {
+	ID         int64   `json:"id"`
+	Name       *string `json:"name"`
+	HotelCount int     `json:"hotel_count"`
+}
+
+type brandsDTO struct {
+	Items []brandDTO `json:"items"`
+}
+
+func toBrandDTO(b domain.Brand) brandDTO {
+	return brandDTO{ID: b.ID, Name: b.Name, HotelCount: b.HotelCount}
+}
+
+type amenityDTO struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+}
+
+type amenitiesDTO struct {
+	Items []amenityDTO `json:"items"`
+}
+
+func toAmenityDTOs(in []domain.Amenity) []amenityDTO {
+	out := make([]amenityDTO, len(in))
+	for i, a := range in {
+		out[i] = amenityDTO{Code: a.Code, Label: a.Label}
+	}
+	return out
+}
+
+type hotelsPageDTO struct {
+	Items      []hotelDTO `json:"items"`
+	NextCursor *string    `json:"next_cursor"`
+}
+
+type hotelLookupDTO struct {
+	ID    int64     `json:"id"`
+	Found bool      `json:"found"`
+	Hotel *hotelDTO `json:"hotel"`
+}
+
+type hotelBatchDTO struct {
+	Items []hotelLookupDTO `json:"items"`
+}
+
+type facetCountDTO struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"` // amenity facet only
+	Count int    `json:"count"`
+}
+
+type hotelFacetsDTO struct {
+	Country []facetCountDTO `json:"country"`
+	City    []facetCountDTO `json:"city"`
+	Stars   []facetCountDTO `json:"stars"`
+	Amenity []facetCountDTO `json:"amenity"`
+}
+
+func toFacetDTOs(in []domain.FacetCount) []facetCountDTO {
+	out := make([]facetCountDTO, len(in))
+	for i, fc := range in {
+		out[i] = facetCountDTO{Value: fc.Value, Label: fc.Label, Count: fc.Count}
+	}
+	return out
+}
+
+type searchHitDTO struct {
+	Hotel      hotelDTO          `json:"hotel"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+type searchPageDTO struct {
+	Items      []searchHitDTO `json:"items"`
+	NextCursor *string        `json:"next_cursor"`
+}
+
+type nearbyHitDTO struct {
+	Hotel      hotelDTO `json:"hotel"`
+	DistanceKm float64  `json:"distance_km"`
+}
+
+type nearbyPageDTO struct {
+	Items []nearbyHitDTO `json:"items"`
+}
+
+type similarHitDTO struct {
+	Hotel      hotelDTO `json:"hotel"`
+	Score      float64  `json:"score"`
+	DistanceKm *float64 `json:"distance_km"`
+}
+
+type similarPageDTO struct {
+	Items []similarHitDTO `json:"items"`
+}
+
+func toHotelDTO(hv domain.HotelView) hotelDTO {
+	out := hotelDTO{
+		ID:                 hv.ID,
+		Name:               hv.Name,
+		Description:        hv.Description,
+		Policies:           hv.Policies,
+		Stars:              hv.Stars,
+		Country:            hv.Country,
+		City:               hv.City,
+		Address:            hv.Address,
+		Amenities:          nonNil(hv.Amenities),
+		CanonicalAmenities: toAmenityDTOs(hv.CanonicalAmenities),
+		Images:             nonNil(hv.Images),
+		MainPhoto:          hv.MainPhoto,
+		Language:           hv.Language,
+		FieldLanguages:     hv.FieldLanguages,
+	}
+	if hv.Coords != nil {
+		out.Coords = &coordsDTO{Lat: hv.Coords.Lat, Lon: hv.Coords.Lon}
+	}
+	if hv.Brand != nil {
+		out.Brand = &brandRefDTO{ID: hv.Brand.ID, Name: hv.Brand.Name}
+	}
+	return out
+}
+
+/********** reviews **********/
+
+type aspectsDTO struct {
+	Pros []string `json:"pros"`
+	Cons []string `json:"cons"`
+}
+
+type reviewDTO struct {
+	ID        int64      `json:"id"`
+	HotelID   int64      `json:"hotel_id"`
+	Author    *string    `json:"author"`
+	Rating    *float64   `json:"rating"`
+	Lang      *string    `json:"lang"`
+	Title     *string    `json:"title"`
+	Text      *string    `json:"text"`
+	Aspects   aspectsDTO `json:"aspects"`
+	Source    *string    `json:"source"`
+	SourceID  *string    `json:"source_id"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type reviewsPageDTO struct {
+	Items      []reviewDTO `json:"items"`
+	NextCursor *string     `json:"next_cursor"`
+}
+
+func toReviewDTO(rv domain.Review) reviewDTO {
+	// Aspects are stored as {"pros":[...],"cons":[...]}; anything else
+	// (legacy rows, "[]") is served as empty lists.
+	var a aspectsDTO
+	if len(rv.AspectsJSON) > 0 {
+		_ = json.Unmarshal(rv.AspectsJSON, &a)
+	}
+	a.Pros, a.Cons = nonNil(a.Pros), nonNil(a.Cons)
+
+	return reviewDTO{
+		ID:        rv.ID,
+		HotelID:   rv.PropertyID,
+		Author:    rv.Author,
+		Rating:    rv.Rating,
+		Lang:      rv.Lang,
+		Title:     rv.Title,
+		Text:      rv.Text,
+		Aspects:   a,
+		Source:    rv.Source,
+		SourceID:  rv.SourceID,
+		CreatedAt: rv.CreatedAt.UTC(),
+	}
+}
+
+type ratingBucketDTO struct {
+	Rating int `json:"rating"`
+	Count  int `json:"count"`
+}
+
+type phraseCountDTO struct {
+	Phrase string `json:"phrase"`
+	Count  int    `json:"count"`
+}
+
+type reviewSummaryDTO struct {
+	HotelID      int64             `json:"hotel_id"`
+	Count        int               `json:"count"`
+	RatedCount   int               `json:"rated_count"`
+	MeanRating   *float64          `json:"mean_rating"`
+	MedianRating *float64          `json:"median_rating"`
+	Histogram    []ratingBucketDTO `json:"histogram"`
+	ByLang       map[string]int    `json:"by_lang"`
+	BySource     map[string]int    `json:"by_source"`
+	TopPros      []phraseCountDTO  `json:"top_pros"`
+	TopCons      []phraseCountDTO  `json:"top_cons"`
+}
+
+func toReviewSummaryDTO(s domain.ReviewSummary) reviewSummaryDTO {
+	out := reviewSummaryDTO{
+		HotelID:      s.PropertyID,
+		Count:        s.Count,
+		RatedCount:   s.RatedCount,
+		MeanRating:   s.MeanRating,
+		MedianRating: s.MedianRating,
+		Histogram:    make([]ratingBucketDTO, len(s.Histogram)),
+		ByLang:       s.ByLang,
+		BySource:     s.BySource,
+		TopPros:      toPhraseCountDTOs(s.TopPros),
+		TopCons:      toPhraseCountDTOs(s.TopCons),
+	}
+	for i, b := range s.Histogram {
+		out.Histogram[i] = ratingBucketDTO{Rating: b.Rating, Count: b.Count}
+	}
+	if out.ByLang == nil {
+		out.ByLang = map[string]int{}
+	}
+	if out.BySource == nil {
+		out.BySource = map[string]int{}
+	}
+	return out
+}
+
+func toPhraseCountDTOs(in []domain.PhraseCount) []phraseCountDTO {
+	out := make([]phraseCountDTO, len(in))
+	for i, p := range in {
+		out[i] = phraseCountDTO{Phrase: p.Phrase, Count: p.Count}
+	}
+	return out
+}
+
+// nonNil makes nil slices serialize as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+/********** change feed **********/
+
+type changeDTO struct {
+	Seq       int64     `json:"seq"`
+	Entity    string    `json:"entity"` // property | translation | reviews
+	Op        string    `json:"op"`     // created | updated | deactivated
+	HotelID   int64     `json:"hotel_id"`
+	Lang      *string   `json:"lang"` // translations only
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type changesPageDTO struct {
+	Items   []changeDTO `json:"items"`
+	Next    int64       `json:"next"`
+	HasMore bool        `json:"has_more"`
+}
+
+/********** webhooks **********/
+
+type webhookDTO struct {
+	ID                  int64      `json:"id"`
+	URL                 string     `json:"url"`
+	Events              []string   `json:"events"`
+	Secret              string     `json:"secret,omitempty"` // on creation only
+	Enabled             bool       `json:"enabled"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      *string    `json:"disabled_reason"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+type webhooksDTO struct {
+	Items []webhookDTO `json:"items"`
+}
+
+type webhookAttemptDTO struct {
+	DeliveryID  int64     `json:"delivery_id"`
+	EventID     int64     `json:"event_id"`
+	Event       string    `json:"event"`
+	Attempt     int       `json:"attempt"`
+	StatusCode  *int      `json:"status_code"` // null when no response was received
+	Error       *string   `json:"error"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type webhookAttemptsDTO struct {
+	Items []webhookAttemptDTO `json:"items"`
+}
+
+func toWebhookDTO(s domain.WebhookSubscription) webhookDTO {
+	return webhookDTO{
+		ID:                  s.ID,
+		URL:                 s.URL,
+		Events:              nonNil(s.Events),
+		Enabled:             s.DisabledAt == nil,
+		DisabledAt:          s.DisabledAt,
+		DisabledReason:      s.DisabledReason,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		CreatedAt:           s.CreatedAt,
+	}
+}
+
+func toWebhookAttemptDTO(a domain.WebhookAttempt) webhookAttemptDTO {
+	out := webhookAttemptDTO{
+		DeliveryID:  a.DeliveryID,
+		EventID:     a.EventID,
+		Event:       a.EventType,
+		Attempt:     a.Attempt,
+		DurationMs:  a.Duration.Milliseconds(),
+		AttemptedAt: a.At,
+	}
+	if a.StatusCode > 0 {
+		out.StatusCode = &a.StatusCode
+	}
+	if a.Error != "" {
+		out.Error = &a.Error
+	}
+	return out
+}
+
+/********** API keys **********/
+
+type apiKeyDTO struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"` // on creation only
+	Scopes     []string   `json:"scopes"`
+	RatePerSec float64    `json:"rate_per_sec"`
+	Burst      int        `json:"burst"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type apiKeysDTO struct {
+	Items []apiKeyDTO `json:"items"`
+}
+
+/********** ingestion (admin) **********/
+
+type ingestStepDTO struct {
+	Step           string `json:"step"`                      // property | reviews | i18n:<lang>
+	Outcome        string `json:"outcome"`                   // ok | missed | failed | skipped
+	UpstreamStatus *int   `json:"upstream_status,omitempty"` // misses: 404 or 403
+	Reason         string `json:"reason,omitempty"`
+	Stored         int    `json:"stored"`
+}
+
+type ingestReportDTO struct {
+	PropertyID int64           `json:"property_id"`
+	Outcome    string          `json:"outcome"` // ok | partial | deactivated | failed
+	Steps      []ingestStepDTO `json:"steps"`
+}
+
+type ingestJobDTO struct {
+	ID                string            `json:"id"`
+	Status            string            `json:"status"`
+	PropertyIDs       []int64           `json:"property_ids"`
+	Options           ingestOptionsDTO  `json:"options"`
+	Progress          ingestProgressDTO `json:"progress"`
+	Reports           []ingestReportDTO `json:"reports"`
+	Error             string            `json:"error,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	StartedAt         *time.Time        `json:"started_at"`
+	FinishedAt        *time.Time        `json:"finished_at"`
+	CancelRequestedAt *time.Time        `json:"cancel_requested_at"`
+}
+
+// ingestOptionsDTO reports what each hotel refreshes; null lists mean all.
+type ingestOptionsDTO struct {
+	ReviewCount int      `json:"review_count"`
+	Languages   []string `json:"languages"`
+	Parts       []string `json:"parts"`
+}
+
+// ingestProgressDTO counts finished hotels by outcome.
+type ingestProgressDTO struct {
+	Total       int `json:"total"`
+	Done        int `json:"done"`
+	OK          int `json:"ok"`
+	Partial     int `json:"partial"`
+	Deactivated int `json:"deactivated"`
+	Failed      int `json:"failed"`
+}
+
+func toIngestReportDTO(r domain.IngestReport) ingestReportDTO {
+	out := ingestReportDTO{PropertyID: r.PropertyID, Outcome: r.Outcome, Steps: make([]ingestStepDTO, len(r.Steps))}
+	for i, s := range r.Steps {
+		out.Steps[i] = ingestStepDTO{Step: s.Step, Outcome: s.Outcome, Reason: s.Reason, Stored: s.Stored}
+		if s.Status != 0 {
+			out.Steps[i].UpstreamStatus = &s.Status
+		}
+	}
+	return out
+}
+
+func toIngestJobDTO(j domain.IngestJob) ingestJobDTO {
+	out := ingestJobDTO{
+		ID:          j.ID,
+		Status:      j.Status,
+		PropertyIDs: nonNil(j.PropertyIDs),
+		Options: ingestOptionsDTO{
+			ReviewCount: j.Options.ReviewCount,
+			Languages:   j.Options.Langs,
+			Parts:       j.Options.Parts,
+		},
+		Progress:          ingestProgressDTO{Total: len(j.PropertyIDs), Done: len(j.Reports)},
+		Reports:           make([]ingestReportDTO, len(j.Reports)),
+		Error:             j.Error,
+		CreatedAt:         j.CreatedAt,
+		StartedAt:         j.StartedAt,
+		FinishedAt:        j.FinishedAt,
+		CancelRequestedAt: j.CancelRequestedAt,
+	}
+	for i, r := range j.Reports {
+		out.Reports[i] = toIngestReportDTO(r)
+		switch r.Outcome {
+		case domain.IngestOK:
+			out.Progress.OK++
+		case domain.IngestPartial:
+			out.Progress.Partial++
+		case domain.IngestDeactivated:
+			out.Progress.Deactivated++
+		case domain.IngestFailed:
+			out.Progress.Failed++
+		}
+	}
+	return out
+}
+
+/********** readiness **********/
+
+type readinessDTO struct {
+	Status string                    `json:"status"` // ready | degraded | unavailable
+	Checks map[string]checkResultDTO `json:"checks"`
+}
+
+type checkResultDTO struct {
+	Status     string `json:"status"` // ok | down
+	Optional   bool   `json:"optional,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+func toReadinessDTO(r domain.Readiness) readinessDTO {
+	out := readinessDTO{Status: "ready", Checks: make(map[string]checkResultDTO, len(r.Checks))}
+	switch {
+	case !r.Ready:
+		out.Status = "unavailable"
+	case r.Degraded:
+		out.Status = "degraded"
+	}
+	for _, c := range r.Checks {
+		dto := checkResultDTO{Status: "ok", Optional: c.Optional, Detail: c.Detail, Error: c.Error, DurationMS: c.Duration.Milliseconds()}
+		if !c.OK {
+			dto.Status = "down"
+		}
+		out.Checks[c.Name] = dto
+	}
+	return out
+}
@@ -0,0 +1,311 @@
+package httpserver
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"cupid_hotel/internal/domain"
+)
+
+// Export formats, negotiated from Accept.
+const (
+	mediaNDJSON = "application/x-ndjson"
+	mediaCSV    = "text/csv"
+)
+
+// exportCursorTrailer carries the continuation token of a paged export. It is
+// a trailer because the token is only known once the last row is written.
+const exportCursorTrailer = "Next-Cursor"
+
+// exportFlushRows is how many rows are buffered between flushes to the client.
+const exportFlushRows = 500
+
+// maxExportLimit bounds one paged export response.
+const maxExportLimit = 1_000_000
+
+var (
+	exportHotelColumns = []string{
+		"id", "name", "description", "policies", "stars", "country", "city", "address",
+		"lat", "lon", "brand_id", "brand_name", "main_photo", "amenities", "language",
+	}
+	exportReviewColumns = []string{
+		"id", "hotel_id", "source", "source_id", "author", "rating", "lang", "title", "text", "created_at",
+	}
+)
+
+func (h *Handlers) exportHotels(w http.ResponseWriter, r *http.Request) {
+	media, ok := exportMediaType(w, r)
+	if !ok {
+		return
+	}
+	lang, ok := h.resolveLang(w, r, r.URL.Query().Get("lang"))
+	if !ok {
+		return
+	}
+	q, ok := parseExportQuery(w, r)
+	if !ok {
+		return
+	}
+	q.Lang = lang
+
+	st := newExportStream(w, r, media, exportHotelColumns)
+	next, err := h.Q.ExportHotels(r.Context(), q, func(hv domain.HotelView) error {
+		return st.write(present(r, hv), func() []string { return hotelCSVRecord(hv) })
+	})
+	st.finish(next, err, "hotels")
+}
+
+func (h *Handlers) exportReviews(w http.ResponseWriter, r *http.Request) {
+	media, ok := exportMediaType(w, r)
+	if !ok {
+		return
+	}
+	q, ok := parseExportQuery(w, r)
+	if !ok {
+		return
+	}
+	// For reviews lang filters by review language, as on /hotels/{id}/reviews.
+	q.Lang = strings.ToLower(strings.TrimSpace(r.URL.Query().Get("lang")))
+
+	st := newExportStream(w, r, media, exportReviewColumns)
+	next, err := h.Q.ExportReviews(r.Context(), q, func(rv domain.Review) error {
+		return st.write(present(r, rv), func() []string { return reviewCSVRecord(rv) })
+	})
+	st.finish(next, err, "reviews")
+}
+
+// parseExportQuery reads updated_since, limit and the resume position:
+// cursor, or after_id (rows come in id order, so a client whose stream broke
+// resumes from the last id it received).
+func parseExportQuery(w http.ResponseWriter, r *http.Request) (domain.ExportQuery, bool) {
+	qs := r.URL.Query()
+	q := domain.ExportQuery{Cursor: optString(qs.Get("cursor"))}
+	if as := qs.Get("after_id"); as != "" {
+		id, err := strconv.ParseInt(as, 10, 64)
+		if err != nil || id <= 0 {
+			writeInvalid(w, r, "after_id", "after_id must be a positive id")
+			return domain.ExportQuery{}, false
+		}
+		if q.Cursor != nil {
+			writeInvalid(w, r, "after_id", "after_id and cursor are mutually exclusive")
+			return domain.ExportQuery{}, false
+		}
+		q.AfterID = id
+	}
+	if us := qs.Get("updated_since"); us != "" {
+		t, err := time.Parse(time.RFC3339, us)
+		if err != nil {
+			writeInvalid(w, r, "updated_since", "updated_since must be an RFC 3339 timestamp")
+			return domain.ExportQuery{}, false
+		}
+		q.UpdatedSince = &t
+	}
+	if ls := qs.Get("limit"); ls != "" {
+		l, err := strconv.Atoi(ls)
+		if err != nil || l <= 0 || l > maxExportLimit {
+			writeInvalid(w, r, "limit", "limit must be an integer between 1 and 1000000")
+			return domain.ExportQuery{}, false
+		}
+		q.Limit = l
+	}
+	return q, true
+}
+
+// exportMediaType negotiates NDJSON or CSV from Accept: ranges are tried by
+// descending q; no header, */* and application/* pick NDJSON. Anything else
+// is answered 406.
+func exportMediaType(w http.ResponseWriter, r *http.Request) (string, bool) {
+	type mediaRange struct {
+		typ string
+		q   float64
+	}
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		return mediaNDJSON, true
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		typ := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, p := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil || f < 0 || f > 1 {
+					f = 0
+				}
+				q = f
+			}
+		}
+		if typ != "" && q > 0 {
+			ranges = append(ranges, mediaRange{typ: typ, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	for _, rg := range ranges {
+		switch rg.typ {
+		case mediaNDJSON, "*/*", "application/*":
+			return mediaNDJSON, true
+		case mediaCSV, "text/*":
+			return mediaCSV, true
+		}
+	}
+	writeProblem(w, r, http.StatusNotAcceptable, "Not Acceptable", "exports are served as "+mediaNDJSON+" or "+mediaCSV)
+	return "", false
+}
+
+// exportStream writes rows as NDJSON lines or CSV records. The status line
+// goes out with the first row, so errors raised before any output (a bad
+// cursor, the database being down) still get a problem response.
+type exportStream struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	media   string
+	columns []string
+
+	bw      *bufio.Writer
+	enc     *json.Encoder
+	cw      *csv.Writer
+	started bool
+	rows    int
+}
+
+func newExportStream(w http.ResponseWriter, r *http.Request, media string, columns []string) *exportStream {
+	return &exportStream{w: w, r: r, media: media, columns: columns}
+}
+
+func (s *exportStream) start() error {
+	s.started = true
+	hdr := s.w.Header()
+	hdr.Set("Content-Type", s.media+"; charset=utf-8")
+	hdr.Set("Cache-Control", "no-store")
+	hdr.Set("Trailer", exportCursorTrailer)
+	s.w.WriteHeader(http.StatusOK)
+
+	s.bw = bufio.NewWriterSize(s.w, 32<<10)
+	if s.media == mediaCSV {
+		s.cw = csv.NewWriter(s.bw)
+		return s.cw.Write(s.columns)
+	}
+	s.enc = json.NewEncoder(s.bw)
+	return nil
+}
+
+// write emits one row: v as an NDJSON line, or record() as a CSV record.
+func (s *exportStream) write(v any, record func() []string) error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	var err error
+	if s.cw != nil {
+		err = s.cw.Write(record())
+	} else {
+		err = s.enc.Encode(v)
+	}
+	if err != nil {
+		return err
+	}
+	if s.rows++; s.rows%exportFlushRows == 0 {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *exportStream) flush() error {
+	if s.cw != nil {
+		s.cw.Flush()
+		if err := s.cw.Error(); err != nil {
+			return err
+		}
+	}
+	if err := s.bw.Flush(); err != nil {
+		return err
+	}
+	if err := http.NewResponseController(s.w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// finish completes the response and sets the continuation trailer when the
+// export was cut by limit. A failure after the first row aborts the
+// connection, so a client never mistakes a truncated export for a full one.
+func (s *exportStream) finish(next *string, err error, subject string) {
+	if err != nil && !s.started {
+		writeError(s.w, s.r, err, subject)
+		return
+	}
+	if err == nil && !s.started {
+		err = s.start() // empty export: headers (and the CSV header row) only
+	}
+	if err == nil {
+		err = s.flush()
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("path", s.r.URL.Path).Int("rows", s.rows).Msg("export aborted")
+		panic(http.ErrAbortHandler)
+	}
+	if next != nil {
+		s.w.Header().Set(exportCursorTrailer, *next)
+	}
+}
+
+func hotelCSVRecord(hv domain.HotelView) []string {
+	var lat, lon, brandID, brandName string
+	if hv.Coords != nil {
+		lat, lon = csvFloat(&hv.Coords.Lat), csvFloat(&hv.Coords.Lon)
+	}
+	if hv.Brand != nil {
+		brandID, brandName = strconv.FormatInt(hv.Brand.ID, 10), csvStr(hv.Brand.Name)
+	}
+	codes := make([]string, len(hv.CanonicalAmenities))
+	for i, a := range hv.CanonicalAmenities {
+		codes[i] = a.Code
+	}
+	var stars string
+	if hv.Stars != nil {
+		stars = strconv.Itoa(*hv.Stars)
+	}
+	return []string{
+		strconv.FormatInt(hv.ID, 10), csvStr(hv.Name), csvStr(hv.Description), csvStr(hv.Policies),
+		stars, csvStr(hv.Country), csvStr(hv.City), csvStr(hv.Address),
+		lat, lon, brandID, brandName, csvStr(hv.MainPhoto), strings.Join(codes, "|"), hv.Language,
+	}
+}
+
+func reviewCSVRecord(rv domain.Review) []string {
+	var created string
+	if !rv.CreatedAt.IsZero() {
+		created = rv.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(rv.ID, 10), strconv.FormatInt(rv.PropertyID, 10),
+		csvStr(rv.Source), csvStr(rv.SourceID), csvStr(rv.Author), csvFloat(rv.Rating),
+		csvStr(rv.Lang), csvStr(rv.Title), csvStr(rv.Text), created,
+	}
+}
+
+func csvStr(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+func csvFloat(p *float64) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*p, 'f', -1, 64)
+}
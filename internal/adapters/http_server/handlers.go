@@ -5,51 +5,213 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/netip"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"cupid_hotel/api"
 	"cupid_hotel/internal/app"
 	"cupid_hotel/internal/domain"
 	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog/log"
 )
 
-type Handlers struct{ Q *app.QueryService }
+type Handlers struct {
+	Q *app.QueryService
+	W *app.WebhookService   // nil leaves the /webhooks routes unmounted
+	A *app.AuthService      // nil serves without API keys (and without /keys)
+	J *app.IngestJobService // nil leaves the /admin routes unmounted
+	H *app.HealthService    // nil leaves /readyz unmounted
 
+	// TrustedProxies are the peers whose X-Forwarded-For is believed when
+	// keying per-client limits; from any other peer RemoteAddr is the client.
+	TrustedProxies []netip.Prefix
+
+	// Spec, when set, is served at /openapi.yaml and request parameters are
+	// validated against it.
+	Spec *api.Spec
+}
+
+// problem is an RFC 7807 body. Instance is the request path; RequestID
+// matches the X-Request-Id of the request for log correlation.
 type problem struct {
-	Type   string `json:"type"`
-	Title  string `json:"title"`
-	Status int    `json:"status"`
-	Detail string `json:"detail,omitempty"`
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Status    int             `json:"status"`
+	Detail    string          `json:"detail,omitempty"`
+	Instance  string          `json:"instance,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Errors    []fieldErrorDTO `json:"errors,omitempty"` // validation failures
+}
+
+type fieldErrorDTO struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// problemTypes are the documented problem type URIs (see api/openapi.yaml),
+// relative to the API host.
+var problemTypes = map[int]string{
+	http.StatusBadRequest:          "/problems/invalid-argument",
+	http.StatusUnauthorized:        "/problems/unauthenticated",
+	http.StatusForbidden:           "/problems/forbidden",
+	http.StatusNotFound:            "/problems/not-found",
+	http.StatusNotAcceptable:       "/problems/not-acceptable",
+	http.StatusGone:                "/problems/gone",
+	http.StatusTooManyRequests:     "/problems/rate-limited",
+	http.StatusBadGateway:          "/problems/upstream",
+	http.StatusServiceUnavailable:  "/problems/unavailable",
+	http.StatusInternalServerError: "/problems/internal",
 }
 
+// /v1 is frozen and scheduled for removal in favour of /v2.
+var (
+	v1DeprecatedAt = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+	v1Sunset       = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
 func (s *Server) MountHandlers(h *Handlers) {
 	s.mux.Get("/healthz", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(200); _, _ = w.Write([]byte("ok")) })
-	s.mux.Get("/v1/hotels/{id}", h.getHotel)
-	s.mux.Get("/v1/hotels/{id}/reviews", h.listReviews)
+	if h.H != nil {
+		s.mux.Get("/readyz", h.readyz)
+	}
+	if h.Spec != nil {
+		s.mux.Get("/openapi.yaml", serveSpec(h.Spec))
+	}
+	if h.J != nil {
+		s.mux.Route("/admin", h.adminRoutes)
+	}
+
+	// Both versions share handlers; only the representation differs (see present).
+	s.mux.Route("/v1", func(r chi.Router) {
+		r.Use(withAPIVersion(1), Deprecated(v1DeprecatedAt, v1Sunset, "/v1", "/v2"))
+		h.routes(r)
+	})
+	s.mux.Route("/v2", func(r chi.Router) {
+		r.Use(withAPIVersion(2))
+		h.routes(r)
+	})
 }
 
-func selectLang(al string) string {
-	s := strings.ToLower(al)
-	if strings.HasPrefix(s, "fr") {
-		return "fr"
+// routes groups endpoints by the API key scope they require.
+func (h *Handlers) routes(r chi.Router) {
+	if h.A != nil {
+		r.Use(h.authenticate)
 	}
-	if strings.HasPrefix(s, "es") {
-		return "es"
+	if h.Spec != nil {
+		r.Use(validateParams(h.Spec))
 	}
-	return "en"
+	r.Group(func(r chi.Router) {
+		r.Use(requireScope(domain.ScopeRead))
+		r.Get("/hotels", h.listHotels)
+		r.Post("/hotels:batchGet", h.batchGetHotels)
+		r.Get("/hotels/facets", h.hotelFacets)
+		r.Get("/hotels/search", h.searchHotels)
+		r.Get("/hotels/nearby", h.nearbyHotels)
+		r.Get("/hotels/{id}", h.getHotel)
+		r.Get("/amenities", h.listAmenities)
+		r.Get("/brands", h.listBrands)
+		r.Get("/brands/{id}/hotels", h.listBrandHotels)
+		r.Get("/hotels/{id}/images", h.listImages)
+		r.Get("/hotels/{id}/similar", h.similarHotels)
+		r.Get("/hotels/{id}/reviews", h.listReviews)
+		r.Get("/hotels/{id}/reviews/summary", h.reviewSummary)
+		r.Get("/changes", h.listChanges)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(requireScope(domain.ScopeExport))
+		r.Get("/export/hotels", h.exportHotels)
+		r.Get("/export/reviews", h.exportReviews)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(requireScope(domain.ScopeAdmin))
+		if h.W != nil {
+			r.Route("/webhooks", h.webhookRoutes)
+		}
+		if h.A != nil {
+			r.Route("/keys", h.apiKeyRoutes)
+		}
+	})
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, title, detail string) {
+	writeProblemBody(w, problem{Type: problemType(status), Title: title, Status: status, Detail: detail}, r)
 }
 
-func writeProblem(w http.ResponseWriter, status int, title, detail string) {
+func writeProblemBody(w http.ResponseWriter, p problem, r *http.Request) {
+	p.Instance = r.URL.Path
+	p.RequestID = chimw.GetReqID(r.Context())
 	w.Header().Set("Content-Type", "application/problem+json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(problem{Type: "about:blank", Title: title, Status: status, Detail: detail}); err != nil {
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
 		log.Error().Err(err).Msg("write JSON problem response failed")
 	}
 }
 
+func problemType(status int) string {
+	if t, ok := problemTypes[status]; ok {
+		return t
+	}
+	return "about:blank"
+}
+
+// writeInvalid rejects one request parameter with a 400 problem.
+func writeInvalid(w http.ResponseWriter, r *http.Request, field, reason string) {
+	writeError(w, r, domain.Invalid(field, reason), "")
+}
+
+// writeError maps a domain error to its problem response; subject names the
+// resource in 404/410 details ("hotel not found"). Unclassified errors are
+// logged and answered 500.
+func writeError(w http.ResponseWriter, r *http.Request, err error, subject string) {
+	var ve *domain.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		p := problem{Type: problemType(http.StatusBadRequest), Title: "Invalid request", Status: http.StatusBadRequest}
+		reasons := make([]string, len(ve.Fields))
+		for i, f := range ve.Fields {
+			p.Errors = append(p.Errors, fieldErrorDTO{Field: f.Field, Reason: f.Reason})
+			reasons[i] = f.Reason
+		}
+		if len(ve.Fields) == 1 {
+			p.Title = "Invalid " + ve.Fields[0].Field
+		}
+		p.Detail = strings.Join(reasons, "; ")
+		writeProblemBody(w, p, r)
+	case errors.Is(err, domain.ErrInvalidArgument):
+		writeProblem(w, r, http.StatusBadRequest, "Invalid request", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		writeProblem(w, r, http.StatusNotFound, "Not Found", subject+" not found")
+	case errors.Is(err, domain.ErrGone):
+		writeProblem(w, r, http.StatusGone, "Gone", subject+" is no longer available")
+	case errors.Is(err, domain.ErrUnauthenticated):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="cupid"`)
+		writeProblem(w, r, http.StatusUnauthorized, "Unauthorized", err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		writeProblem(w, r, http.StatusForbidden, "Forbidden", err.Error())
+	case errors.Is(err, domain.ErrRateLimited):
+		writeProblem(w, r, http.StatusTooManyRequests, "Too Many Requests", "rate limit exceeded")
+	case errors.Is(err, domain.ErrUpstream):
+		log.Warn().Err(err).Str("path", r.URL.Path).Msg("upstream rejected the call")
+		writeProblem(w, r, http.StatusBadGateway, "Bad Gateway", "the hotel provider rejected the request")
+	case errors.Is(err, domain.ErrUnavailable):
+		log.Warn().Err(err).Str("path", r.URL.Path).Msg("dependency unavailable")
+		w.Header().Set("Retry-After", "5")
+		writeProblem(w, r, http.StatusServiceUnavailable, "Service Unavailable", "a backing service is unavailable, retry later")
+	default:
+		log.Error().Err(err).Str("path", r.URL.Path).Str("request_id", chimw.GetReqID(r.Context())).Msg("request failed")
+		writeProblem(w, r, http.StatusInternalServerError, "Internal Server Error", "could not load "+subject)
+	}
+}
+
 // calcETagAndBody marshals once and hashes once, returning both ETag and body.
+// Used by listings, whose content has no single stored version; per-hotel
+// and review reads derive strong ETags from versions instead (strongETag).
 func calcETagAndBody(v any) (string, []byte) {
 	body, err := json.Marshal(v)
 	if err != nil {
@@ -63,31 +225,127 @@ func calcETagAndBody(v any) (string, []byte) {
 }
 
 func (h *Handlers) getHotel(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
-	lang := r.URL.Query().Get("lang")
-	if lang == "" {
-		lang = selectLang(r.Header.Get("Accept-Language"))
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeInvalid(w, r, "id", "id must be a number")
+		return
+	}
+	lang, ok := h.resolveLang(w, r, r.URL.Query().Get("lang"))
+	if !ok {
+		return
+	}
+	// Validators come from the stored version, so 304s never load the view.
+	ver, err := h.Q.HotelVersion(r.Context(), id, lang)
+	if err != nil {
+		writeError(w, r, err, "hotel")
+		return
+	}
+	etag := strongETag(r, ver, "hotel", lang)
+	setValidators(w, etag, ver.Modified)
+	// The version also knows the languages the fields are served in, so HEAD
+	// and GET carry the same headers.
+	contentLang := ver.Language
+	if contentLang == "" {
+		contentLang = lang
+	}
+	w.Header().Set("Content-Language", contentLang)
+	if notModified(r, etag, ver.Modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// HEAD answers from the version alone.
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
 	}
 	resp, err := h.Q.GetHotel(r.Context(), id, lang)
 	if err != nil {
-		writeProblem(w, http.StatusNotFound, "Not Found", "hotel not found")
+		writeError(w, r, err, "hotel")
+		return
+	}
+	body, err := json.Marshal(present(r, resp))
+	if err != nil {
+		writeError(w, r, err, "hotel")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write getHotel body")
+	}
+}
+
+// maxBatchIDs bounds one batch lookup (one IN list, one MGET).
+const maxBatchIDs = 100
+
+type batchGetRequest struct {
+	IDs  []int64 `json:"ids"`
+	Lang string  `json:"lang"`
+}
+
+func (h *Handlers) batchGetHotels(w http.ResponseWriter, r *http.Request) {
+	var req batchGetRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeInvalid(w, r, "body", `body must be {"ids":[...],"lang":"en"}`)
+		return
+	}
+	if slices.ContainsFunc(req.IDs, func(id int64) bool { return id <= 0 }) {
+		writeInvalid(w, r, "ids", "ids must be positive hotel ids")
+		return
+	}
+	lang, ok := h.resolveLang(w, r, req.Lang)
+	if !ok {
+		return
+	}
+	h.writeBatch(w, r, req.IDs, lang)
+}
+
+// parseIDs parses a comma-separated id list ("1,2,3").
+func parseIDs(s string) ([]int64, bool) {
+	parts := strings.Split(s, ",")
+	ids := make([]int64, 0, len(parts))
+	for _, p := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(p), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+func (h *Handlers) writeBatch(w http.ResponseWriter, r *http.Request, ids []int64, lang string) {
+	if len(ids) == 0 || len(ids) > maxBatchIDs {
+		writeInvalid(w, r, "ids", "ids must list between 1 and 100 hotel ids")
+		return
+	}
+
+	out, err := h.Q.GetHotels(r.Context(), ids, lang)
+	if err != nil {
+		writeError(w, r, err, "hotels")
 		return
 	}
 
-	etag, body := calcETagAndBody(resp)
-	// If client already has this version, short-circuit.
-	if inm := r.Header.Get("If-None-Match"); inm != "" && inm == etag {
-		w.Header().Set("ETag", etag) // include ETag on 304
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
+		w.Header().Set("ETag", etag)
 		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
+	var served []domain.HotelView
+	for _, it := range out.Items {
+		if it.Hotel != nil {
+			served = append(served, *it.Hotel)
+		}
+	}
 	w.Header().Set("ETag", etag)
-	w.Header().Set("Content-Language", resp.Language)
+	w.Header().Set("Content-Language", contentLanguage(lang, served...))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(body); err != nil {
-		log.Error().Err(err).Msg("failed to write getHotel body")
+		log.Error().Err(err).Msg("failed to write batchGetHotels body")
 	}
 }
 
@@ -95,39 +353,643 @@ func (h *Handlers) listReviews(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		writeProblem(w, http.StatusBadRequest, "Invalid ID", "id must be a number")
+		writeInvalid(w, r, "id", "id must be a number")
 		return
 	}
 
+	qs := r.URL.Query()
 	limit := 50
-	if ls := r.URL.Query().Get("limit"); ls != "" {
+	if ls := qs.Get("limit"); ls != "" {
 		l, err := strconv.Atoi(ls)
 		if err != nil || l <= 0 || l > 200 {
-			writeProblem(w, http.StatusBadRequest, "Invalid limit", "limit must be an integer between 1 and 200")
+			writeInvalid(w, r, "limit", "limit must be an integer between 1 and 200")
 			return
 		}
 		limit = l
 	}
 
-	// Newest first; aligns with DB index on (property_id, created_at, id)
-	page := domain.PageQuery{Limit: limit, Cursor: nil, Sort: "-created_at"}
+	page := domain.PageQuery{
+		Limit:  limit,
+		Cursor: optString(qs.Get("cursor")),
+		Sort:   domain.SortNewest, // aligns with DB index on (property_id, created_at)
+		Lang:   optString(qs.Get("lang")),
+		Source: optString(qs.Get("source")),
+	}
+	if so := qs.Get("sort"); so != "" {
+		switch so {
+		case domain.SortNewest, domain.SortOldest, domain.SortRatingDesc, domain.SortRatingAsc:
+			page.Sort = so
+		default:
+			writeInvalid(w, r, "sort", "sort must be one of -created_at, created_at, -rating, rating")
+			return
+		}
+	}
+	if mr := qs.Get("min_rating"); mr != "" {
+		f, err := strconv.ParseFloat(mr, 64)
+		if err != nil || f < 0 || f > 10 {
+			writeInvalid(w, r, "min_rating", "min_rating must be a number between 0 and 10")
+			return
+		}
+		page.MinRating = &f
+	}
+
+	ver, err := h.Q.ReviewsVersion(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "hotel")
+		return
+	}
+	etag := strongETag(r, ver, "reviews", qs.Encode())
+	setValidators(w, etag, ver.Modified)
+	if notModified(r, etag, ver.Modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	out, err := h.Q.ListReviews(r.Context(), id, page)
 	if err != nil {
-		writeProblem(w, http.StatusNotFound, "Not Found", "reviews not found")
+		writeError(w, r, err, "hotel")
+		return
+	}
+	body, err := json.Marshal(present(r, out))
+	if err != nil {
+		writeError(w, r, err, "reviews")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write listReviews body")
+	}
+}
+
+func (h *Handlers) listHotels(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	lang, ok := h.resolveLang(w, r, qs.Get("lang"))
+	if !ok {
+		return
+	}
+
+	// ?ids= turns the listing into a batch lookup.
+	if is := qs.Get("ids"); is != "" {
+		ids, ok := parseIDs(is)
+		if !ok {
+			writeInvalid(w, r, "ids", "ids must be a comma-separated list of hotel ids")
+			return
+		}
+		h.writeBatch(w, r, ids, lang)
+		return
+	}
+	h.writeHotelsPage(w, r, lang, nil)
+}
+
+// listBrandHotels is /hotels scoped to one chain; unknown brands are 404.
+func (h *Handlers) listBrandHotels(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeInvalid(w, r, "id", "id must be a number")
+		return
+	}
+	lang, ok := h.resolveLang(w, r, r.URL.Query().Get("lang"))
+	if !ok {
 		return
 	}
+	if _, err := h.Q.GetBrand(r.Context(), id); err != nil {
+		writeError(w, r, err, "brand")
+		return
+	}
+	h.writeHotelsPage(w, r, lang, &id)
+}
 
-	etag, body := calcETagAndBody(out)
-	if inm := r.Header.Get("If-None-Match"); inm != "" && inm == etag {
+// writeHotelsPage serves a filtered listing page; brandID (when set) overrides
+// the brand_id filter.
+func (h *Handlers) writeHotelsPage(w http.ResponseWriter, r *http.Request, lang string, brandID *int64) {
+	qs := r.URL.Query()
+	limit := 20
+	if ls := qs.Get("limit"); ls != "" {
+		l, err := strconv.Atoi(ls)
+		if err != nil || l <= 0 || l > 100 {
+			writeInvalid(w, r, "limit", "limit must be an integer between 1 and 100")
+			return
+		}
+		limit = l
+	}
+
+	q, ok := parseHotelFilters(w, r, lang)
+	if !ok {
+		return
+	}
+	if brandID != nil {
+		q.BrandID = brandID
+	}
+	q.Limit = limit
+	q.Cursor = optString(qs.Get("cursor"))
+
+	out, err := h.Q.ListHotels(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err, "hotels")
+		return
+	}
+
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
 		w.Header().Set("ETag", etag)
 		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Language", lang)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(body); err != nil {
-		log.Error().Err(err).Msg("failed to write listReviews body")
+		log.Error().Err(err).Msg("failed to write listHotels body")
+	}
+}
+
+// parseHotelFilters reads the listing filters shared by /hotels and
+// /hotels/facets; on invalid input it writes a 400 problem and returns false.
+func parseHotelFilters(w http.ResponseWriter, r *http.Request, lang string) (domain.HotelsQuery, bool) {
+	qs := r.URL.Query()
+	q := domain.HotelsQuery{
+		Lang:    lang,
+		Q:       optString(qs.Get("q")),
+		Country: optString(qs.Get("country")),
+		City:    optString(qs.Get("city")),
+	}
+	// amenity=pool,spa (or repeated); all codes must match unless amenity_match=any.
+	for _, v := range qs["amenity"] {
+		for _, a := range strings.Split(v, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				q.Amenities = append(q.Amenities, a)
+			}
+		}
+	}
+	switch m := qs.Get("amenity_match"); m {
+	case "", domain.AmenityMatchAll, domain.AmenityMatchAny:
+		q.AmenityMatch = m
+	default:
+		writeInvalid(w, r, "amenity_match", "amenity_match must be all or any")
+		return domain.HotelsQuery{}, false
+	}
+	if ss := qs.Get("stars"); ss != "" {
+		st, err := strconv.Atoi(ss)
+		if err != nil || st < 1 || st > 5 {
+			writeInvalid(w, r, "stars", "stars must be an integer between 1 and 5")
+			return domain.HotelsQuery{}, false
+		}
+		q.Stars = &st
+	}
+	if bs := qs.Get("brand_id"); bs != "" {
+		b, err := strconv.ParseInt(bs, 10, 64)
+		if err != nil || b <= 0 {
+			writeInvalid(w, r, "brand_id", "brand_id must be a positive integer")
+			return domain.HotelsQuery{}, false
+		}
+		q.BrandID = &b
+	}
+	return q, true
+}
+
+// hotelFacets counts hotels per country, city, stars and amenity under the
+// same filters as listHotels; limit caps the buckets returned per facet.
+func (h *Handlers) hotelFacets(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	lang, ok := h.resolveLang(w, r, qs.Get("lang"))
+	if !ok {
+		return
+	}
+
+	limit := 20
+	if ls := qs.Get("limit"); ls != "" {
+		l, err := strconv.Atoi(ls)
+		if err != nil || l <= 0 || l > 100 {
+			writeInvalid(w, r, "limit", "limit must be an integer between 1 and 100")
+			return
+		}
+		limit = l
+	}
+
+	q, ok := parseHotelFilters(w, r, lang)
+	if !ok {
+		return
+	}
+	q.Limit = limit
+
+	out, err := h.Q.HotelFacets(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err, "facets")
+		return
+	}
+
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write hotelFacets body")
+	}
+}
+
+func (h *Handlers) listImages(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeInvalid(w, r, "id", "id must be a number")
+		return
+	}
+
+	out, err := h.Q.ListImages(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "hotel")
+		return
+	}
+
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write listImages body")
+	}
+}
+
+func (h *Handlers) listAmenities(w http.ResponseWriter, r *http.Request) {
+	lang, ok := h.resolveLang(w, r, r.URL.Query().Get("lang"))
+	if !ok {
+		return
+	}
+
+	out, err := h.Q.ListAmenities(r.Context(), lang)
+	if err != nil {
+		writeError(w, r, err, "amenities")
+		return
+	}
+
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write listAmenities body")
+	}
+}
+
+func (h *Handlers) listBrands(w http.ResponseWriter, r *http.Request) {
+	out, err := h.Q.ListBrands(r.Context())
+	if err != nil {
+		writeError(w, r, err, "brands")
+		return
+	}
+
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write listBrands body")
+	}
+}
+
+func (h *Handlers) searchHotels(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	text := strings.TrimSpace(qs.Get("q"))
+	if text == "" {
+		writeInvalid(w, r, "q", "q is required")
+		return
+	}
+	lang, ok := h.resolveLang(w, r, qs.Get("lang"))
+	if !ok {
+		return
+	}
+
+	mode := domain.SearchNatural
+	if m := qs.Get("mode"); m != "" {
+		if m != domain.SearchNatural && m != domain.SearchBoolean {
+			writeInvalid(w, r, "mode", "mode must be natural or boolean")
+			return
+		}
+		mode = m
+	}
+
+	limit := 20
+	if ls := qs.Get("limit"); ls != "" {
+		l, err := strconv.Atoi(ls)
+		if err != nil || l <= 0 || l > 50 {
+			writeInvalid(w, r, "limit", "limit must be an integer between 1 and 50")
+			return
+		}
+		limit = l
+	}
+
+	q := domain.SearchQuery{
+		Lang:    lang,
+		Q:       text,
+		Mode:    mode,
+		Country: optString(qs.Get("country")),
+		City:    optString(qs.Get("city")),
+		Limit:   limit,
+		Cursor:  optString(qs.Get("cursor")),
+	}
+	if ss := qs.Get("stars"); ss != "" {
+		st, err := strconv.Atoi(ss)
+		if err != nil || st < 1 || st > 5 {
+			writeInvalid(w, r, "stars", "stars must be an integer between 1 and 5")
+			return
+		}
+		q.Stars = &st
+	}
+
+	out, err := h.Q.SearchHotels(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err, "hotels")
+		return
+	}
+
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write searchHotels body")
+	}
+}
+
+func (h *Handlers) nearbyHotels(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	lang, ok := h.resolveLang(w, r, qs.Get("lang"))
+	if !ok {
+		return
+	}
+
+	q := domain.NearbyQuery{Lang: lang, Limit: 20}
+	if ls := qs.Get("limit"); ls != "" {
+		l, err := strconv.Atoi(ls)
+		if err != nil || l <= 0 || l > 100 {
+			writeInvalid(w, r, "limit", "limit must be an integer between 1 and 100")
+			return
+		}
+		q.Limit = l
+	}
+
+	if bs := qs.Get("bbox"); bs != "" {
+		b, ok := parseBBox(bs)
+		if !ok {
+			writeInvalid(w, r, "bbox", "bbox must be minLon,minLat,maxLon,maxLat in degrees")
+			return
+		}
+		q.BBox = &b
+		// Without an explicit point, distances are measured from the viewport centre.
+		q.Lat, q.Lon = (b.MinLat+b.MaxLat)/2, (b.MinLon+b.MaxLon)/2
+	}
+
+	latS, lonS := qs.Get("lat"), qs.Get("lon")
+	if latS != "" || lonS != "" {
+		lat, err1 := strconv.ParseFloat(latS, 64)
+		lon, err2 := strconv.ParseFloat(lonS, 64)
+		if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			writeInvalid(w, r, "lat", "lat and lon must both be given, in degrees")
+			return
+		}
+		q.Lat, q.Lon = lat, lon
+	}
+	if rs := qs.Get("radius_km"); rs != "" {
+		rk, err := strconv.ParseFloat(rs, 64)
+		if err != nil || rk <= 0 || rk > 500 {
+			writeInvalid(w, r, "radius_km", "radius_km must be a number in (0, 500]")
+			return
+		}
+		if latS == "" {
+			writeInvalid(w, r, "lat", "radius_km requires lat and lon")
+			return
+		}
+		q.RadiusKm = &rk
+	}
+	if q.RadiusKm == nil && q.BBox == nil {
+		writeInvalid(w, r, "radius_km", "provide lat, lon and radius_km, or bbox")
+		return
+	}
+
+	out, err := h.Q.NearbyHotels(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err, "hotels")
+		return
+	}
+
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write nearbyHotels body")
+	}
+}
+
+// similarHotels ranks hotels like {id} from its city, or within radius_km.
+func (h *Handlers) similarHotels(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeInvalid(w, r, "id", "id must be a number")
+		return
+	}
+	qs := r.URL.Query()
+	lang, ok := h.resolveLang(w, r, qs.Get("lang"))
+	if !ok {
+		return
+	}
+
+	q := domain.SimilarQuery{ID: id, Lang: lang, Limit: 10}
+	if ls := qs.Get("limit"); ls != "" {
+		l, err := strconv.Atoi(ls)
+		if err != nil || l <= 0 || l > 50 {
+			writeInvalid(w, r, "limit", "limit must be an integer between 1 and 50")
+			return
+		}
+		q.Limit = l
+	}
+	if rs := qs.Get("radius_km"); rs != "" {
+		rk, err := strconv.ParseFloat(rs, 64)
+		if err != nil || rk <= 0 || rk > 500 {
+			writeInvalid(w, r, "radius_km", "radius_km must be a number in (0, 500]")
+			return
+		}
+		q.RadiusKm = &rk
+	}
+
+	out, err := h.Q.SimilarHotels(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err, "hotel")
+		return
+	}
+
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write similarHotels body")
+	}
+}
+
+// parseBBox parses "minLon,minLat,maxLon,maxLat" (GeoJSON order).
+func parseBBox(s string) (domain.BBox, bool) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return domain.BBox{}, false
+	}
+	var f [4]float64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return domain.BBox{}, false
+		}
+		f[i] = v
+	}
+	b := domain.BBox{MinLon: f[0], MinLat: f[1], MaxLon: f[2], MaxLat: f[3]}
+	if b.MinLat < -90 || b.MaxLat > 90 || b.MinLon < -180 || b.MaxLon > 180 ||
+		b.MinLat >= b.MaxLat || b.MinLon >= b.MaxLon {
+		return domain.BBox{}, false
+	}
+	return b, true
+}
+
+// optString maps an empty query value to nil (filter not set).
+func optString(v string) *string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func (h *Handlers) reviewSummary(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeInvalid(w, r, "id", "id must be a number")
+		return
+	}
+
+	ver, err := h.Q.ReviewsVersion(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "hotel")
+		return
+	}
+	etag := strongETag(r, ver, "reviews/summary")
+	setValidators(w, etag, ver.Modified)
+	if notModified(r, etag, ver.Modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	out, err := h.Q.ReviewSummary(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "hotel")
+		return
+	}
+	body, err := json.Marshal(present(r, out))
+	if err != nil {
+		writeError(w, r, err, "review summary")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write reviewSummary body")
+	}
+}
+
+// listChanges serves the change feed: entries after the `since` token, in
+// commit order. Clients store `next` and poll again with it.
+func (h *Handlers) listChanges(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	var since int64
+	if ss := qs.Get("since"); ss != "" {
+		v, err := strconv.ParseInt(ss, 10, 64)
+		if err != nil || v < 0 {
+			writeInvalid(w, r, "since", "since must be a token returned by a previous /changes call")
+			return
+		}
+		since = v
+	}
+	limit := 100
+	if ls := qs.Get("limit"); ls != "" {
+		l, err := strconv.Atoi(ls)
+		if err != nil || l <= 0 || l > 1000 {
+			writeInvalid(w, r, "limit", "limit must be an integer between 1 and 1000")
+			return
+		}
+		limit = l
+	}
+
+	out, err := h.Q.ListChanges(r.Context(), since, limit)
+	if err != nil {
+		writeError(w, r, err, "changes")
+		return
+	}
+
+	etag, body := calcETagAndBody(present(r, out))
+	if notModified(r, etag, time.Time{}) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write listChanges body")
 	}
 }
@@ -2,9 +2,21 @@ package httpserver_test
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	httpserver "cupid_hotel/internal/adapters/http_server"
+	"cupid_hotel/internal/adapters/ratelimit"
 	"cupid_hotel/internal/app"
 	"cupid_hotel/internal/domain"
 )
@@ -12,22 +24,193 @@ import (
 // ---- fakes ----
 
 type fakeRepo struct {
-	hv domain.HotelView
-	rp domain.ReviewsPage
+	hv  domain.HotelView
+	rp  domain.ReviewsPage
+	hp  domain.HotelsPage
+	sp  domain.SearchPage
+	np  domain.NearbyPage
+	hf  domain.HotelFacets
+	tx  []domain.AmenityDef
+	im  []domain.HotelImage
+	br  []domain.Brand
+	sc  []domain.SimilarCandidate
+	err error // returned by GetHotel, ListReviews and the version lookups when set
+	ver domain.Version
+
+	exH    []domain.HotelView // export rows
+	exR    []domain.Review
+	exNext *string
+	lastEQ domain.ExportQuery
+
+	changes    []domain.Change
+	lastSince  int64
+	lastLimitC int
+	rs         domain.ReviewSummary
+	ra         [][]byte
+
+	byID    map[int64]domain.HotelView
+	i18n    map[string]domain.HotelView // per-language view of one hotel, when set
+	lastIDs []int64
+
+	lastHQ     domain.HotelsQuery
+	lastNQ     domain.NearbyQuery
+	lastPQ     domain.PageQuery
+	listCalls  int
+	revCalls   int
+	hotelCalls int
 }
 
 func (f *fakeRepo) UpsertProperty(ctx context.Context, h domain.Hotel) error    { return nil }
 func (f *fakeRepo) UpsertI18n(ctx context.Context, i domain.HotelI18n) error    { return nil }
 func (f *fakeRepo) UpsertReviews(ctx context.Context, rs []domain.Review) error { return nil }
+func (f *fakeRepo) DeactivateProperty(ctx context.Context, id int64) error      { return nil }
 func (f *fakeRepo) GetHotel(ctx context.Context, id int64, lang string) (domain.HotelView, error) {
+	f.hotelCalls++
+	if f.err != nil {
+		return domain.HotelView{}, f.err
+	}
+	if hv, ok := f.i18n[lang]; ok {
+		return hv, nil
+	}
 	return f.hv, nil
 }
+func (f *fakeRepo) GetHotels(ctx context.Context, ids []int64, lang string) ([]domain.HotelView, error) {
+	f.lastIDs = ids
+	var out []domain.HotelView
+	if f.i18n != nil {
+		if hv, ok := f.i18n[lang]; ok && len(ids) > 0 && ids[0] == hv.ID {
+			out = append(out, hv)
+		}
+		return out, nil
+	}
+	for _, id := range ids {
+		if hv, ok := f.byID[id]; ok {
+			out = append(out, hv)
+		}
+	}
+	return out, nil
+}
 func (f *fakeRepo) ListHotels(ctx context.Context, q domain.HotelsQuery) (domain.HotelsPage, error) {
-	return domain.HotelsPage{}, nil
+	f.lastHQ = q
+	f.listCalls++
+	return f.hp, nil
+}
+func (f *fakeRepo) HotelFacets(ctx context.Context, q domain.HotelsQuery) (domain.HotelFacets, error) {
+	f.lastHQ = q
+	f.listCalls++
+	return f.hf, nil
+}
+func (f *fakeRepo) AmenityTaxonomy(ctx context.Context) ([]domain.AmenityDef, error) {
+	return f.tx, nil
+}
+func (f *fakeRepo) ListImages(ctx context.Context, id int64) ([]domain.HotelImage, error) {
+	if f.im == nil {
+		return nil, domain.ErrNotFound
+	}
+	return f.im, nil
+}
+func (f *fakeRepo) SimilarCandidates(ctx context.Context, q domain.SimilarQuery, afterID int64, max int) ([]domain.SimilarCandidate, error) {
+	return f.sc, nil
+}
+func (f *fakeRepo) ListBrands(ctx context.Context) ([]domain.Brand, error) { return f.br, nil }
+func (f *fakeRepo) GetBrand(ctx context.Context, id int64) (domain.Brand, error) {
+	for _, b := range f.br {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return domain.Brand{}, domain.ErrNotFound
+}
+func (f *fakeRepo) SearchHotels(ctx context.Context, q domain.SearchQuery) (domain.SearchPage, error) {
+	return f.sp, nil
+}
+func (f *fakeRepo) NearbyHotels(ctx context.Context, q domain.NearbyQuery) (domain.NearbyPage, error) {
+	f.lastNQ = q
+	return f.np, nil
 }
 func (f *fakeRepo) ListReviews(ctx context.Context, id int64, pg domain.PageQuery) (domain.ReviewsPage, error) {
+	f.lastPQ = pg
+	f.revCalls++
+	if f.err != nil {
+		return domain.ReviewsPage{}, f.err
+	}
 	return f.rp, nil
 }
+func (f *fakeRepo) ReviewSummary(ctx context.Context, id int64) (domain.ReviewSummary, error) {
+	return f.rs, nil
+}
+func (f *fakeRepo) ReviewAspects(ctx context.Context, id int64) ([][]byte, error) {
+	return f.ra, nil
+}
+func (f *fakeRepo) ExportHotels(ctx context.Context, q domain.ExportQuery, fn func(domain.HotelView) error) (*string, error) {
+	f.lastEQ = q
+	if q.Cursor != nil && *q.Cursor == "bad" {
+		return nil, domain.ErrInvalidCursor
+	}
+	for _, hv := range f.exH {
+		if err := fn(hv); err != nil {
+			return nil, err
+		}
+	}
+	return f.exNext, nil
+}
+func (f *fakeRepo) ExportReviews(ctx context.Context, q domain.ExportQuery, fn func(domain.Review) error) (*string, error) {
+	f.lastEQ = q
+	for _, rv := range f.exR {
+		if err := fn(rv); err != nil {
+			return nil, err
+		}
+	}
+	return f.exNext, nil
+}
+func (f *fakeRepo) ListChanges(ctx context.Context, since int64, limit int) (domain.ChangesPage, error) {
+	f.lastSince, f.lastLimitC = since, limit
+	page := domain.ChangesPage{Items: []domain.Change{}, Next: since}
+	for _, c := range f.changes {
+		if c.Seq <= since {
+			continue
+		}
+		if len(page.Items) == limit {
+			page.HasMore = true
+			break
+		}
+		page.Items = append(page.Items, c)
+		page.Next = c.Seq
+	}
+	return page, nil
+}
+func (f *fakeRepo) HotelVersion(ctx context.Context, id int64, langs []string) (domain.Version, error) {
+	if f.err != nil {
+		return domain.Version{}, f.err
+	}
+	v := f.ver
+	// like the repo: each translated field from the first language having it
+	var served []string
+	for _, field := range []func(domain.HotelView) *string{
+		func(h domain.HotelView) *string { return h.Name },
+		func(h domain.HotelView) *string { return h.Description },
+		func(h domain.HotelView) *string { return h.Policies },
+	} {
+		for _, l := range langs {
+			if hv, ok := f.i18n[l]; ok && field(hv) != nil {
+				if !slices.Contains(served, l) {
+					served = append(served, l)
+				}
+				break
+			}
+		}
+	}
+	if len(served) > 0 {
+		v.Language = strings.Join(served, ", ")
+	}
+	return v, nil
+}
+func (f *fakeRepo) ReviewsVersion(ctx context.Context, id int64) (domain.Version, error) {
+	if f.err != nil {
+		return domain.Version{}, f.err
+	}
+	return f.ver, nil
+}
 func (f *fakeRepo) LogMiss(ctx context.Context, id int64, status int, reason string) error {
 	// no-op for tests
 	return nil
@@ -50,6 +233,28 @@ func (c *fakeCache) Get(ctx context.Context, key string, dst any) (bool, error)
 		*d = v.(domain.HotelView)
 	case *domain.ReviewsPage:
 		*d = v.(domain.ReviewsPage)
+	case *domain.HotelsPage:
+		*d = v.(domain.HotelsPage)
+	case *domain.SearchPage:
+		*d = v.(domain.SearchPage)
+	case *domain.NearbyPage:
+		*d = v.(domain.NearbyPage)
+	case *domain.HotelFacets:
+		*d = v.(domain.HotelFacets)
+	case *[]domain.AmenityDef:
+		*d = v.([]domain.AmenityDef)
+	case *[]domain.HotelImage:
+		*d = v.([]domain.HotelImage)
+	case *[]domain.Brand:
+		*d = v.([]domain.Brand)
+	case *domain.SimilarPage:
+		*d = v.(domain.SimilarPage)
+	case *domain.ReviewSummary:
+		*d = v.(domain.ReviewSummary)
+	case *domain.Version:
+		*d = v.(domain.Version)
+	case *int64:
+		*d = v.(int64)
 	}
 	return true, nil
 }
@@ -61,6 +266,207 @@ func (c *fakeCache) Set(ctx context.Context, key string, v any, ttlSec int) erro
 	return nil
 }
 func (c *fakeCache) Del(ctx context.Context, key string) error { return nil }
+func (c *fakeCache) MGet(ctx context.Context, keys []string, dst []any) ([]bool, error) {
+	hits := make([]bool, len(keys))
+	for i, k := range keys {
+		hits[i], _ = c.Get(ctx, k, dst[i])
+	}
+	return hits, nil
+}
+func (c *fakeCache) MSet(ctx context.Context, kv map[string]any, ttlSec int) error {
+	for k, v := range kv {
+		_ = c.Set(ctx, k, v, ttlSec)
+	}
+	return nil
+}
+
+// fakeWebhooks stores subscriptions in memory.
+type fakeWebhooks struct {
+	subs     []domain.WebhookSubscription
+	attempts []domain.WebhookAttempt
+}
+
+func (f *fakeWebhooks) CreateWebhook(_ context.Context, s domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	s.ID = int64(len(f.subs) + 1)
+	s.CreatedAt = time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+	f.subs = append(f.subs, s)
+	return s, nil
+}
+func (f *fakeWebhooks) ListWebhooks(context.Context) ([]domain.WebhookSubscription, error) {
+	return f.subs, nil
+}
+func (f *fakeWebhooks) GetWebhook(_ context.Context, id int64) (domain.WebhookSubscription, error) {
+	for _, s := range f.subs {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return domain.WebhookSubscription{}, domain.ErrNotFound
+}
+func (f *fakeWebhooks) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := f.GetWebhook(ctx, id)
+	return err
+}
+func (f *fakeWebhooks) EnableWebhook(ctx context.Context, id int64) error {
+	for i := range f.subs {
+		if f.subs[i].ID == id {
+			f.subs[i].DisabledAt, f.subs[i].DisabledReason, f.subs[i].ConsecutiveFailures = nil, nil, 0
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+func (f *fakeWebhooks) ListWebhookAttempts(ctx context.Context, id int64, limit int) ([]domain.WebhookAttempt, error) {
+	if _, err := f.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	return f.attempts, nil
+}
+func (f *fakeWebhooks) FanOutEvents(context.Context, int) (int, error) { return 0, nil }
+func (f *fakeWebhooks) ClaimDeliveries(context.Context, int, time.Duration) ([]domain.WebhookDelivery, error) {
+	return nil, nil
+}
+func (f *fakeWebhooks) RecordAttempt(context.Context, domain.WebhookAttempt, domain.WebhookOutcome) (bool, error) {
+	return false, nil
+}
+
+type fakeAPIKeys struct {
+	keys    []domain.APIKey
+	hashes  []string
+	lookups int
+}
+
+func (f *fakeAPIKeys) CreateAPIKey(_ context.Context, k domain.APIKey, hash string) (domain.APIKey, error) {
+	k.ID = int64(len(f.keys) + 1)
+	f.keys = append(f.keys, k)
+	f.hashes = append(f.hashes, hash)
+	return k, nil
+}
+func (f *fakeAPIKeys) APIKeyByHash(_ context.Context, hash string) (domain.APIKey, error) {
+	f.lookups++
+	for i, h := range f.hashes {
+		if h == hash && f.keys[i].RevokedAt == nil {
+			return f.keys[i], nil
+		}
+	}
+	return domain.APIKey{}, domain.ErrNotFound
+}
+func (f *fakeAPIKeys) ListAPIKeys(context.Context) ([]domain.APIKey, error) { return f.keys, nil }
+func (f *fakeAPIKeys) RevokeAPIKey(_ context.Context, id int64) error {
+	if id < 1 || int(id) > len(f.keys) {
+		return domain.ErrNotFound
+	}
+	now := time.Now()
+	f.keys[id-1].RevokedAt = &now
+	return nil
+}
+
+// fakeCupid answers every call with an empty payload, or with the error
+// set for the step ("property", "reviews", "i18n:<lang>").
+// With gate set, every property fetch announces itself on entered, then
+// waits for a value on gate or for its context to end.
+type fakeCupid struct {
+	errs    map[string]error
+	gate    chan struct{}
+	entered chan struct{}
+}
+
+func (f *fakeCupid) GetProperty(ctx context.Context, _ int64) (map[string]any, error) {
+	if f.gate != nil {
+		f.entered <- struct{}{}
+		select {
+		case <-f.gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return map[string]any{}, f.errs[domain.StepProperty]
+}
+func (f *fakeCupid) GetTranslation(_ context.Context, _ int64, lang string) (map[string]any, error) {
+	return map[string]any{}, f.errs[domain.StepI18nPrefix+lang]
+}
+func (f *fakeCupid) GetReviews(context.Context, int64, int) ([]map[string]any, error) {
+	return []map[string]any{{"review_id": 1.0}, {"review_id": 2.0}}, f.errs[domain.StepReviews]
+}
+
+// fakeIngestJobs stores jobs in memory; jobs run on other goroutines. Jobs
+// listed in stale have lost their replica.
+type fakeIngestJobs struct {
+	mu      sync.Mutex
+	jobs    map[string]domain.IngestJob
+	stale   []string
+	written int // reports stored, counting rewrites
+}
+
+func (f *fakeIngestJobs) CreateIngestJob(_ context.Context, j domain.IngestJob) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.jobs == nil {
+		f.jobs = map[string]domain.IngestJob{}
+	}
+	f.jobs[j.ID] = j
+	return nil
+}
+func (f *fakeIngestJobs) GetIngestJob(_ context.Context, id string) (domain.IngestJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	j, ok := f.jobs[id]
+	if !ok {
+		return domain.IngestJob{}, domain.ErrNotFound
+	}
+	return j, nil
+}
+func (f *fakeIngestJobs) UpdateIngestJob(_ context.Context, j domain.IngestJob) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.jobs == nil {
+		f.jobs = map[string]domain.IngestJob{}
+	}
+	old := f.jobs[j.ID]
+	j.CancelRequestedAt, j.Reports = old.CancelRequestedAt, old.Reports
+	f.jobs[j.ID] = j
+	return nil
+}
+func (f *fakeIngestJobs) AddIngestJobReports(_ context.Context, id string, reps []domain.IngestReport) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	j := f.jobs[id]
+	j.Reports = append(slices.Clone(j.Reports), reps...)
+	f.written += len(reps)
+	f.jobs[id] = j
+	return nil
+}
+func (f *fakeIngestJobs) InterruptStaleIngestJobs(context.Context, time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int64
+	for _, id := range f.stale {
+		if j, ok := f.jobs[id]; ok && !j.Finished() {
+			j.Status = domain.JobInterrupted
+			f.jobs[id] = j
+			n++
+		}
+	}
+	return n, nil
+}
+func (f *fakeIngestJobs) CancelIngestJob(_ context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	j, ok := f.jobs[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if j.CancelRequestedAt == nil {
+		now := time.Now()
+		j.CancelRequestedAt = &now
+		f.jobs[id] = j
+	}
+	return nil
+}
+func (f *fakeIngestJobs) IngestJobCancelRequested(ctx context.Context, id string) (bool, error) {
+	j, err := f.GetIngestJob(ctx, id)
+	return j.CancelRequestedAt != nil, err
+}
 
 // ---- tests ----
 
@@ -118,6 +524,587 @@ func TestListReviews_Cache(t *testing.T) {
 	}
 }
 
+func newTestServer(t *testing.T, repo *fakeRepo) http.Handler {
+	q := app.NewQueryService(repo, &fakeCache{}, 10*time.Minute)
+	srv := httpserver.New()
+	srv.MountHandlers(&httpserver.Handlers{Q: q})
+	return conform(t, srv.Mux())
+}
+
+func TestListHotels_ParsesFilters(t *testing.T) {
+	next := "abc"
+	repo := &fakeRepo{
+		hp: domain.HotelsPage{Items: []domain.HotelView{{ID: 1, Name: ptr("Alpha")}}, NextCursor: &next},
+	}
+	h := newTestServer(t, repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/hotels?lang=fr&country=FR&city=Paris&stars=4&amenity=spa&q=grand&limit=5", nil)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	got := repo.lastHQ
+	if got.Lang != "fr" || deref(got.Country) != "FR" || deref(got.City) != "Paris" ||
+		got.Stars == nil || *got.Stars != 4 || len(got.Amenities) != 1 || got.Amenities[0] != "spa" ||
+		deref(got.Q) != "grand" || got.Limit != 5 {
+		t.Fatalf("unexpected query: %+v", got)
+	}
+
+	var page domain.HotelsPage
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Items) != 1 || deref(page.NextCursor) != "abc" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+}
+
+func TestListHotels_RejectsBadStars(t *testing.T) {
+	h := newTestServer(t, &fakeRepo{})
+	req := httptest.NewRequest(http.MethodGet, "/v1/hotels?stars=9", nil)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestNearbyHotels_BBoxCentre(t *testing.T) {
+	repo := &fakeRepo{}
+	h := newTestServer(t, repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/hotels/nearby?bbox=2.2,48.8,2.4,48.9", nil)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	got := repo.lastNQ
+	if got.BBox == nil || got.RadiusKm != nil {
+		t.Fatalf("unexpected query: %+v", got)
+	}
+	if got.Lat < 48.849 || got.Lat > 48.851 || got.Lon < 2.299 || got.Lon > 2.301 {
+		t.Fatalf("expected bbox centre as reference point, got %v,%v", got.Lat, got.Lon)
+	}
+}
+
+func TestNearbyHotels_Validation(t *testing.T) {
+	h := newTestServer(t, &fakeRepo{})
+	for _, qs := range []string{
+		"",                              // no area
+		"lat=48.8&lon=2.3",              // point without radius
+		"radius_km=5",                   // radius without point
+		"lat=48.8&lon=2.3&radius_km=-1", // bad radius
+		"bbox=2.4,48.8,2.2,48.9",        // min > max
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/hotels/nearby?"+qs, nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%q: expected 400, got %d", qs, rr.Code)
+		}
+	}
+}
+
+func TestListReviews_ParsesSortAndFilters(t *testing.T) {
+	repo := &fakeRepo{}
+	h := newTestServer(t, repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/hotels/1/reviews?sort=-rating&lang=en&source=booking&min_rating=7.5&cursor=xyz&limit=10", nil)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	got := repo.lastPQ
+	if got.Sort != domain.SortRatingDesc || deref(got.Lang) != "en" || deref(got.Source) != "booking" ||
+		got.MinRating == nil || *got.MinRating != 7.5 || deref(got.Cursor) != "xyz" || got.Limit != 10 {
+		t.Fatalf("unexpected page query: %+v", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/hotels/1/reviews?sort=author", nil)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown sort, got %d", rr.Code)
+	}
+}
+
+func TestV1_DeprecationHeaders(t *testing.T) {
+	h := newTestServer(t, &fakeRepo{hv: domain.HotelView{ID: 42, Language: "en"}})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/hotels/42", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d", rr.Code)
+	}
+	if rr.Header().Get("Deprecation") == "" || rr.Header().Get("Sunset") == "" {
+		t.Fatalf("expected Deprecation and Sunset headers, got %v", rr.Header())
+	}
+	if got := rr.Header().Get("Link"); got != `</v2/hotels/42>; rel="successor-version"` {
+		t.Fatalf("unexpected Link: %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/hotels/42", nil))
+	if rr.Header().Get("Deprecation") != "" {
+		t.Fatalf("v2 must not be marked deprecated")
+	}
+}
+
+func TestV2_HotelContract(t *testing.T) {
+	h := newTestServer(t, &fakeRepo{hv: domain.HotelView{
+		ID: 42, Language: "fr", Name: ptr("Hôtel Test"), Coords: &domain.Coords{Lat: 1, Lon: 2},
+	}})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/hotels/42", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d", rr.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body["id"] != float64(42) || body["name"] != "Hôtel Test" || body["language"] != "fr" {
+		t.Fatalf("unexpected body: %v", body)
+	}
+	if _, leaked := body["ID"]; leaked {
+		t.Fatalf("v2 must not expose Go field names: %v", body)
+	}
+	if amen, ok := body["amenities"].([]any); !ok || len(amen) != 0 {
+		t.Fatalf("expected amenities to be an empty array, got %v", body["amenities"])
+	}
+	if ca, ok := body["canonical_amenities"].([]any); !ok || len(ca) != 0 {
+		t.Fatalf("expected canonical_amenities to be an empty array, got %v", body["canonical_amenities"])
+	}
+	if v, ok := body["main_photo"]; !ok || v != nil {
+		t.Fatalf("expected main_photo to be null, got %v", v)
+	}
+}
+
+func TestV2_ReviewContract(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := newTestServer(t, &fakeRepo{rp: domain.ReviewsPage{Items: []domain.Review{{
+		ID: 9, PropertyID: 1, Author: ptr("Ana"), Rating: pfloat(9),
+		AspectsJSON: []byte(`{"pros":["Clean"],"cons":["Noisy"]}`),
+		RawJSON:     []byte(`{"secret":"upstream"}`),
+		CreatedAt:   created,
+	}}}})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/hotels/1/reviews", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d", rr.Code)
+	}
+	var body struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Items) != 1 {
+		t.Fatalf("unexpected items: %v", body.Items)
+	}
+	it := body.Items[0]
+	if it["hotel_id"] != float64(1) || it["author"] != "Ana" || it["created_at"] != "2025-01-02T03:04:05Z" {
+		t.Fatalf("unexpected review: %v", it)
+	}
+	aspects, _ := it["aspects"].(map[string]any)
+	if pros, _ := aspects["pros"].([]any); len(pros) != 1 || pros[0] != "Clean" {
+		t.Fatalf("expected aspects as JSON arrays, got %v", it["aspects"])
+	}
+	for _, k := range []string{"raw", "RawJSON", "AspectsJSON", "PropertyID"} {
+		if _, leaked := it[k]; leaked {
+			t.Fatalf("v2 review leaks %q: %v", k, it)
+		}
+	}
+}
+
+func TestBatchGet_RequestOrderAndNotFound(t *testing.T) {
+	repo := &fakeRepo{byID: map[int64]domain.HotelView{
+		7: {ID: 7, Name: ptr("Seven"), Language: "en"},
+	}}
+	h := newTestServer(t, repo)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/v2/hotels:batchGet", strings.NewReader(`{"ids":[3,7],"lang":"en"}`)),
+		httptest.NewRequest(http.MethodGet, "/v2/hotels?ids=3,7&lang=en", nil),
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s %s: status %d body=%s", req.Method, req.URL, rr.Code, rr.Body.String())
+		}
+		var body struct {
+			Items []struct {
+				ID    int64           `json:"id"`
+				Found bool            `json:"found"`
+				Hotel json.RawMessage `json:"hotel"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(body.Items) != 2 || body.Items[0].ID != 3 || body.Items[0].Found || string(body.Items[0].Hotel) != "null" ||
+			body.Items[1].ID != 7 || !body.Items[1].Found {
+			t.Fatalf("unexpected batch: %s", rr.Body.String())
+		}
+	}
+}
+
+func TestBatchGet_Validation(t *testing.T) {
+	h := newTestServer(t, &fakeRepo{})
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/v2/hotels:batchGet", strings.NewReader(`{"ids":[]}`)),
+		httptest.NewRequest(http.MethodPost, "/v2/hotels:batchGet", strings.NewReader(`not json`)),
+		httptest.NewRequest(http.MethodPost, "/v2/hotels:batchGet", strings.NewReader(`{"ids":[1,0,-3]}`)),
+		httptest.NewRequest(http.MethodGet, "/v2/hotels?ids=1,x", nil),
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s %s: expected 400, got %d", req.Method, req.URL, rr.Code)
+		}
+	}
+}
+
+func TestGetHotel_NegotiatesAcceptLanguage(t *testing.T) {
+	repo := &fakeRepo{i18n: map[string]domain.HotelView{
+		"fr": {ID: 1, Language: "fr", Name: ptr("Nom")},
+		"en": {ID: 1, Language: "en", Name: ptr("Name"), Description: ptr("Desc")},
+	}}
+	h := newTestServer(t, repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/hotels/1", nil)
+	req.Header.Set("Accept-Language", "de-DE;q=1, es;q=0, fr-CA;q=0.8, en;q=0.5")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	// fr-CA truncates to fr; description falls back to en.
+	if got := rr.Header().Get("Content-Language"); got != "fr, en" {
+		t.Fatalf("Content-Language = %q, want %q", got, "fr, en")
+	}
+	// HEAD carries the same representation headers without loading the view.
+	head := httptest.NewRequest(http.MethodHead, "/v2/hotels/1", nil)
+	head.Header.Set("Accept-Language", "fr-CA, en;q=0.5")
+	hr := httptest.NewRecorder()
+	h.ServeHTTP(hr, head)
+	for _, k := range []string{"Content-Language", "Vary", "ETag", "Last-Modified", "Content-Type"} {
+		if hr.Header().Get(k) != rr.Header().Get(k) {
+			t.Fatalf("HEAD %s = %q, GET %q", k, hr.Header().Get(k), rr.Header().Get(k))
+		}
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	fl, _ := body["field_languages"].(map[string]any)
+	if fl["name"] != "fr" || fl["description"] != "en" {
+		t.Fatalf("unexpected field_languages: %v", body["field_languages"])
+	}
+}
+
+func TestGetHotel_RejectsUnsupportedLang(t *testing.T) {
+	h := newTestServer(t, &fakeRepo{})
+	for _, path := range []string{"/v2/hotels/1?lang=xx", "/v2/hotels?lang=en'--"} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestLang_FollowsConfiguredRegistry(t *testing.T) {
+	q := app.NewQueryService(&fakeRepo{hv: domain.HotelView{ID: 1}}, &fakeCache{}, 10*time.Minute)
+	q.SetLanguages(domain.Languages{Supported: []string{"en", "de"}, Default: "de"})
+	srv := httpserver.New()
+	srv.MountHandlers(&httpserver.Handlers{Q: q})
+	h := conform(t, srv.Mux())
+
+	cases := []struct {
+		path, accept string
+		code         int
+		lang         string
+	}{
+		{"/v2/hotels/1?lang=de", "", http.StatusOK, "de"},
+		{"/v2/hotels/1?lang=fr", "", http.StatusBadRequest, ""},
+		{"/v2/hotels/1", "fr-FR, fr;q=0.9", http.StatusOK, "de"}, // no match -> default
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		if c.accept != "" {
+			req.Header.Set("Accept-Language", c.accept)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != c.code {
+			t.Fatalf("%s (%q): status %d, want %d", c.path, c.accept, rr.Code, c.code)
+		}
+		if c.lang != "" && rr.Header().Get("Content-Language") != c.lang {
+			t.Fatalf("%s (%q): Content-Language %q, want %q", c.path, c.accept, rr.Header().Get("Content-Language"), c.lang)
+		}
+	}
+}
+
+func TestHotelFacets_FiltersAndShape(t *testing.T) {
+	repo := &fakeRepo{hf: domain.HotelFacets{
+		City:    []domain.FacetCount{{Value: "Paris", Count: 42}, {Value: "Lyon", Count: 3}},
+		Amenity: []domain.FacetCount{{Value: "Spa", Count: 17}},
+	}}
+	h := newTestServer(t, repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/hotels/facets?country=FR&stars=4&limit=10", nil)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if q := repo.lastHQ; deref(q.Country) != "FR" || q.Stars == nil || *q.Stars != 4 || q.Limit != 10 {
+		t.Fatalf("filters not forwarded: %+v", q)
+	}
+
+	var body map[string][]struct {
+		Value string `json:"value"`
+		Count int    `json:"count"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if c := body["city"]; len(c) != 2 || c[0].Value != "Paris" || c[0].Count != 42 {
+		t.Fatalf("unexpected city facet: %v", c)
+	}
+	if c, ok := body["country"]; !ok || c == nil || len(c) != 0 {
+		t.Fatalf("empty facets must be [] not null/missing: %s", rr.Body.String())
+	}
+}
+
+func TestListHotels_AmenityCodesAndMatch(t *testing.T) {
+	repo := &fakeRepo{}
+	h := newTestServer(t, repo)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/hotels?amenity=spa,Swimming%20pool&amenity=wifi&amenity_match=any", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	got := repo.lastHQ
+	want := []string{"pool", "spa", "wifi"} // canonicalized, sorted
+	if got.AmenityMatch != domain.AmenityMatchAny || len(got.Amenities) != len(want) {
+		t.Fatalf("unexpected amenity filter: %+v", got)
+	}
+	for i := range want {
+		if got.Amenities[i] != want[i] {
+			t.Fatalf("amenities = %v, want %v", got.Amenities, want)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/hotels?amenity=spa&amenity_match=some", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad amenity_match, got %d", rr.Code)
+	}
+}
+
+func TestListImages_ContractAndNotFound(t *testing.T) {
+	repo := &fakeRepo{im: []domain.HotelImage{
+		{URL: "https://img/1.jpg", Caption: ptr("Lobby"), Category: ptr("lobby"), Width: ptr(1200), Height: ptr(800), SortOrder: 3, IsPrimary: true},
+		{URL: "https://img/0.jpg", SortOrder: 0},
+	}}
+	h := newTestServer(t, repo)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/hotels/1/images", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Items) != 2 {
+		t.Fatalf("unexpected items: %v", body.Items)
+	}
+	first := body.Items[0]
+	if first["url"] != "https://img/1.jpg" || first["is_primary"] != true || first["category"] != "lobby" ||
+		first["width"] != float64(1200) || first["caption"] != "Lobby" {
+		t.Fatalf("unexpected image: %v", first)
+	}
+	if _, ok := body.Items[1]["caption"]; !ok {
+		t.Fatalf("nullable fields must be present: %v", body.Items[1])
+	}
+
+	rr = httptest.NewRecorder()
+	newTestServer(t, &fakeRepo{}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/hotels/404/images", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown hotel, got %d", rr.Code)
+	}
+}
+
+func TestBrands_ListAndBrandHotels(t *testing.T) {
+	repo := &fakeRepo{
+		br: []domain.Brand{{ID: 7, Name: ptr("Ibis"), HotelCount: 2}},
+		hp: domain.HotelsPage{Items: []domain.HotelView{{ID: 1, Brand: &domain.Brand{ID: 7, Name: ptr("Ibis")}}}},
+	}
+	h := newTestServer(t, repo)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/brands", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	var brands struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &brands); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(brands.Items) != 1 || brands.Items[0]["id"] != float64(7) ||
+		brands.Items[0]["name"] != "Ibis" || brands.Items[0]["hotel_count"] != float64(2) {
+		t.Fatalf("unexpected brands: %v", brands.Items)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/brands/7/hotels?stars=4&brand_id=9", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if got := repo.lastHQ; got.BrandID == nil || *got.BrandID != 7 || got.Stars == nil || *got.Stars != 4 {
+		t.Fatalf("path brand must scope the listing: %+v", got)
+	}
+	var page struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if b, ok := page.Items[0]["brand"].(map[string]any); !ok || b["id"] != float64(7) || b["name"] != "Ibis" {
+		t.Fatalf("unexpected hotel brand: %v", page.Items[0]["brand"])
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/brands/8/hotels", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown brand, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/hotels?brand_id=abc", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad brand_id, got %d", rr.Code)
+	}
+}
+
+func TestSimilarHotels_ContractAndValidation(t *testing.T) {
+	repo := &fakeRepo{
+		hv: domain.HotelView{ID: 1, Stars: ptr(3)},
+		sc: []domain.SimilarCandidate{{Hotel: domain.HotelView{ID: 2, Stars: ptr(3), Name: ptr("Near")}, DistanceKm: ptr(2.5)}},
+	}
+	h := newTestServer(t, repo)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/hotels/1/similar?radius_km=10&limit=5", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Items []struct {
+			Hotel      map[string]any `json:"hotel"`
+			Score      float64        `json:"score"`
+			DistanceKm *float64       `json:"distance_km"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Items) != 1 || body.Items[0].Hotel["id"] != float64(2) || body.Items[0].Score <= 0 ||
+		body.Items[0].DistanceKm == nil || *body.Items[0].DistanceKm != 2.5 {
+		t.Fatalf("unexpected body: %s", rr.Body.String())
+	}
+
+	for _, url := range []string{"/v1/hotels/1/similar?limit=0", "/v1/hotels/1/similar?radius_km=-1", "/v1/hotels/x/similar"} {
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", url, rr.Code)
+		}
+	}
+}
+
+func TestErrors_MapToProblemTypes(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		path     string
+		status   int
+		typ      string
+		retryHdr bool
+	}{
+		{"not found", domain.ErrNotFound, "/v2/hotels/1", http.StatusNotFound, "/problems/not-found", false},
+		{"gone", domain.ErrGone, "/v2/hotels/1", http.StatusGone, "/problems/gone", false},
+		{"unavailable", fmt.Errorf("%w: dial tcp: refused", domain.ErrUnavailable), "/v2/hotels/1", http.StatusServiceUnavailable, "/problems/unavailable", true},
+		{"internal", errors.New("boom"), "/v2/hotels/1", http.StatusInternalServerError, "/problems/internal", false},
+		{"upstream key rejected", fmt.Errorf("cupid: %w", domain.ErrUpstreamUnauthorized), "/v2/hotels/1", http.StatusBadGateway, "/problems/upstream", false},
+		{"reviews of unknown hotel", domain.ErrNotFound, "/v2/hotels/1/reviews", http.StatusNotFound, "/problems/not-found", false},
+		{"bad id", nil, "/v1/hotels/abc", http.StatusBadRequest, "/problems/invalid-argument", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestServer(t, &fakeRepo{err: tc.err})
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rr.Code != tc.status {
+				t.Fatalf("status = %d, want %d (body=%s)", rr.Code, tc.status, rr.Body.String())
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Fatalf("content type = %q", ct)
+			}
+			var p map[string]any
+			if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if p["type"] != tc.typ || p["status"] != float64(tc.status) || p["instance"] != tc.path || p["request_id"] == "" {
+				t.Fatalf("unexpected problem: %v", p)
+			}
+			if got := rr.Header().Get("Retry-After") != ""; got != tc.retryHdr {
+				t.Fatalf("Retry-After present = %v, want %v", got, tc.retryHdr)
+			}
+			if got := rr.Header().Get("WWW-Authenticate"); got != "" {
+				t.Fatalf("WWW-Authenticate = %q on a %d", got, rr.Code)
+			}
+		})
+	}
+}
+
+func TestErrors_ValidationDetails(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newTestServer(t, &fakeRepo{}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/hotels?limit=0", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status: %d", rr.Code)
+	}
+	var p struct {
+		Title  string `json:"title"`
+		Errors []struct {
+			Field  string `json:"field"`
+			Reason string `json:"reason"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if p.Title != "Invalid limit" || len(p.Errors) != 1 || p.Errors[0].Field != "limit" || p.Errors[0].Reason == "" {
+		t.Fatalf("unexpected problem: %+v", p)
+	}
+}
+
 func ptr[T any](v T) *T { return &v }
 func deref(p *string) string {
 	if p == nil {
@@ -126,3 +1113,770 @@ func deref(p *string) string {
 	return *p
 }
 func pfloat(f float64) *float64 { return &f }
+
+func TestConditional_VersionValidatorsAndHead(t *testing.T) {
+	modified := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	repo := &fakeRepo{
+		hv:  domain.HotelView{ID: 42, Language: "en", Name: ptr("N")},
+		rp:  domain.ReviewsPage{Items: []domain.Review{{PropertyID: 42, Author: ptr("Ana")}}},
+		ver: domain.Version{Tag: "abc", Modified: modified},
+	}
+	h := newTestServer(t, repo)
+	do := func(method, path string, hdr map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range hdr {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodGet, "/v2/hotels/42?lang=en", nil)
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("status=%d etag=%q", rr.Code, etag)
+	}
+	if lm := rr.Header().Get("Last-Modified"); lm != "Wed, 04 Mar 2026 05:06:07 GMT" {
+		t.Fatalf("Last-Modified = %q", lm)
+	}
+	if v1 := do(http.MethodGet, "/v1/hotels/42?lang=en", nil).Header().Get("ETag"); v1 == etag {
+		t.Fatal("v1 and v2 representations share an ETag")
+	}
+
+	cases := []struct {
+		name string
+		hdr  map[string]string
+		want int
+	}{
+		{"tag in list", map[string]string{"If-None-Match": `"nope", W/` + etag}, http.StatusNotModified},
+		{"star", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"stale tag", map[string]string{"If-None-Match": `"nope"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": "Wed, 04 Mar 2026 05:06:07 GMT"}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": "Wed, 04 Mar 2026 05:06:06 GMT"}, http.StatusOK},
+		{"tag wins over date", map[string]string{"If-None-Match": `"nope"`, "If-Modified-Since": "Wed, 04 Mar 2026 05:06:07 GMT"}, http.StatusOK},
+	}
+	for _, tc := range cases {
+		rr := do(http.MethodGet, "/v2/hotels/42?lang=en", tc.hdr)
+		if rr.Code != tc.want {
+			t.Fatalf("%s: status = %d, want %d", tc.name, rr.Code, tc.want)
+		}
+		if tc.want == http.StatusNotModified && (rr.Body.Len() != 0 || rr.Header().Get("ETag") != etag) {
+			t.Fatalf("%s: 304 must carry the ETag and no body", tc.name)
+		}
+	}
+
+	if rr := do(http.MethodHead, "/v2/hotels/42?lang=en", nil); rr.Code != http.StatusOK || rr.Body.Len() != 0 || rr.Header().Get("ETag") != etag {
+		t.Fatalf("HEAD hotel: status=%d body=%q etag=%q", rr.Code, rr.Body.String(), rr.Header().Get("ETag"))
+	}
+	// HEAD never loads the view, even uncached (es was never asked for).
+	calls := repo.hotelCalls
+	if rr := do(http.MethodHead, "/v2/hotels/42?lang=es", nil); rr.Code != http.StatusOK || repo.hotelCalls != calls {
+		t.Fatalf("HEAD hotel es: status=%d repo calls=%d", rr.Code, repo.hotelCalls-calls)
+	}
+
+	// Reviews: HEAD and 304 never load the page; each query is its own representation.
+	rr = do(http.MethodHead, "/v2/hotels/42/reviews?limit=5", nil)
+	if rr.Code != http.StatusOK || rr.Body.Len() != 0 || repo.revCalls != 0 {
+		t.Fatalf("HEAD reviews: status=%d body=%q repo calls=%d", rr.Code, rr.Body.String(), repo.revCalls)
+	}
+	revTag := rr.Header().Get("ETag")
+	if other := do(http.MethodGet, "/v2/hotels/42/reviews?limit=6", nil).Header().Get("ETag"); other == revTag {
+		t.Fatal("different review queries share an ETag")
+	}
+	calls = repo.revCalls
+	if rr := do(http.MethodGet, "/v2/hotels/42/reviews?limit=5", map[string]string{"If-None-Match": revTag}); rr.Code != http.StatusNotModified || repo.revCalls != calls {
+		t.Fatalf("conditional reviews: status=%d repo calls %d -> %d", rr.Code, calls, repo.revCalls)
+	}
+
+	// Listings keep body-derived weak ETags but accept lists too.
+	rr = do(http.MethodGet, "/v2/brands", nil)
+	if rr := do(http.MethodGet, "/v2/brands", map[string]string{"If-None-Match": `"x", ` + rr.Header().Get("ETag")}); rr.Code != http.StatusNotModified {
+		t.Fatalf("listing conditional: status=%d", rr.Code)
+	}
+}
+
+func TestExport_StreamsNDJSONAndCSV(t *testing.T) {
+	next := "tok"
+	repo := &fakeRepo{
+		exH: []domain.HotelView{
+			{ID: 1, Name: ptr("Alpha"), Language: "fr", CanonicalAmenities: []domain.Amenity{{Code: "pool"}, {Code: "spa"}}},
+			{ID: 2, Name: ptr(`Beta, "the" hotel`), Language: "fr"},
+		},
+		exR:    []domain.Review{{ID: 7, PropertyID: 1, Author: ptr("Ana"), Rating: pfloat(8.5)}},
+		exNext: &next,
+	}
+	h := newTestServer(t, repo)
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/v2/export/hotels?lang=fr&updated_since=2026-01-02T03:04:05Z&limit=2", "application/x-ndjson")
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "application/x-ndjson") {
+		t.Fatalf("status=%d content-type=%q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !rr.Flushed {
+		t.Fatal("export was not flushed; is it still behind the timeout handler?")
+	}
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 NDJSON lines, got %q", rr.Body.String())
+	}
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first["id"] != float64(1) || first["name"] != "Alpha" {
+		t.Fatalf("first line = %s (%v)", lines[0], err)
+	}
+	if got := rr.Result().Trailer.Get("Next-Cursor"); got != "tok" {
+		t.Fatalf("Next-Cursor trailer = %q", got)
+	}
+	if q := repo.lastEQ; q.Lang != "fr" || q.Limit != 2 || q.UpdatedSince == nil || !q.UpdatedSince.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("export query = %+v", q)
+	}
+
+	// A client without trailers resumes from the last id it received.
+	if rr = get("/v2/export/hotels?lang=fr&after_id=2", ""); rr.Code != http.StatusOK || repo.lastEQ.AfterID != 2 || repo.lastEQ.Cursor != nil {
+		t.Fatalf("after_id: status=%d query=%+v", rr.Code, repo.lastEQ)
+	}
+
+	rr = get("/v2/export/hotels?lang=fr", "text/csv;q=0.9, application/json;q=0.1")
+	recs, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil || rr.Code != http.StatusOK || len(recs) != 3 {
+		t.Fatalf("csv: status=%d records=%v err=%v", rr.Code, recs, err)
+	}
+	if recs[0][0] != "id" || recs[1][1] != "Alpha" || recs[1][13] != "pool|spa" || recs[2][1] != `Beta, "the" hotel` {
+		t.Fatalf("unexpected csv: %v", recs)
+	}
+
+	rr = get("/v1/export/reviews?lang=EN", "text/*")
+	recs, _ = csv.NewReader(rr.Body).ReadAll()
+	if len(recs) != 2 || recs[1][0] != "7" || recs[1][4] != "Ana" || recs[1][5] != "8.5" || repo.lastEQ.Lang != "en" {
+		t.Fatalf("reviews csv: %v (query %+v)", recs, repo.lastEQ)
+	}
+
+	for _, tc := range []struct {
+		path, accept string
+		status       int
+	}{
+		{"/v2/export/hotels", "application/xml", http.StatusNotAcceptable},
+		{"/v2/export/hotels?updated_since=yesterday", "", http.StatusBadRequest},
+		{"/v2/export/hotels?limit=0", "", http.StatusBadRequest},
+		{"/v2/export/hotels?cursor=bad", "", http.StatusBadRequest},
+		{"/v2/export/hotels?after_id=0", "", http.StatusBadRequest},
+		{"/v2/export/reviews?after_id=5&cursor=tok", "", http.StatusBadRequest},
+	} {
+		if rr := get(tc.path, tc.accept); rr.Code != tc.status || rr.Header().Get("Content-Type") != "application/problem+json" {
+			t.Fatalf("%s (Accept %q): status=%d content-type=%q", tc.path, tc.accept, rr.Code, rr.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestChanges_FeedPagingAndValidation(t *testing.T) {
+	at := time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+	repo := &fakeRepo{changes: []domain.Change{
+		{Seq: 3, Entity: domain.ChangeProperty, Op: domain.ChangeCreated, PropertyID: 1, At: at},
+		{Seq: 4, Entity: domain.ChangeTranslation, Op: domain.ChangeUpdated, PropertyID: 1, Lang: ptr("fr"), At: at},
+		{Seq: 9, Entity: domain.ChangeProperty, Op: domain.ChangeDeactivated, PropertyID: 2, At: at},
+	}}
+	h := newTestServer(t, repo)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/changes?since=3&limit=1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d", rr.Code)
+	}
+	var page struct {
+		Items []struct {
+			Seq       int64   `json:"seq"`
+			Entity    string  `json:"entity"`
+			Op        string  `json:"op"`
+			HotelID   int64   `json:"hotel_id"`
+			Lang      *string `json:"lang"`
+			ChangedAt string  `json:"changed_at"`
+		} `json:"items"`
+		Next    int64 `json:"next"`
+		HasMore bool  `json:"has_more"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Seq != 4 || page.Items[0].Entity != "translation" ||
+		deref(page.Items[0].Lang) != "fr" || page.Items[0].ChangedAt != "2026-05-06T07:08:09Z" {
+		t.Fatalf("unexpected items: %+v", page.Items)
+	}
+	if page.Next != 4 || !page.HasMore || repo.lastSince != 3 || repo.lastLimitC != 1 {
+		t.Fatalf("next=%d has_more=%v since=%d limit=%d", page.Next, page.HasMore, repo.lastSince, repo.lastLimitC)
+	}
+
+	// An empty page keeps the caller's token.
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/changes?since=9", nil))
+	if !strings.Contains(rr.Body.String(), `"items":[],"next":9,"has_more":false`) {
+		t.Fatalf("empty page: %s", rr.Body.String())
+	}
+
+	for _, path := range []string{"/v2/changes?since=abc", "/v2/changes?since=-1", "/v2/changes?limit=5000"} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d", path, rr.Code)
+		}
+	}
+}
+
+func TestWebhooks_SubscriptionsAndDeliveryLog(t *testing.T) {
+	hooks := &fakeWebhooks{}
+	srv := httpserver.New()
+	srv.MountHandlers(&httpserver.Handlers{
+		Q: app.NewQueryService(&fakeRepo{}, &fakeCache{}, time.Minute),
+		W: app.NewWebhookService(hooks),
+	})
+	h := conform(t, srv.Mux())
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v2/webhooks", `{"url":"ftp://x","events":["hotel.updated","hotel.exploded"],"secret":"short"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid subscription: status %d", rr.Code)
+	}
+	var p struct {
+		Errors []struct{ Field string } `json:"errors"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &p)
+	if len(p.Errors) != 3 || p.Errors[0].Field != "url" || p.Errors[1].Field != "events" || p.Errors[2].Field != "secret" {
+		t.Fatalf("unexpected field errors: %s", rr.Body.String())
+	}
+
+	// Without a secret one is generated and shown once.
+	rr = do(http.MethodPost, "/v2/webhooks", `{"url":"https://partner.example/hooks","events":["hotel.updated","reviews.added","hotel.updated"]}`)
+	if rr.Code != http.StatusCreated || rr.Header().Get("Location") != "/v2/webhooks/1" {
+		t.Fatalf("create: status %d location %q", rr.Code, rr.Header().Get("Location"))
+	}
+	var created struct {
+		ID      int64    `json:"id"`
+		Secret  string   `json:"secret"`
+		Events  []string `json:"events"`
+		Enabled bool     `json:"enabled"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &created)
+	if !strings.HasPrefix(created.Secret, "whsec_") || created.Secret != hooks.subs[0].Secret ||
+		len(created.Events) != 2 || !created.Enabled {
+		t.Fatalf("unexpected subscription: %s", rr.Body.String())
+	}
+
+	// The secret is never listed, on /v1 either.
+	for _, path := range []string{"/v1/webhooks", "/v2/webhooks", "/v2/webhooks/1"} {
+		rr = do(http.MethodGet, path, "")
+		if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), created.Secret) || strings.Contains(rr.Body.String(), `"secret"`) {
+			t.Fatalf("%s leaked the secret or failed: %d %s", path, rr.Code, rr.Body.String())
+		}
+	}
+
+	hooks.attempts = []domain.WebhookAttempt{
+		{DeliveryID: 5, EventID: 42, EventType: domain.EventReviewsAdded, Attempt: 2, StatusCode: 204, Duration: 30 * time.Millisecond},
+		{DeliveryID: 5, EventID: 42, EventType: domain.EventReviewsAdded, Attempt: 1, Error: "dial tcp: connection refused"},
+	}
+	rr = do(http.MethodGet, "/v2/webhooks/1/deliveries?limit=10", "")
+	if rr.Code != http.StatusOK ||
+		!strings.Contains(rr.Body.String(), `"attempt":2,"status_code":204,"error":null,"duration_ms":30`) ||
+		!strings.Contains(rr.Body.String(), `"attempt":1,"status_code":null,"error":"dial tcp: connection refused"`) {
+		t.Fatalf("delivery log: %d %s", rr.Code, rr.Body.String())
+	}
+
+	if rr = do(http.MethodPost, "/v2/webhooks/1/enable", ""); rr.Code != http.StatusOK {
+		t.Fatalf("enable: status %d", rr.Code)
+	}
+	if rr = do(http.MethodDelete, "/v2/webhooks/1", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: status %d", rr.Code)
+	}
+	if rr = do(http.MethodDelete, "/v2/webhooks/2", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("delete unknown: status %d", rr.Code)
+	}
+}
+
+func TestAuth_KeysScopesAndQuota(t *testing.T) {
+	auth := app.NewAuthService(&fakeAPIKeys{}, ratelimit.NewLocal(), domain.Quota{Rate: 100, Burst: 100})
+	srv := httpserver.New()
+	srv.MountHandlers(&httpserver.Handlers{Q: app.NewQueryService(&fakeRepo{}, &fakeCache{}, time.Minute), A: auth})
+	h := conform(t, srv.Mux())
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	problemType := func(rr *httptest.ResponseRecorder) string {
+		var p struct{ Type string }
+		_ = json.Unmarshal(rr.Body.Bytes(), &p)
+		return p.Type
+	}
+
+	for _, key := range []string{"", "ck_unknown"} {
+		rr := do(http.MethodGet, "/v1/hotels", key, "")
+		if rr.Code != http.StatusUnauthorized || problemType(rr) != "/problems/unauthenticated" ||
+			!strings.HasPrefix(rr.Header().Get("WWW-Authenticate"), "Bearer") {
+			t.Fatalf("key %q: status %d type %q", key, rr.Code, problemType(rr))
+		}
+	}
+
+	// A slow bucket of two: both requests pass, the third is refused.
+	_, reader, err := auth.CreateKey(context.Background(), "partner", []string{domain.ScopeRead}, domain.Quota{Rate: 0.01, Burst: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"1", "0"} {
+		rr := do(http.MethodGet, "/v2/hotels", reader, "")
+		if rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "2" || rr.Header().Get("RateLimit-Remaining") != want {
+			t.Fatalf("request %d: status %d headers %v", i+1, rr.Code, rr.Header())
+		}
+	}
+	rr := do(http.MethodGet, "/v2/hotels", reader, "")
+	if rr.Code != http.StatusTooManyRequests || problemType(rr) != "/problems/rate-limited" ||
+		rr.Header().Get("RateLimit-Remaining") != "0" || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("over quota: status %d headers %v", rr.Code, rr.Header())
+	}
+
+	// Scopes: a read key may not export or manage keys; admin implies all.
+	_, admin, _ := auth.CreateKey(context.Background(), "ops", []string{domain.ScopeAdmin}, domain.Quota{})
+	_, exporter, _ := auth.CreateKey(context.Background(), "bi", []string{domain.ScopeExport}, domain.Quota{})
+	if rr = do(http.MethodGet, "/v2/hotels", exporter, ""); rr.Code != http.StatusForbidden || problemType(rr) != "/problems/forbidden" {
+		t.Fatalf("export key reading: status %d", rr.Code)
+	}
+	if rr = do(http.MethodGet, "/v2/keys", exporter, ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("export key listing keys: status %d", rr.Code)
+	}
+	if rr = do(http.MethodGet, "/v2/hotels", admin, ""); rr.Code != http.StatusOK {
+		t.Fatalf("admin key reading: status %d", rr.Code)
+	}
+
+	// Keys are shown once, then listed by prefix only; revocation is immediate.
+	rr = do(http.MethodPost, "/v2/keys", admin, `{"name":"mobile","scopes":["read","read"]}`)
+	var created struct {
+		ID     int64    `json:"id"`
+		Key    string   `json:"key"`
+		Prefix string   `json:"prefix"`
+		Scopes []string `json:"scopes"`
+		Burst  int      `json:"burst"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &created)
+	if rr.Code != http.StatusCreated || !strings.HasPrefix(created.Key, created.Prefix) || len(created.Scopes) != 1 || created.Burst != 100 {
+		t.Fatalf("create: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/v2/keys", admin, ""); rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), created.Key) {
+		t.Fatalf("list leaked a key or failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPost, "/v2/keys", admin, `{"name":"x","scopes":["root"]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unknown scope: status %d", rr.Code)
+	}
+	if rr = do(http.MethodGet, "/v2/hotels", created.Key, ""); rr.Code != http.StatusOK {
+		t.Fatalf("new key: status %d", rr.Code)
+	}
+	if rr = do(http.MethodDelete, fmt.Sprintf("/v2/keys/%d", created.ID), admin, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("revoke: status %d", rr.Code)
+	}
+	if rr = do(http.MethodGet, "/v2/hotels", created.Key, ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked key: status %d", rr.Code)
+	}
+}
+
+func TestAuth_UnknownKeysCachedAndLimitedPerClient(t *testing.T) {
+	keys := &fakeAPIKeys{}
+	auth := app.NewAuthService(keys, ratelimit.NewLocal(), domain.Quota{Rate: 100, Burst: 100})
+	auth.SetClientQuota(domain.Quota{Rate: 0.01, Burst: 3})
+	srv := httpserver.New()
+	srv.MountHandlers(&httpserver.Handlers{Q: app.NewQueryService(&fakeRepo{}, &fakeCache{}, time.Minute), A: auth,
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}})
+	h := conform(t, srv.Mux())
+	do := func(ip, key string, xff ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v2/hotels", nil)
+		req.RemoteAddr = ip + ":1234"
+		if len(xff) > 0 {
+			req.Header.Set("X-Forwarded-For", strings.Join(xff, ", "))
+		}
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Unknown keys are looked up once, then refused from the cache.
+	for i := 0; i < 2; i++ {
+		if rr := do("192.0.2.7", "ck_guess"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("unknown key %d: status %d", i+1, rr.Code)
+		}
+	}
+	if keys.lookups != 1 {
+		t.Fatalf("lookups = %d, want 1", keys.lookups)
+	}
+
+	// The client's bucket of three is spent: the next request without a known
+	// key is refused before any lookup, whatever key it guesses.
+	_, valid, _ := auth.CreateKey(context.Background(), "partner", []string{domain.ScopeRead}, domain.Quota{})
+	if rr := do("192.0.2.7", "ck_other"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("third request: status %d", rr.Code)
+	}
+	rr := do("192.0.2.7", "ck_another")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" || keys.lookups != 2 {
+		t.Fatalf("over client quota: status %d retry-after %q lookups %d", rr.Code, rr.Header().Get("Retry-After"), keys.lookups)
+	}
+
+	// A forwarded address only counts when a trusted proxy appended it.
+	if rr := do("192.0.2.7", "ck_spoof", "203.0.113.1"); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("X-Forwarded-For from an untrusted peer: status %d", rr.Code)
+	}
+	if rr := do("10.0.0.2", "ck_spoof", "203.0.113.1", "192.0.2.7", "10.0.0.3"); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("throttled client behind trusted proxies: status %d", rr.Code)
+	}
+	if rr := do("10.0.0.2", "ck_spoof", "192.0.2.7", "203.0.113.1"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("other client behind a trusted proxy: status %d", rr.Code)
+	}
+
+	// Other clients are unaffected; a known key skips the client bucket.
+	if rr := do("198.51.100.9", valid); rr.Code != http.StatusOK {
+		t.Fatalf("other client: status %d", rr.Code)
+	}
+	if rr := do("192.0.2.7", valid); rr.Code != http.StatusOK {
+		t.Fatalf("known key from a throttled client: status %d", rr.Code)
+	}
+}
+
+func TestAdmin_RefreshHotelReportAndJob(t *testing.T) {
+	cupid := &fakeCupid{errs: map[string]error{
+		domain.StepReviews:           domain.ErrUpstreamNotFound,
+		domain.StepI18nPrefix + "fr": fmt.Errorf("cupid: %w", domain.ErrUpstreamForbidden),
+	}}
+	repo := &fakeRepo{}
+	srv := httpserver.New()
+	srv.MountHandlers(&httpserver.Handlers{
+		Q: app.NewQueryService(repo, &fakeCache{}, time.Minute),
+		J: app.NewIngestJobService(app.NewIngestionService(cupid, repo, &fakeCache{}), &fakeIngestJobs{}, app.IngestJobConfig{ReviewCount: 10}),
+	})
+	h := conform(t, srv.Mux())
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+	type report struct {
+		PropertyID int64  `json:"property_id"`
+		Outcome    string `json:"outcome"`
+		Steps      []struct {
+			Step           string `json:"step"`
+			Outcome        string `json:"outcome"`
+			UpstreamStatus int    `json:"upstream_status"`
+			Stored         int    `json:"stored"`
+		} `json:"steps"`
+	}
+	check := func(r report) {
+		t.Helper()
+		want := []string{"property ok 0 1", "reviews missed 404 0", "i18n:en ok 0 1", "i18n:fr missed 403 0", "i18n:es ok 0 1"}
+		var got []string
+		for _, s := range r.Steps {
+			got = append(got, fmt.Sprintf("%s %s %d %d", s.Step, s.Outcome, s.UpstreamStatus, s.Stored))
+		}
+		if r.PropertyID != 7 || r.Outcome != domain.IngestPartial || !slices.Equal(got, want) {
+			t.Fatalf("unexpected report %+v", r)
+		}
+	}
+
+	rr := do(http.MethodPost, "/admin/hotels/7/refresh")
+	if rr.Code != http.StatusOK || rr.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("refresh: status %d", rr.Code)
+	}
+	var rep report
+	_ = json.Unmarshal(rr.Body.Bytes(), &rep)
+	check(rep)
+
+	if rr = do(http.MethodPost, "/admin/hotels/7/refresh?async=maybe"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("bad async: status %d", rr.Code)
+	}
+	if rr = do(http.MethodPost, "/admin/hotels/0/refresh"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("bad id: status %d", rr.Code)
+	}
+
+	// Asynchronously: 202 with a job to poll until it is done.
+	rr = do(http.MethodPost, "/admin/hotels/7/refresh?async=true")
+	loc := rr.Header().Get("Location")
+	if rr.Code != http.StatusAccepted || !strings.HasPrefix(loc, "/admin/ingest-jobs/") {
+		t.Fatalf("async refresh: status %d location %q", rr.Code, loc)
+	}
+	var job struct {
+		Status      string   `json:"status"`
+		PropertyIDs []int64  `json:"property_ids"`
+		Reports     []report `json:"reports"`
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		rr = do(http.MethodGet, loc)
+		_ = json.Unmarshal(rr.Body.Bytes(), &job)
+		if rr.Code != http.StatusOK || job.Status == domain.JobSucceeded || job.Status == domain.JobFailed || time.Now().After(deadline) {
+			break
+		}
+	}
+	if rr.Code != http.StatusOK || job.Status != domain.JobSucceeded || !slices.Equal(job.PropertyIDs, []int64{7}) || len(job.Reports) != 1 {
+		t.Fatalf("job: status %d %s", rr.Code, rr.Body.String())
+	}
+	check(job.Reports[0])
+
+	if rr = do(http.MethodGet, "/admin/ingest-jobs/nope"); rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown job: status %d", rr.Code)
+	}
+}
+
+func TestAdmin_BatchIngestJobs(t *testing.T) {
+	cupid := &fakeCupid{errs: map[string]error{domain.StepReviews: domain.ErrUpstreamNotFound}}
+	repo, jobs := &fakeRepo{}, &fakeIngestJobs{}
+	srv := httpserver.New()
+	srv.MountHandlers(&httpserver.Handlers{
+		Q: app.NewQueryService(repo, &fakeCache{}, time.Minute),
+		J: app.NewIngestJobService(app.NewIngestionService(cupid, repo, &fakeCache{}), jobs,
+			app.IngestJobConfig{ReviewCount: 10, Workers: 1, SaveEvery: time.Millisecond}),
+		Spec: testSpec,
+	})
+	h := conform(t, srv.Mux())
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+	type job struct {
+		Status   string `json:"status"`
+		Options  struct{ Languages, Parts []string }
+		Progress struct{ Total, Done, OK, Partial, Failed int }
+		Reports  []struct {
+			PropertyID int64 `json:"property_id"`
+			Steps      []struct{ Step string }
+		}
+	}
+	poll := func(loc, query string, final string) job {
+		t.Helper()
+		var j job
+		for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(2 * time.Millisecond) {
+			rr := do(http.MethodGet, loc+query, "")
+			if rr.Code != http.StatusOK {
+				t.Fatalf("poll: status %d %s", rr.Code, rr.Body.String())
+			}
+			_ = json.Unmarshal(rr.Body.Bytes(), &j)
+			if j.Status == final || time.Now().After(deadline) {
+				return j
+			}
+		}
+	}
+
+	rr := do(http.MethodPost, "/admin/ingest-jobs", `{"property_ids":[],"review_count":5000,"languages":["xx"],"parts":["bogus"]}`)
+	var p struct {
+		Errors []struct{ Field string } `json:"errors"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &p)
+	if rr.Code != http.StatusBadRequest || len(p.Errors) != 4 {
+		t.Fatalf("invalid job: status %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Duplicates run once; only the requested parts and languages are fetched.
+	rr = do(http.MethodPost, "/admin/ingest-jobs", `{"property_ids":[7,8,7],"languages":["FR"],"parts":["translations","reviews"]}`)
+	loc := rr.Header().Get("Location")
+	if rr.Code != http.StatusAccepted || !strings.HasPrefix(loc, "/admin/ingest-jobs/") {
+		t.Fatalf("create: status %d %s", rr.Code, rr.Body.String())
+	}
+	j := poll(loc, "", domain.JobSucceeded)
+	if j.Status != domain.JobSucceeded || j.Progress.Total != 2 || j.Progress.Done != 2 || j.Progress.Partial != 2 ||
+		!slices.Equal(j.Options.Languages, []string{"fr"}) || !slices.Equal(j.Options.Parts, []string{"reviews", "translations"}) ||
+		len(j.Reports) != 2 || len(j.Reports[0].Steps) != 2 || j.Reports[0].Steps[1].Step != "i18n:fr" {
+		t.Fatalf("job: %+v", j)
+	}
+	// Each progress write stores only the reports added since the last one.
+	jobs.mu.Lock()
+	written := jobs.written
+	jobs.mu.Unlock()
+	if written != 2 {
+		t.Fatalf("reports written %d times, want once per hotel", written)
+	}
+	if j = poll(loc, "?outcome=ok", domain.JobSucceeded); len(j.Reports) != 0 || j.Progress.Done != 2 {
+		t.Fatalf("filtered reports: %+v", j)
+	}
+	if rr = do(http.MethodGet, loc+"?outcome=meh", ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("bad outcome filter: status %d", rr.Code)
+	}
+	if rr = do(http.MethodDelete, loc, ""); rr.Code != http.StatusOK {
+		t.Fatalf("cancel finished job: status %d", rr.Code)
+	}
+
+	// Cancelling lets the hotel in flight finish and leaves the rest out.
+	cupid.gate, cupid.entered = make(chan struct{}), make(chan struct{})
+	loc = do(http.MethodPost, "/admin/ingest-jobs", `{"property_ids":[1,2,3]}`).Header().Get("Location")
+	<-cupid.entered
+	if rr = do(http.MethodDelete, loc, ""); rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"cancel_requested_at":"`) {
+		t.Fatalf("cancel: status %d %s", rr.Code, rr.Body.String())
+	}
+	cupid.gate <- struct{}{}
+	if j = poll(loc, "", domain.JobCancelled); j.Status != domain.JobCancelled || j.Progress.Total != 3 || j.Progress.Done != 1 {
+		t.Fatalf("cancelled job: %+v", j)
+	}
+	if rr = do(http.MethodDelete, "/admin/ingest-jobs/nope", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("cancel unknown job: status %d", rr.Code)
+	}
+
+	// A job whose replica died is swept to interrupted, not left running.
+	orphan := domain.IngestJob{ID: "0123456789abcdef0123456789abcdef", Status: domain.JobRunning, PropertyIDs: []int64{4, 5}, CreatedAt: time.Now()}
+	_ = jobs.CreateIngestJob(context.Background(), orphan)
+	_ = jobs.AddIngestJobReports(context.Background(), orphan.ID, []domain.IngestReport{{PropertyID: 4, Outcome: domain.IngestOK, Steps: []domain.IngestStep{}}})
+	jobs.stale = []string{orphan.ID}
+	rr = do(http.MethodDelete, "/admin/ingest-jobs/"+orphan.ID, "")
+	_ = json.Unmarshal(rr.Body.Bytes(), &j)
+	if rr.Code != http.StatusOK || j.Status != domain.JobInterrupted || j.Progress.Done != 1 {
+		t.Fatalf("cancel orphaned job: status %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReadyz_DependencyBreakdown(t *testing.T) {
+	up := func(context.Context) (string, error) { return "", nil }
+	down := func(context.Context) (string, error) { return "", errors.New("connection refused") }
+	hung := func(ctx context.Context) (string, error) { <-ctx.Done(); return "", ctx.Err() }
+	schema := func(context.Context) (string, error) { return "version 16 (binary expects 16)", nil }
+
+	cases := []struct {
+		name           string
+		mysql, redis   func(context.Context) (string, error)
+		code           int
+		status         string
+		mysqlS, redisS string
+	}{
+		{"all up", up, up, http.StatusOK, "ready", "ok", "ok"},
+		{"redis down degrades", up, down, http.StatusOK, "degraded", "ok", "down"},
+		{"mysql down", down, up, http.StatusServiceUnavailable, "unavailable", "down", "ok"},
+		{"hung mysql times out", hung, up, http.StatusServiceUnavailable, "unavailable", "down", "ok"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httpserver.New()
+			srv.MountHandlers(&httpserver.Handlers{
+				Q: app.NewQueryService(&fakeRepo{}, &fakeCache{}, time.Minute),
+				H: app.NewHealthService(20*time.Millisecond,
+					app.Check{Name: "mysql", Probe: tc.mysql},
+					app.Check{Name: "schema", Probe: schema},
+					app.Check{Name: "redis", Optional: true, Probe: tc.redis},
+				),
+			})
+			h := conform(t, srv.Mux())
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rr.Code != tc.code || rr.Header().Get("Cache-Control") != "no-store" {
+				t.Fatalf("code %d, headers %v: %s", rr.Code, rr.Header(), rr.Body)
+			}
+			var body struct {
+				Status string `json:"status"`
+				Checks map[string]struct {
+					Status   string `json:"status"`
+					Optional bool   `json:"optional"`
+					Detail   string `json:"detail"`
+					Error    string `json:"error"`
+				} `json:"checks"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			m, r, s := body.Checks["mysql"], body.Checks["redis"], body.Checks["schema"]
+			if body.Status != tc.status || m.Status != tc.mysqlS || r.Status != tc.redisS || !r.Optional || m.Optional {
+				t.Fatalf("unexpected body %s", rr.Body)
+			}
+			if (m.Status == "down") != (m.Error != "") || s.Detail != "version 16 (binary expects 16)" {
+				t.Fatalf("unexpected body %s", rr.Body)
+			}
+
+			// liveness never looks at dependencies
+			rr = httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if rr.Code != http.StatusOK || rr.Body.String() != "ok" {
+				t.Fatalf("healthz %d %q", rr.Code, rr.Body)
+			}
+		})
+	}
+}
+
+func TestAdmin_ShutdownInterruptsJobs(t *testing.T) {
+	cupid := &fakeCupid{gate: make(chan struct{}), entered: make(chan struct{})}
+	repo, store := &fakeRepo{}, &fakeIngestJobs{}
+	serve := func() (*app.IngestJobService, http.Handler) {
+		jobs := app.NewIngestJobService(app.NewIngestionService(cupid, repo, &fakeCache{}), store,
+			app.IngestJobConfig{ReviewCount: 10, Workers: 1, SaveEvery: time.Millisecond})
+		srv := httpserver.New()
+		srv.MountHandlers(&httpserver.Handlers{Q: app.NewQueryService(repo, &fakeCache{}, time.Minute), J: jobs})
+		return jobs, conform(t, srv.Mux())
+	}
+	post := func(h http.Handler, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/ingest-jobs", strings.NewReader(body)))
+		return rr
+	}
+	type job struct {
+		Status  string `json:"status"`
+		Reports []struct {
+			PropertyID int64 `json:"property_id"`
+		} `json:"reports"`
+	}
+	get := func(h http.Handler, loc string) job {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, loc, nil))
+		var j job
+		if err := json.Unmarshal(rr.Body.Bytes(), &j); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("get %s: status %d %s", loc, rr.Code, rr.Body.String())
+		}
+		return j
+	}
+
+	// Shutdown stops dispatching and refuses new jobs; the hotel in flight
+	// finishes and the job keeps its report.
+	jobs, h := serve()
+	loc := post(h, `{"property_ids":[1,2,3]}`).Header().Get("Location")
+	<-cupid.entered
+	shut := make(chan error, 1)
+	go func() { shut <- jobs.Shutdown(context.Background()) }()
+	for {
+		rr := post(h, `{"property_ids":[4]}`) // queued behind hotel 1 until refused
+		if rr.Code == http.StatusServiceUnavailable {
+			if rr.Header().Get("Retry-After") == "" {
+				t.Fatalf("refused job without Retry-After")
+			}
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cupid.gate <- struct{}{}
+	if err := <-shut; err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if j := get(h, loc); j.Status != domain.JobInterrupted || len(j.Reports) != 1 || j.Reports[0].PropertyID != 1 {
+		t.Fatalf("interrupted job: %+v", j)
+	}
+	j, _ := store.GetIngestJob(context.Background(), strings.TrimPrefix(loc, "/admin/ingest-jobs/"))
+	if !slices.Equal(j.Remaining(), []int64{2, 3}) {
+		t.Fatalf("remaining %v", j.Remaining())
+	}
+
+	// Past the grace period hotels in flight are cancelled and get no report.
+	jobs, h = serve()
+	loc = post(h, `{"property_ids":[5,6]}`).Header().Get("Location")
+	<-cupid.entered
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := jobs.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("shutdown past grace: %v", err)
+	}
+	if j := get(h, loc); j.Status != domain.JobInterrupted || len(j.Reports) != 0 {
+		t.Fatalf("aborted job: %+v", j)
+	}
+}
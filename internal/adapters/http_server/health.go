@@ -0,0 +1,16 @@
+package httpserver
+
+import "net/http"
+
+// readyz tells load balancers whether to route traffic here: 503 when a
+// required dependency is down, 200 otherwise, a failed optional one
+// reported as degraded. /healthz stays a liveness check and never looks
+// at dependencies, so a database outage does not get replicas restarted.
+func (h *Handlers) readyz(w http.ResponseWriter, r *http.Request) {
+	rd := h.H.Ready(r.Context())
+	status := http.StatusOK
+	if !rd.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writePrivateJSON(w, status, toReadinessDTO(rd))
+}
@@ -0,0 +1,104 @@
+package httpserver
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cupid_hotel/internal/domain"
+)
+
+// resolveLang picks the content language: an explicit lang (validated against
+// the registry) wins, otherwise Accept-Language is negotiated. On an
+// unsupported explicit value it writes a 400 problem and returns false.
+func (h *Handlers) resolveLang(w http.ResponseWriter, r *http.Request, explicit string) (string, bool) {
+	langs := h.Q.Languages()
+	if explicit = strings.TrimSpace(explicit); explicit != "" {
+		if !langs.IsSupported(explicit) {
+			writeInvalid(w, r, "lang", "lang must be one of "+strings.Join(langs.Supported, ", "))
+			return "", false
+		}
+		return strings.ToLower(explicit), true
+	}
+	w.Header().Add("Vary", "Accept-Language")
+	return negotiateLang(r.Header.Get("Accept-Language"), langs), true
+}
+
+type langRange struct {
+	tag string
+	q   float64
+}
+
+// negotiateLang implements RFC 4647 "Lookup" over an Accept-Language header:
+// ranges are tried by descending q (ties keep header order), each one
+// progressively truncated (de-CH-1996 -> de-CH -> de) until a supported tag
+// matches. "*" and no match at all yield the default language.
+func negotiateLang(header string, langs domain.Languages) string {
+	var ranges []langRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if v, ok := strings.CutPrefix(p, "q="); ok {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil || f < 0 || f > 1 {
+					f = 0
+				}
+				q = f
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, langRange{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, rg := range ranges {
+		if rg.tag == "*" {
+			return langs.Default
+		}
+		for tag := rg.tag; tag != ""; {
+			if langs.IsSupported(tag) {
+				return tag
+			}
+			i := strings.LastIndex(tag, "-")
+			if i < 0 {
+				break
+			}
+			tag = tag[:i]
+			// Never end a truncated tag on a single-letter singleton (RFC 4647 §3.4).
+			if j := strings.LastIndex(tag, "-"); j >= 0 && j == len(tag)-2 {
+				tag = tag[:j]
+			}
+		}
+	}
+	return langs.Default
+}
+
+// contentLanguage lists the languages that actually served the views'
+// translated fields, in first-seen order (e.g. "fr, en" after a fallback).
+func contentLanguage(lang string, views ...domain.HotelView) string {
+	var out []string
+	seen := map[string]bool{}
+	add := func(l string) {
+		if l != "" && !seen[l] {
+			seen[l] = true
+			out = append(out, l)
+		}
+	}
+	for _, hv := range views {
+		for _, f := range []string{"name", "description", "policies"} {
+			add(hv.FieldLanguages[f])
+		}
+	}
+	if len(out) == 0 {
+		add(lang)
+	}
+	return strings.Join(out, ", ")
+}
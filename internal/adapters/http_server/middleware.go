@@ -3,6 +3,8 @@ package httpserver
 import (
 	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,8 +14,42 @@ import (
 	"cupid_hotel/internal/adapters/observability"
 )
 
-func Timeout(d time.Duration) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler { return http.TimeoutHandler(next, d, "timeout") }
+// Timeout bounds each request to d. Paths containing one of exempt (e.g.
+// "/export/") bypass it: http.TimeoutHandler buffers the whole response,
+// which streaming endpoints cannot afford; they end when the client leaves.
+func Timeout(d time.Duration, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		bounded := http.TimeoutHandler(next, d, "timeout")
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, p := range exempt {
+				if strings.Contains(r.URL.Path, p) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			bounded.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ---- API deprecation headers ----
+
+// Deprecated marks responses as deprecated since `since` (RFC 9745), announces
+// removal at `sunset` (RFC 8594), and links the same path under the successor
+// prefix (e.g. /v1 -> /v2).
+func Deprecated(since, sunset time.Time, prefix, successor string) func(http.Handler) http.Handler {
+	dep := "@" + strconv.FormatInt(since.Unix(), 10)
+	sun := sunset.UTC().Format(http.TimeFormat)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", dep)
+			w.Header().Set("Sunset", sun)
+			if rest, ok := strings.CutPrefix(r.URL.Path, prefix); ok {
+				w.Header().Add("Link", "<"+successor+rest+`>; rel="successor-version"`)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // ---- status-recording ResponseWriter ----
@@ -39,6 +75,9 @@ func (w *srw) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer (Flush).
+func (w *srw) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
 func (w *srw) Status() int {
 	if w.status == 0 {
 		return http.StatusOK
@@ -85,6 +124,38 @@ func Logger(l zerolog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
+// clientIP is the address per-client limits are keyed on. Unlike remoteIP it
+// can't be picked by the client: X-Forwarded-For is walked from the right,
+// and only while the hop that appended an entry is a trusted proxy.
+func (h *Handlers) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0 && h.trusted(addr); i-- {
+		prev, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = prev
+	}
+	return addr.Unmap().String()
+}
+
+func (h *Handlers) trusted(addr netip.Addr) bool {
+	for _, p := range h.TrustedProxies {
+		if p.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
 // Picks first X-Forwarded-For IP, else X-Real-IP, else RemoteAddr host.
 func remoteIP(r *http.Request) string {
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
@@ -0,0 +1,95 @@
+package httpserver
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+
+	"cupid_hotel/api"
+	"cupid_hotel/internal/domain"
+)
+
+// serveSpec serves the embedded contract, Lang narrowed to the configured set.
+func serveSpec(spec *api.Spec) http.HandlerFunc {
+	sum := sha1.Sum(spec.YAML)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Header().Set("ETag", etag)
+		if notModified(r, etag, time.Time{}) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write(spec.YAML)
+	}
+}
+
+// validateParams rejects requests whose path, query or header parameters
+// break the spec with a 400 problem listing every violation. Bodies are left
+// to the handlers, and authentication to authenticate; requests matching no
+// documented operation fall through to chi's 404/405.
+func validateParams(spec *api.Spec) func(http.Handler) http.Handler {
+	opts := &openapi3filter.Options{
+		ExcludeRequestBody:  true,
+		SkipSettingDefaults: true, // handlers apply their own
+		MultiError:          true,
+		AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, params, err := spec.Router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			in := &openapi3filter.RequestValidationInput{Request: r, PathParams: params, Route: route, Options: opts}
+			if err := openapi3filter.ValidateRequest(r.Context(), in); err != nil {
+				writeError(w, r, paramErrors(err), "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// paramErrors turns validator errors into a ValidationError, one field error
+// per offending parameter.
+func paramErrors(err error) error {
+	var errs []error
+	if me, ok := err.(openapi3.MultiError); ok {
+		errs = me
+	} else {
+		errs = []error{err}
+	}
+	ve := &domain.ValidationError{}
+	for _, e := range errs {
+		var re *openapi3filter.RequestError
+		if !errors.As(e, &re) || re.Parameter == nil {
+			ve.Fields = append(ve.Fields, domain.FieldError{Field: "request", Reason: e.Error()})
+			continue
+		}
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: re.Parameter.Name, Reason: re.Parameter.Name + ": " + paramReason(re)})
+	}
+	return ve
+}
+
+func paramReason(re *openapi3filter.RequestError) string {
+	var se *openapi3.SchemaError
+	if errors.As(re.Err, &se) {
+		return se.Reason
+	}
+	var pe *openapi3filter.ParseError
+	if errors.As(re.Err, &pe) {
+		return pe.Reason
+	}
+	if re.Err != nil {
+		return re.Err.Error()
+	}
+	return re.Reason
+}
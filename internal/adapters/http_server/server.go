@@ -14,10 +14,12 @@ func New() *Server {
 	m := chi.NewRouter()
 
 	// ✅ All middlewares go here (before any routes are added)
-	m.Use(chimw.RealIP)
+	// No chimw.RealIP: RemoteAddr stays the peer, since per-client limits may
+	// only follow X-Forwarded-For through trusted proxies. Logs read it anyway.
 	m.Use(chimw.RequestID)
-	m.Use(chimw.Recoverer)           // chi's built-in recover
-	m.Use(Timeout(15 * time.Second)) // timeout wrapper
+	m.Use(chimw.Recoverer)                     // chi's built-in recover
+	m.Use(chimw.GetHead)                       // HEAD is served by the GET route
+	m.Use(Timeout(15*time.Second, "/export/")) // timeout wrapper; exports stream unbounded
 	m.Use(Metrics)
 	m.Use(Logger(log.Logger))
 
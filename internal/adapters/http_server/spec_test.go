@@ -0,0 +1,211 @@
+package httpserver_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+
+	"cupid_hotel/api"
+	httpserver "cupid_hotel/internal/adapters/http_server"
+	"cupid_hotel/internal/app"
+	"cupid_hotel/internal/domain"
+)
+
+// ---- contract conformance ----
+
+// testSpec is the served contract, made strict for responses: objects may
+// not carry properties it does not document, so renamed or added fields fail
+// as well as mistyped ones. (Clients keep the lenient, extensible reading.)
+var testSpec = func() *api.Spec {
+	s, err := api.Load(domain.DefaultLanguages().Supported)
+	if err != nil {
+		panic(err)
+	}
+	seen := map[*openapi3.Schema]bool{}
+	for _, sr := range s.Doc.Components.Schemas {
+		closeSchema(sr, seen)
+	}
+	for _, item := range s.Doc.Paths.Map() {
+		for _, op := range item.Operations() {
+			for _, rr := range op.Responses.Map() {
+				for _, mt := range rr.Value.Content {
+					closeSchema(mt.Schema, seen)
+				}
+			}
+		}
+	}
+	return s
+}()
+
+// closeSchema forbids undocumented properties on sr and the schemas it nests.
+func closeSchema(sr *openapi3.SchemaRef, seen map[*openapi3.Schema]bool) {
+	if sr == nil || sr.Value == nil || seen[sr.Value] {
+		return
+	}
+	sc := sr.Value
+	seen[sc] = true
+	if len(sc.Properties) > 0 && sc.AdditionalProperties.Has == nil && sc.AdditionalProperties.Schema == nil {
+		sc.AdditionalProperties.Has = openapi3.Ptr(false)
+	}
+	for _, p := range sc.Properties {
+		closeSchema(p, seen)
+	}
+	closeSchema(sc.Items, seen)
+	closeSchema(sc.AdditionalProperties.Schema, seen)
+	for _, list := range []openapi3.SchemaRefs{sc.OneOf, sc.AnyOf, sc.AllOf} {
+		for _, x := range list {
+			closeSchema(x, seen)
+		}
+	}
+}
+
+func init() {
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", ndjsonDecoder)
+}
+
+// ndjsonDecoder checks every line of an NDJSON stream against the item
+// schema and hands the first one on.
+func ndjsonDecoder(body io.Reader, _ http.Header, schema *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	var first any
+	sc := bufio.NewScanner(body)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		var v any
+		if err := json.Unmarshal(sc.Bytes(), &v); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if err := schema.Value.VisitJSON(v, openapi3.VisitAsResponse()); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if n == 1 {
+			first = v
+		}
+	}
+	if first == nil {
+		return map[string]any{}, sc.Err()
+	}
+	return first, sc.Err()
+}
+
+// conform serves h and fails t whenever a response to a documented operation
+// breaks the spec: an undocumented status, a header or a body that does not
+// match its schema. Every test server goes through it, so drift between the
+// handlers and api/openapi.yaml fails the build.
+func conform(t *testing.T, h http.Handler) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		res := rec.Result()
+		body := rec.Body.Bytes()
+
+		if route, params, err := testSpec.Router.FindRoute(r); err == nil {
+			in := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{Request: r, PathParams: params, Route: route},
+				Status:                 res.StatusCode,
+				Header:                 res.Header,
+				Body:                   io.NopCloser(bytes.NewReader(body)),
+				Options: &openapi3filter.Options{
+					IncludeResponseStatus: true,
+					ExcludeResponseBody:   r.Method == http.MethodHead || res.StatusCode == http.StatusNotModified,
+					MultiError:            true,
+				},
+			}
+			if err := openapi3filter.ValidateResponse(context.Background(), in); err != nil {
+				t.Errorf("%s %s: %d response breaks the spec: %v\n%s", r.Method, r.URL, res.StatusCode, err, body)
+			}
+		}
+
+		for k, vv := range rec.Header() {
+			w.Header()[k] = vv
+		}
+		w.WriteHeader(res.StatusCode)
+		_, _ = w.Write(body)
+		if f, ok := w.(http.Flusher); ok && rec.Flushed {
+			f.Flush()
+		}
+		for k, vv := range res.Trailer {
+			w.Header()[k] = vv
+		}
+	})
+}
+
+// ---- tests ----
+
+func TestSpec_ServedWithConfiguredLanguages(t *testing.T) {
+	q := app.NewQueryService(&fakeRepo{}, &fakeCache{}, time.Minute)
+	q.SetLanguages(domain.Languages{Supported: []string{"en", "de"}, Default: "en"})
+	spec, err := api.Load(q.Languages().Supported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httpserver.New()
+	srv.MountHandlers(&httpserver.Handlers{Q: q, Spec: spec})
+	h := srv.Mux()
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/yaml" ||
+		!strings.Contains(rr.Body.String(), "enum: [en, de] # SUPPORTED_LANGS") {
+		t.Fatalf("spec: %d %s", rr.Code, rr.Header())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil)
+	req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified {
+		t.Fatalf("revalidation: status %d", rr.Code)
+	}
+}
+
+func TestSpec_ValidatesRequestParameters(t *testing.T) {
+	srv := httpserver.New()
+	srv.MountHandlers(&httpserver.Handlers{
+		Q:    app.NewQueryService(&fakeRepo{hv: domain.HotelView{ID: 7, Language: "en"}}, &fakeCache{}, time.Minute),
+		Spec: testSpec,
+	})
+	h := conform(t, srv.Mux())
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get("/v2/hotels?stars=9&lang=xx&limit=abc")
+	var p struct {
+		Type   string `json:"type"`
+		Errors []struct {
+			Field  string `json:"field"`
+			Reason string `json:"reason"`
+		} `json:"errors"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &p)
+	fields := map[string]bool{}
+	for _, e := range p.Errors {
+		fields[e.Field] = true
+	}
+	if rr.Code != http.StatusBadRequest || rr.Header().Get("Content-Type") != "application/problem+json" ||
+		p.Type != "/problems/invalid-argument" || len(p.Errors) != 3 || !fields["stars"] || !fields["lang"] || !fields["limit"] {
+		t.Fatalf("invalid params: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr = get("/v2/hotels/abc"); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"field":"id"`) {
+		t.Fatalf("path param: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr = get("/v2/hotels/7?lang=fr"); rr.Code != http.StatusOK {
+		t.Fatalf("valid request: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr = get("/v2/nowhere"); rr.Code != http.StatusNotFound {
+		t.Fatalf("undocumented route: %d", rr.Code)
+	}
+}
@@ -0,0 +1,146 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"cupid_hotel/internal/domain"
+)
+
+// Subscriptions carry a signing secret, so both API versions answer with
+// DTOs (never the raw domain struct) and only creation echoes the secret.
+
+func (h *Handlers) webhookRoutes(r chi.Router) {
+	r.Post("/", h.createWebhook)
+	r.Get("/", h.listWebhooks)
+	r.Get("/{id}", h.getWebhook)
+	r.Delete("/{id}", h.deleteWebhook)
+	r.Post("/{id}/enable", h.enableWebhook)
+	r.Get("/{id}/deliveries", h.listWebhookDeliveries)
+}
+
+type createWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+func (h *Handlers) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&req); err != nil {
+		writeInvalid(w, r, "body", `body must be {"url":"https://...","events":["hotel.updated"],"secret":"..."}`)
+		return
+	}
+	sub, err := h.W.Create(r.Context(), domain.WebhookSubscription{URL: req.URL, Secret: req.Secret, Events: req.Events})
+	if err != nil {
+		writeError(w, r, err, "webhook")
+		return
+	}
+	dto := toWebhookDTO(sub)
+	dto.Secret = sub.Secret
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+strconv.FormatInt(sub.ID, 10))
+	writePrivateJSON(w, http.StatusCreated, dto)
+}
+
+func (h *Handlers) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.W.List(r.Context())
+	if err != nil {
+		writeError(w, r, err, "webhooks")
+		return
+	}
+	items := make([]webhookDTO, len(subs))
+	for i, s := range subs {
+		items[i] = toWebhookDTO(s)
+	}
+	writePrivateJSON(w, http.StatusOK, webhooksDTO{Items: items})
+}
+
+func (h *Handlers) getWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	sub, err := h.W.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "webhook")
+		return
+	}
+	writePrivateJSON(w, http.StatusOK, toWebhookDTO(sub))
+}
+
+func (h *Handlers) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.W.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err, "webhook")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) enableWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	sub, err := h.W.Enable(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err, "webhook")
+		return
+	}
+	writePrivateJSON(w, http.StatusOK, toWebhookDTO(sub))
+}
+
+// listWebhookDeliveries serves the delivery log: one item per POST attempt.
+func (h *Handlers) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	limit := 50
+	if ls := r.URL.Query().Get("limit"); ls != "" {
+		l, err := strconv.Atoi(ls)
+		if err != nil || l <= 0 || l > 500 {
+			writeInvalid(w, r, "limit", "limit must be an integer between 1 and 500")
+			return
+		}
+		limit = l
+	}
+	as, err := h.W.Attempts(r.Context(), id, limit)
+	if err != nil {
+		writeError(w, r, err, "webhook")
+		return
+	}
+	items := make([]webhookAttemptDTO, len(as))
+	for i, a := range as {
+		items[i] = toWebhookAttemptDTO(a)
+	}
+	writePrivateJSON(w, http.StatusOK, webhookAttemptsDTO{Items: items})
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeInvalid(w, r, "id", "id must be a number")
+		return 0, false
+	}
+	return id, true
+}
+
+// writePrivateJSON writes management responses (webhooks, API keys), which
+// are never cached and are the same for both API versions.
+func writePrivateJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("failed to write management response")
+	}
+}
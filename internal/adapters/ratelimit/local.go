@@ -0,0 +1,74 @@
+// Package ratelimit keeps token buckets in process memory.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"cupid_hotel/internal/domain"
+)
+
+// maxBuckets bounds the buckets a Local keeps.
+const maxBuckets = 100_000
+
+// Local enforces quotas per process: with N replicas a consumer gets up to N
+// times its quota. Use the Redis limiter to share one bucket.
+type Local struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	max     int
+	now     func() time.Time
+}
+
+type bucket struct {
+	lim *rate.Limiter
+	q   domain.Quota
+}
+
+func NewLocal() *Local {
+	return &Local{buckets: map[string]*bucket{}, max: maxBuckets, now: time.Now}
+}
+
+func (l *Local) Allow(_ context.Context, key string, q domain.Quota) (domain.RateDecision, error) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok || b.q != q {
+		if !ok && len(l.buckets) >= l.max {
+			l.evict(now)
+		}
+		b = &bucket{lim: rate.NewLimiter(rate.Limit(q.Rate), q.Burst), q: q}
+		l.buckets[key] = b
+	}
+
+	d := domain.RateDecision{Allowed: b.lim.AllowN(now, 1), Limit: q.Burst}
+	tokens := b.lim.TokensAt(now)
+	d.Remaining = max(0, int(math.Floor(tokens)))
+	d.Reset = seconds((float64(q.Burst) - tokens) / q.Rate)
+	if !d.Allowed {
+		d.RetryAfter = seconds((1 - tokens) / q.Rate)
+	}
+	return d, nil
+}
+
+// evict drops the buckets that refilled: a new one starts full, so forgetting
+// them changes no decision. When every bucket is still draining the map is
+// emptied, like the API key cache, rather than grown.
+func (l *Local) evict(now time.Time) {
+	for k, b := range l.buckets {
+		if b.lim.TokensAt(now) >= float64(b.q.Burst) {
+			delete(l.buckets, k)
+		}
+	}
+	if len(l.buckets) >= l.max {
+		clear(l.buckets)
+	}
+}
+
+func seconds(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
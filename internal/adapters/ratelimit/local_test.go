@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cupid_hotel/internal/domain"
+)
+
+func TestLocal_EvictsRefilledBuckets(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewLocal()
+	l.max = 3
+	l.now = func() time.Time { return now }
+	q := domain.Quota{Rate: 1, Burst: 2}
+	allow := func(key string) domain.RateDecision {
+		d, _ := l.Allow(context.Background(), key, q)
+		return d
+	}
+
+	allow("busy")
+	allow("busy")
+	if d := allow("busy"); d.Allowed {
+		t.Fatal("busy: third request within a second allowed")
+	}
+	for i := range 10 {
+		allow(fmt.Sprintf("client:%d", i))
+		now = now.Add(100 * time.Millisecond)
+	}
+	if len(l.buckets) > l.max {
+		t.Fatalf("%d buckets kept, cap %d", len(l.buckets), l.max)
+	}
+
+	// Once refilled, a bucket may go: a new one starts just as full.
+	now = now.Add(10 * time.Second)
+	allow("busy")
+	allow("busy")
+	allow("late")
+	if d := allow("busy"); d.Allowed {
+		t.Fatal("busy: drained bucket was forgotten")
+	}
+}
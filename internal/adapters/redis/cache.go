@@ -39,3 +39,47 @@ func (r *Cache) Del(ctx context.Context, key string) error {
 	observability.ObserveCache("redis", "del")
 	return r.c.Del(ctx, key).Err()
 }
+
+func (r *Cache) MGet(ctx context.Context, keys []string, dst []any) ([]bool, error) {
+	hits := make([]bool, len(keys))
+	if len(keys) == 0 {
+		return hits, nil
+	}
+	vals, err := r.c.MGet(ctx, keys...).Result()
+	if err != nil {
+		return hits, err
+	}
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok { // nil = miss
+			observability.ObserveCache("redis", "miss")
+			continue
+		}
+		if err := json.Unmarshal([]byte(s), dst[i]); err != nil {
+			observability.ObserveCache("redis", "miss")
+			continue
+		}
+		observability.ObserveCache("redis", "hit")
+		hits[i] = true
+	}
+	return hits, nil
+}
+
+func (r *Cache) MSet(ctx context.Context, kv map[string]any, ttlSec int) error {
+	if len(kv) == 0 {
+		return nil
+	}
+	pipe := r.c.Pipeline()
+	for k, v := range kv {
+		b, _ := json.Marshal(v)
+		observability.ObserveCache("redis", "set")
+		pipe.Set(ctx, k, b, time.Duration(ttlSec)*time.Second)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Ping checks that Redis answers.
+func (r *Cache) Ping(ctx context.Context) error {
+	return r.c.Ping(ctx).Err()
+}
@@ -0,0 +1,73 @@
+package redisad
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"cupid_hotel/internal/domain"
+)
+
+// tokenBucket refills and takes one token atomically. Time comes from the
+// Redis server, so replicas with skewed clocks share one consistent bucket.
+// Returns {allowed, tokens left} (tokens as a string: Lua numbers would be
+// truncated to integers on the way out).
+var tokenBucket = redis.NewScript(`
+local rate  = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t     = redis.call('TIME')
+local now   = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local b      = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1]) or burst
+local ts     = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// Limiter is a token bucket per key shared by every API replica.
+type Limiter struct{ c *redis.Client }
+
+// NewLimiter shares the cache's connection pool.
+func NewLimiter(c *Cache) *Limiter { return &Limiter{c: c.c} }
+
+func (l *Limiter) Allow(ctx context.Context, key string, q domain.Quota) (domain.RateDecision, error) {
+	res, err := tokenBucket.Run(ctx, l.c, []string{"ratelimit:" + key}, q.Rate, q.Burst).Slice()
+	if err != nil {
+		return domain.RateDecision{}, err
+	}
+	if len(res) != 2 {
+		return domain.RateDecision{}, fmt.Errorf("token bucket: unexpected reply %v", res)
+	}
+	allowed, _ := res[0].(int64)
+	s, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return domain.RateDecision{}, fmt.Errorf("token bucket: tokens %q: %w", s, err)
+	}
+
+	d := domain.RateDecision{
+		Allowed:   allowed == 1,
+		Limit:     q.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(q.Burst) - tokens) / q.Rate),
+	}
+	if !d.Allowed {
+		d.RetryAfter = seconds((1 - tokens) / q.Rate)
+	}
+	return d, nil
+}
+
+func seconds(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
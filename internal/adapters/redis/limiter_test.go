@@ -0,0 +1,50 @@
+package redisad_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	redisad "cupid_hotel/internal/adapters/redis"
+	"cupid_hotel/internal/domain"
+)
+
+func TestLimiter_SharedTokenBucket(t *testing.T) {
+	mr := miniredis.RunT(t)
+	now := time.Unix(1_700_000_000, 0)
+	mr.SetTime(now)
+	ctx := context.Background()
+	q := domain.Quota{Rate: 2, Burst: 3}
+
+	// Two replicas, one bucket.
+	a := redisad.NewLimiter(redisad.New(mr.Addr(), "", 0))
+	b := redisad.NewLimiter(redisad.New(mr.Addr(), "", 0))
+	for i, l := range []*redisad.Limiter{a, b, a} {
+		d, err := l.Allow(ctx, "k", q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !d.Allowed || d.Limit != 3 || d.Remaining != 2-i {
+			t.Fatalf("request %d: %+v", i+1, d)
+		}
+	}
+	d, _ := b.Allow(ctx, "k", q)
+	if d.Allowed || d.Remaining != 0 || d.RetryAfter != 500*time.Millisecond || d.Reset != 1500*time.Millisecond {
+		t.Fatalf("over quota: %+v", d)
+	}
+	if d, _ = a.Allow(ctx, "other", q); !d.Allowed {
+		t.Fatalf("buckets are per key: %+v", d)
+	}
+
+	// Refills at Rate per second, capped at Burst.
+	mr.SetTime(now.Add(time.Second))
+	if d, _ = a.Allow(ctx, "k", q); !d.Allowed || d.Remaining != 1 {
+		t.Fatalf("after 1s: %+v", d)
+	}
+	mr.SetTime(now.Add(time.Hour))
+	if d, _ = a.Allow(ctx, "k", q); !d.Allowed || d.Remaining != 2 {
+		t.Fatalf("after an hour: %+v", d)
+	}
+}
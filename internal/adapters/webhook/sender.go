@@ -0,0 +1,117 @@
+// Package webhook POSTs signed event payloads to partner endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cupid_hotel/internal/domain"
+)
+
+// Delivery headers. Receivers should dedupe on HeaderEvent + the payload id:
+// a delivery may arrive more than once.
+const (
+	HeaderSignature = "X-Cupid-Signature" // t=<unix seconds>,v1=<hex HMAC-SHA256>
+	HeaderEvent     = "X-Cupid-Event"
+	HeaderDelivery  = "X-Cupid-Delivery"
+)
+
+// ErrBadSignature is returned by Verify.
+var ErrBadSignature = errors.New("webhook: bad signature")
+
+type Sender struct {
+	hc  *http.Client
+	now func() time.Time
+}
+
+// New returns a Sender whose POSTs time out after timeout. Redirects are not
+// followed: the subscribed URL is the endpoint.
+func New(timeout time.Duration) *Sender {
+	return &Sender{
+		hc: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Send POSTs body to the delivery's subscription, signed with its secret.
+func (s *Sender) Send(ctx context.Context, d domain.WebhookDelivery, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cupid-hotel-webhooks/1.0")
+	req.Header.Set(HeaderEvent, d.Event.Type)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderSignature, Sign(d.Subscription.Secret, s.now(), body))
+
+	resp, err := s.hc.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	// Drain a little so the connection can be reused; the body is ignored.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// Sign builds the signature header value: an HMAC-SHA256 of
+// "<unix seconds>.<body>" keyed with secret. The timestamp lets receivers
+// reject replays.
+func Sign(secret string, at time.Time, body []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+// Verify checks a signature header against body, rejecting timestamps more
+// than tolerance away from now (0 skips that check).
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return fmt.Errorf("%w: malformed header", ErrBadSignature)
+	}
+	if d := now.Sub(time.Unix(sec, 0)).Abs(); tolerance > 0 && d > tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrBadSignature)
+	}
+	want := mac(secret, ts, body)
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(want)) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+func mac(secret, ts string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(ts))
+	m.Write([]byte("."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
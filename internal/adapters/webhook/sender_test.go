@@ -0,0 +1,215 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cupid_hotel/internal/adapters/webhook"
+	"cupid_hotel/internal/app"
+	"cupid_hotel/internal/domain"
+)
+
+const secret = "whsec_test_secret_1234"
+
+func TestSign_VerifyRoundTrip(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	at := time.Unix(1_700_000_000, 0)
+	sig := webhook.Sign(secret, at, body)
+
+	if err := webhook.Verify(secret, sig, body, at.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	cases := map[string]error{
+		"tampered body": webhook.Verify(secret, sig, []byte(`{"id":2}`), at, 0),
+		"wrong secret":  webhook.Verify("another_secret_1234", sig, body, at, 0),
+		"replayed":      webhook.Verify(secret, sig, body, at.Add(time.Hour), 5*time.Minute),
+		"malformed":     webhook.Verify(secret, "v1=abc", body, at, 0),
+	}
+	for name, err := range cases {
+		if !errors.Is(err, webhook.ErrBadSignature) {
+			t.Errorf("%s: want ErrBadSignature, got %v", name, err)
+		}
+	}
+}
+
+// fakeHooks keeps one delivery in memory and records the dispatcher's verdicts.
+type fakeHooks struct {
+	mu        sync.Mutex
+	delivery  domain.WebhookDelivery
+	done      bool
+	attempts  []domain.WebhookAttempt
+	outcomes  []domain.WebhookOutcome
+	failures  int
+	fanOuts   int
+	claimMax  int
+	claimTerm time.Duration
+}
+
+func (f *fakeHooks) CreateWebhook(context.Context, domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	return domain.WebhookSubscription{}, nil
+}
+func (f *fakeHooks) ListWebhooks(context.Context) ([]domain.WebhookSubscription, error) {
+	return nil, nil
+}
+func (f *fakeHooks) GetWebhook(context.Context, int64) (domain.WebhookSubscription, error) {
+	return domain.WebhookSubscription{}, nil
+}
+func (f *fakeHooks) DeleteWebhook(context.Context, int64) error { return nil }
+func (f *fakeHooks) EnableWebhook(context.Context, int64) error { return nil }
+func (f *fakeHooks) ListWebhookAttempts(context.Context, int64, int) ([]domain.WebhookAttempt, error) {
+	return nil, nil
+}
+func (f *fakeHooks) FanOutEvents(context.Context, int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fanOuts++
+	return 0, nil
+}
+func (f *fakeHooks) ClaimDeliveries(_ context.Context, max int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.claimMax, f.claimTerm = max, lease
+	if f.done {
+		return nil, nil
+	}
+	return []domain.WebhookDelivery{f.delivery}, nil
+}
+func (f *fakeHooks) RecordAttempt(_ context.Context, a domain.WebhookAttempt, o domain.WebhookOutcome) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts = append(f.attempts, a)
+	f.outcomes = append(f.outcomes, o)
+	f.delivery.Attempts++
+	if o.Delivered {
+		f.done, f.failures = true, 0
+		return false, nil
+	}
+	f.failures++
+	f.done = o.RetryIn == 0
+	return f.failures >= o.DisableAfter, nil
+}
+
+func TestDispatcher_SignsRetriesAndDelivers(t *testing.T) {
+	var hits int32
+	var got struct {
+		sync.Mutex
+		body  map[string]any
+		event string
+		err   error
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got.Lock()
+		got.err = webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), b, time.Now(), time.Minute)
+		got.event = r.Header.Get(webhook.HeaderEvent)
+		_ = json.Unmarshal(b, &got.body)
+		got.Unlock()
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	lang := "fr"
+	repo := &fakeHooks{delivery: domain.WebhookDelivery{
+		ID:           7,
+		Subscription: domain.WebhookSubscription{ID: 3, URL: ts.URL, Secret: secret},
+		Event:        domain.Event{ID: 42, Type: domain.EventHotelUpdated, PropertyID: 1641879, Lang: &lang, At: time.Now()},
+	}}
+	d := app.NewWebhookDispatcher(repo, webhook.New(time.Second), app.DispatcherConfig{
+		Batch: 5, BaseBackoff: time.Second, MaxBackoff: 4 * time.Second, DisableAfter: 3, Lease: time.Minute,
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := d.RunOnce(context.Background()); err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+	}
+
+	if hits != 2 || len(repo.attempts) != 2 {
+		t.Fatalf("want 2 POSTs and 2 recorded attempts, got %d and %d", hits, len(repo.attempts))
+	}
+	if repo.fanOuts != 3 || repo.claimMax != 5 || repo.claimTerm != time.Minute {
+		t.Fatalf("dispatcher did not poll with its config: %+v", repo)
+	}
+	first, second := repo.attempts[0], repo.attempts[1]
+	if first.Attempt != 1 || first.StatusCode != 503 || first.Error == "" || repo.outcomes[0].Delivered || repo.outcomes[0].RetryIn != time.Second {
+		t.Fatalf("first attempt should fail and retry after the base backoff: %+v %+v", first, repo.outcomes[0])
+	}
+	if second.Attempt != 2 || second.StatusCode != 204 || !repo.outcomes[1].Delivered || second.DeliveryID != 7 || second.SubscriptionID != 3 {
+		t.Fatalf("second attempt should deliver: %+v %+v", second, repo.outcomes[1])
+	}
+
+	got.Lock()
+	defer got.Unlock()
+	if got.err != nil {
+		t.Fatalf("receiver could not verify the signature: %v", got.err)
+	}
+	if got.event != "hotel.updated" || got.body["type"] != "hotel.updated" || got.body["id"] != 42.0 {
+		t.Fatalf("unexpected delivery: event=%q body=%v", got.event, got.body)
+	}
+	if data, _ := got.body["data"].(map[string]any); data["hotel_id"] != 1641879.0 || data["lang"] != "fr" {
+		t.Fatalf("unexpected data: %v", got.body["data"])
+	}
+}
+
+func TestDispatcher_BacksOffThenGivesUp(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	repo := &fakeHooks{delivery: domain.WebhookDelivery{
+		ID:           1,
+		Subscription: domain.WebhookSubscription{ID: 9, URL: ts.URL, Secret: secret},
+		Event:        domain.Event{ID: 1, Type: domain.EventHotelDeactivated, PropertyID: 5},
+	}}
+	d := app.NewWebhookDispatcher(repo, webhook.New(time.Second), app.DispatcherConfig{
+		MaxAttempts: 4, BaseBackoff: time.Second, MaxBackoff: 3 * time.Second, DisableAfter: 10,
+	})
+	for i := 0; i < 6; i++ {
+		if _, err := d.RunOnce(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var retries []time.Duration
+	for _, o := range repo.outcomes {
+		if o.Delivered || o.DisableAfter != 10 {
+			t.Fatalf("unexpected outcome %+v", o)
+		}
+		retries = append(retries, o.RetryIn)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 0} // doubling, capped, then given up
+	if len(retries) != len(want) {
+		t.Fatalf("retries %v, want %v", retries, want)
+	}
+	for i := range want {
+		if retries[i] != want[i] {
+			t.Fatalf("retries %v, want %v", retries, want)
+		}
+	}
+}
+
+func TestSender_ReportsTransportErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	s := webhook.New(50 * time.Millisecond)
+	status, err := s.Send(context.Background(), domain.WebhookDelivery{Subscription: domain.WebhookSubscription{URL: ts.URL, Secret: secret}}, []byte(`{}`))
+	if err == nil || status != 0 {
+		t.Fatalf("want a timeout without status, got %d %v", status, err)
+	}
+}
@@ -0,0 +1,230 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"cupid_hotel/internal/domain"
+)
+
+// amenityAliases maps each canonical amenity code to the phrases that identify
+// it in a raw facility name. Phrases match on whole words after normalization
+// ("Outdoor swimming pool" -> pool), so "spa" never matches "spacious".
+// Codes must exist in the amenities table (see migration 0006_amenities.sql).
+var amenityAliases = map[string][]string{
+	"wifi":             {"wifi", "wi fi", "wireless"},
+	"pool":             {"pool", "swimming"},
+	"spa":              {"spa", "sauna", "hammam", "wellness", "steam room", "massage"},
+	"parking":          {"parking", "garage", "valet"},
+	"pet_friendly":     {"pets", "pet", "pet friendly", "dogs"},
+	"accessible":       {"wheelchair", "accessible", "accessibility", "disabled"},
+	"gym":              {"gym", "fitness"},
+	"restaurant":       {"restaurant", "dining"},
+	"bar":              {"bar", "lounge", "pub"},
+	"air_conditioning": {"air conditioning", "air conditioned", "air conditioner"},
+	"breakfast":        {"breakfast"},
+	"airport_shuttle":  {"airport shuttle", "airport transfer", "shuttle"},
+	"room_service":     {"room service"},
+	"front_desk_24h":   {"24 hour front desk", "24 hour reception", "24h reception", "24h front desk"},
+	"laundry":          {"laundry", "dry cleaning"},
+	"business_center":  {"business centre", "business center", "conference"},
+	"family_rooms":     {"family rooms", "family room", "kids club"},
+	"non_smoking":      {"non smoking", "no smoking", "smoke free"},
+}
+
+// facilityCodes maps Cupid facility ids to codes. It is curated by hand, so
+// the same id maps to the same code in every process whatever its name says
+// (reworded, localized or missing). Ids not listed fall back to the name.
+var facilityCodes = map[int64]string{
+	2:   "parking",
+	3:   "restaurant",
+	4:   "pet_friendly",
+	5:   "room_service",
+	7:   "bar",
+	8:   "front_desk_24h",
+	11:  "gym",
+	16:  "non_smoking",
+	17:  "airport_shuttle",
+	22:  "laundry",
+	25:  "accessible",
+	28:  "family_rooms",
+	46:  "parking",
+	54:  "spa",
+	96:  "wifi",
+	107: "wifi",
+	108: "non_smoking",
+	109: "air_conditioning",
+	301: "pool",
+	433: "pool",
+}
+
+// negations mark a facility that states the absence of something
+// ("Pets not allowed"). A bare "no" only negates as the leading word
+// ("No parking"); elsewhere it is usually "no charge" and the like.
+var negations = []string{" not allowed ", " not available ", " without "}
+
+// mapAmenityCodes extracts canonical codes from the property payload's
+// facilities (strings or {facility_id|id, name} objects): listed ids map
+// through facilityCodes, everything else by name. Sorted, unique.
+func mapAmenityCodes(p map[string]any) []string {
+	set := map[string]struct{}{}
+	for _, key := range []string{"facilities", "amenities"} {
+		raw, ok := lookupAny(p, key).([]any)
+		if !ok {
+			continue
+		}
+		for _, it := range raw {
+			var name string
+			switch t := it.(type) {
+			case string:
+				name = t
+			case map[string]any:
+				if id := firstInt64Flexible(t, "facility_id", "id"); id != nil {
+					if code, ok := facilityCodes[*id]; ok {
+						set[code] = struct{}{}
+						continue
+					}
+				}
+				name, _ = t["name"].(string)
+			}
+			for _, code := range amenityCodesFor(name) {
+				set[code] = struct{}{}
+			}
+		}
+		if len(set) > 0 {
+			break // same precedence as firstSliceStrings: first non-empty list wins
+		}
+	}
+	return sortedKeys(set)
+}
+
+// amenityCodesFor returns every code whose aliases occur in a raw name.
+func amenityCodesFor(name string) []string {
+	norm := normalizeFacility(name)
+	if norm == "" {
+		return nil
+	}
+	padded := " " + norm + " "
+	negation := ""
+	if strings.HasPrefix(padded, " no ") {
+		negation = " no "
+	} else {
+		for _, n := range negations {
+			if strings.Contains(padded, n) {
+				negation = n
+				break
+			}
+		}
+	}
+	var out []string
+	for code, phrases := range amenityAliases {
+		for _, ph := range phrases {
+			ph = " " + ph + " "
+			// A negated name only matches phrases that carry the negation ("no smoking").
+			if strings.Contains(padded, ph) && (negation == "" || strings.Contains(ph, negation)) {
+				out = append(out, code)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// canonicalAmenity maps a filter value to a code: codes pass through, other
+// text (legacy raw labels such as "Spa") goes through the alias matcher.
+func canonicalAmenity(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if _, ok := amenityAliases[v]; ok {
+		return v
+	}
+	if codes := amenityCodesFor(v); len(codes) == 1 {
+		return codes[0]
+	}
+	return v
+}
+
+// normalizeFacility lowercases and turns every non-alphanumeric run into a single space.
+func normalizeFacility(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+/********** query side: localized labels **********/
+
+const amenityTaxonomyKey = "amenities:taxonomy"
+
+// taxonomy loads the amenity taxonomy; it only changes with migrations, so a
+// plain TTL entry is enough.
+func (s *QueryService) taxonomy(ctx context.Context) ([]domain.AmenityDef, error) {
+	var defs []domain.AmenityDef
+	if ok, _ := s.cache.Get(ctx, amenityTaxonomyKey, &defs); ok {
+		return defs, nil
+	}
+	defs, err := s.repo.AmenityTaxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.cache.Set(ctx, amenityTaxonomyKey, defs, int(s.cacheTTL.Seconds()))
+	return defs, nil
+}
+
+// amenityLabels resolves one label per code along lang's fallback chain;
+// codes without any label are labelled with the code itself.
+func (s *QueryService) amenityLabels(ctx context.Context, lang string) (map[string]string, error) {
+	defs, err := s.taxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	chain := s.langs.Chain(lang)
+	out := make(map[string]string, len(defs))
+	for _, d := range defs {
+		out[d.Code] = d.Code
+		for _, l := range chain {
+			if label, ok := d.Labels[l]; ok {
+				out[d.Code] = label
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// labelAmenities fills CanonicalAmenities labels of views in lang.
+func (s *QueryService) labelAmenities(ctx context.Context, views []domain.HotelView, lang string) error {
+	labels, err := s.amenityLabels(ctx, lang)
+	if err != nil {
+		return err
+	}
+	for i := range views {
+		for j, a := range views[i].CanonicalAmenities {
+			if label, ok := labels[a.Code]; ok {
+				views[i].CanonicalAmenities[j].Label = label
+			} else {
+				views[i].CanonicalAmenities[j].Label = a.Code
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"cupid_hotel/internal/domain"
+)
+
+// apiKeyPrefix starts every key, so leaked keys are easy to grep for.
+const apiKeyPrefix = "ck_"
+
+// keyCacheTTL is how long a replica trusts a looked-up key; a revocation
+// takes at most this long to reach every replica.
+const keyCacheTTL = 30 * time.Second
+
+// unknownKeyTTL is how long a replica remembers that a key is unknown, so a
+// client retrying a bad key costs one lookup, not one per request. A key
+// created on another replica may be refused for that long.
+const unknownKeyTTL = 10 * time.Second
+
+// maxCachedKeys bounds the key cache; it is simply emptied when full.
+const maxCachedKeys = 10_000
+
+// AuthService authenticates API keys, enforces their quotas and manages them.
+type AuthService struct {
+	repo    domain.APIKeyRepository
+	limiter domain.RateLimiter
+	quota   domain.Quota // for keys without their own
+	anon    domain.Quota // per client, for requests without a known key
+
+	mu   sync.Mutex
+	keys map[string]cachedKey // by key hash
+	now  func() time.Time
+}
+
+type cachedKey struct {
+	key     domain.APIKey
+	unknown bool // no such key, or revoked
+	expires time.Time
+}
+
+func NewAuthService(r domain.APIKeyRepository, l domain.RateLimiter, def domain.Quota) *AuthService {
+	return &AuthService{
+		repo: r, limiter: l, quota: def, anon: domain.Quota{Rate: 1, Burst: 10},
+		keys: map[string]cachedKey{}, now: time.Now,
+	}
+}
+
+// SetClientQuota replaces the per-client quota of requests that carry no
+// known key (see LimitClient).
+func (s *AuthService) SetClientQuota(q domain.Quota) { s.anon = q }
+
+// Authenticate resolves a presented key. Unknown, revoked and missing keys
+// are ErrUnauthenticated; other errors come from the store.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (domain.APIKey, error) {
+	if token == "" {
+		return domain.APIKey{}, fmt.Errorf("%w: an API key is required", domain.ErrUnauthenticated)
+	}
+	hash := hashAPIKey(token)
+	now := s.now()
+
+	s.mu.Lock()
+	c, ok := s.keys[hash]
+	s.mu.Unlock()
+	if ok && now.Before(c.expires) {
+		if c.unknown {
+			return domain.APIKey{}, fmt.Errorf("%w: unknown or revoked API key", domain.ErrUnauthenticated)
+		}
+		return c.key, nil
+	}
+
+	k, err := s.repo.APIKeyByHash(ctx, hash)
+	if errors.Is(err, domain.ErrNotFound) {
+		s.cache(hash, cachedKey{unknown: true, expires: now.Add(unknownKeyTTL)})
+		return domain.APIKey{}, fmt.Errorf("%w: unknown or revoked API key", domain.ErrUnauthenticated)
+	}
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	s.cache(hash, cachedKey{key: k, expires: now.Add(keyCacheTTL)})
+	return k, nil
+}
+
+func (s *AuthService) cache(hash string, c cachedKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.keys) >= maxCachedKeys {
+		clear(s.keys)
+	}
+	s.keys[hash] = c
+}
+
+// Known reports whether token is a valid key this replica has looked up
+// recently; requests carrying one skip LimitClient.
+func (s *AuthService) Known(token string) bool {
+	if token == "" {
+		return false
+	}
+	s.mu.Lock()
+	c, ok := s.keys[hashAPIKey(token)]
+	s.mu.Unlock()
+	return ok && !c.unknown && s.now().Before(c.expires)
+}
+
+// LimitClient takes one request from client's bucket (its IP), shared by its
+// requests without a known key: each may cost a key lookup, so guessing keys
+// or hammering with a revoked one is throttled. Fails open like Limit.
+func (s *AuthService) LimitClient(ctx context.Context, client string) (d domain.RateDecision, ok bool) {
+	d, err := s.limiter.Allow(ctx, "client:"+client, s.anon)
+	if err != nil {
+		log.Warn().Err(err).Str("client", client).Msg("rate limiter unavailable; request not limited")
+		return domain.RateDecision{}, false
+	}
+	return d, true
+}
+
+// Limit takes one request from k's quota. When the limiter itself fails
+// (e.g. Redis is down) it fails open: the error is logged and ok is false,
+// meaning the request goes through without a decision.
+func (s *AuthService) Limit(ctx context.Context, k domain.APIKey) (d domain.RateDecision, ok bool) {
+	d, err := s.limiter.Allow(ctx, "apikey:"+strconv.FormatInt(k.ID, 10), s.QuotaOf(k))
+	if err != nil {
+		log.Warn().Err(err).Int64("api_key", k.ID).Msg("rate limiter unavailable; request not limited")
+		return domain.RateDecision{}, false
+	}
+	return d, true
+}
+
+// QuotaOf is k's quota with the service defaults filled in.
+func (s *AuthService) QuotaOf(k domain.APIKey) domain.Quota {
+	q := k.Quota
+	if q.Rate <= 0 {
+		q.Rate = s.quota.Rate
+	}
+	if q.Burst <= 0 {
+		q.Burst = s.quota.Burst
+	}
+	return q
+}
+
+// CreateKey mints a key. The returned token is the only copy of the secret.
+func (s *AuthService) CreateKey(ctx context.Context, name string, scopes []string, q domain.Quota) (domain.APIKey, string, error) {
+	ve := &domain.ValidationError{}
+	if name = strings.TrimSpace(name); name == "" || len(name) > 128 {
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: "name", Reason: "name must be 1 to 128 characters"})
+	}
+	if len(scopes) == 0 {
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: "scopes", Reason: "scopes must list at least one scope"})
+	}
+	var granted []string
+	for _, sc := range scopes {
+		if !slices.Contains(domain.Scopes, sc) {
+			ve.Fields = append(ve.Fields, domain.FieldError{
+				Field: "scopes", Reason: fmt.Sprintf("unknown scope %q; one of %s", sc, strings.Join(domain.Scopes, ", ")),
+			})
+		} else if !slices.Contains(granted, sc) {
+			granted = append(granted, sc)
+		}
+	}
+	if q.Rate < 0 || q.Burst < 0 {
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: "quota", Reason: "rate and burst must not be negative"})
+	}
+	if len(ve.Fields) > 0 {
+		return domain.APIKey{}, "", ve
+	}
+
+	b := make([]byte, 32)
+	if _, err := crand.Read(b); err != nil {
+		return domain.APIKey{}, "", err
+	}
+	token := apiKeyPrefix + hex.EncodeToString(b)
+	k := domain.APIKey{Name: name, Prefix: token[:len(apiKeyPrefix)+8], Scopes: granted, Quota: q}
+	hash := hashAPIKey(token)
+	k, err := s.repo.CreateAPIKey(ctx, k, hash)
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	s.mu.Lock()
+	delete(s.keys, hash) // usable at once on this replica
+	s.mu.Unlock()
+	return k, token, nil
+}
+
+func (s *AuthService) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+// RevokeKey revokes a key at once on this replica; others notice within
+// keyCacheTTL.
+func (s *AuthService) RevokeKey(ctx context.Context, id int64) error {
+	if err := s.repo.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	for h, c := range s.keys {
+		if !c.unknown && c.key.ID == id {
+			delete(s.keys, h)
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
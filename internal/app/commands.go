@@ -4,152 +4,234 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"cupid_hotel/internal/domain"
 )
 
+// catalogGenKey holds a generation stamp bumped on every property or
+// translation write; list/search cache keys embed it (see QueryService).
+const catalogGenKey = "catalog:gen"
+
+// reviewsGenKey is the per-property generation stamp for review pages.
+func reviewsGenKey(id int64) string { return fmt.Sprintf("reviews:%d:gen", id) }
+
+// IngestionService pulls hotels from Cupid into the store. Every write that
+// changes stored data also logs a change-feed entry and queues the webhook
+// event it emits (see domain.EventFor) in the same transaction.
 type IngestionService struct {
 	cupid domain.CupidClient
 	repo  domain.HotelRepository
 	cache domain.Cache
+	langs domain.Languages
 }
 
 func NewIngestionService(c domain.CupidClient, r domain.HotelRepository, cache domain.Cache) *IngestionService {
-	return &IngestionService{cupid: c, repo: r, cache: cache}
+	return &IngestionService{cupid: c, repo: r, cache: cache, langs: domain.DefaultLanguages()}
 }
 
+// SetLanguages replaces the language registry; it decides which translations
+// are fetched and which cached views are evicted.
+func (s *IngestionService) SetLanguages(l domain.Languages) { s.langs = l }
+
 func (s *IngestionService) IngestHotel(ctx context.Context, id int64, reviewCount int) error {
-	// 1) Fetch property (parent first). Handle known 404/401/403 as "misses".
-	p, err := s.cupid.GetProperty(ctx, id)
-	if err != nil {
-		low := strings.ToLower(err.Error())
-
-		// 404: property not found -> record miss, clear caches, and stop gracefully.
-		if errors.Is(err, domain.ErrNotFound) || strings.Contains(low, "not found") {
-			_ = s.repo.LogMiss(ctx, id, 404, "not found")
+	_, err := s.RefreshHotel(ctx, id, domain.IngestOptions{ReviewCount: reviewCount})
+	return err
+}
+
+// Languages is the registry translations are fetched for.
+func (s *IngestionService) Languages() domain.Languages { return s.langs }
+
+// RefreshHotel ingests the parts of one property opts asks for and reports
+// every step: the property, its reviews and each translation. The error is
+// that of the failed step (the report then says which); later steps are
+// skipped. Reviews and translations alone need the property already stored.
+func (s *IngestionService) RefreshHotel(ctx context.Context, id int64, opts domain.IngestOptions) (domain.IngestReport, error) {
+	langs := opts.Langs
+	if langs == nil {
+		langs = s.langs.Supported
+	}
+	rep := newIngestReport(id, opts, langs)
+
+	// 1) Fetch property (parent first). Handle a definite 404/403 as a "miss":
+	// record it, deactivate, evict stale caches, and stop gracefully.
+	if opts.Has(domain.PartProperty) {
+		p, err := s.cupid.GetProperty(ctx, id)
+		if err != nil {
+			status, miss := upstreamMiss(err)
+			if !miss {
+				// Anything else is unexpected (network/5xx/JSON/etc.) -> bubble up.
+				return failStep(rep, domain.StepProperty, err)
+			}
+			reason := "not found"
+			if status == 403 {
+				reason = "inactive"
+			}
+			_ = s.repo.LogMiss(ctx, id, status, reason)
+			if err := s.repo.DeactivateProperty(ctx, id); err != nil {
+				return failStep(rep, domain.StepProperty, err)
+			}
 			// Evict any stale caches so we don't keep serving an old snapshot.
 			if s.cache != nil {
 				s.invalidateHotelAllLangs(ctx, id)
 				s.invalidateReviews(ctx, id)
+				s.bumpGeneration(ctx, catalogGenKey)
 			}
-			return nil
+			setStep(&rep, domain.IngestStep{Step: domain.StepProperty, Outcome: domain.StepMissed, Status: status, Reason: reason})
+			rep.Outcome = domain.IngestDeactivated
+			return rep, nil
 		}
 
-		// 401/403: unauthorized/forbidden/inactive -> record miss, evict caches, stop.
-		if strings.Contains(low, "403") || strings.Contains(low, "forbidden") ||
-			strings.Contains(low, "401") || strings.Contains(low, "unauthorized") {
-			_ = s.repo.LogMiss(ctx, id, 403, "inactive")
-			if s.cache != nil {
-				s.invalidateHotelAllLangs(ctx, id)
-				s.invalidateReviews(ctx, id)
-			}
-			return nil
+		// Parent upsert first to satisfy FK for i18n/reviews.
+		if err := s.repo.UpsertProperty(ctx, mapProperty(p)); err != nil {
+			return failStep(rep, domain.StepProperty, err)
 		}
+		setStep(&rep, domain.IngestStep{Step: domain.StepProperty, Outcome: domain.StepOK, Stored: 1})
 
-		// Anything else is unexpected (network/5xx/JSON/etc.) -> bubble up.
-		return err
-	}
-
-	// Parent upsert first to satisfy FK for i18n/reviews.
-	if err := s.repo.UpsertProperty(ctx, mapProperty(p)); err != nil {
-		return err
-	}
-
-	// Property change affects all languages -> invalidate all hotel caches.
-	if s.cache != nil {
-		s.invalidateHotelAllLangs(ctx, id)
+		// Property change affects all languages -> invalidate all hotel caches.
+		if s.cache != nil {
+			s.invalidateHotelAllLangs(ctx, id)
+			s.bumpGeneration(ctx, catalogGenKey)
+		}
 	}
 
-	// 2) Reviews: best-effort. We don't fail ingestion on 404/401/403,
+	// 2) Reviews: best-effort. We don't fail ingestion on 404/403,
 	// but we do bubble up other errors. We always invalidate the reviews cache
 	// after a successful call (even if the list is empty) to avoid stale cache.
-	if revs, rerr := s.cupid.GetReviews(ctx, id, reviewCount); rerr != nil {
-		low := strings.ToLower(rerr.Error())
-		switch {
-		case errors.Is(rerr, domain.ErrNotFound) || strings.Contains(low, "not found"):
-			_ = s.repo.LogMiss(ctx, id, 404, "reviews")
+	if opts.Has(domain.PartReviews) {
+		if revs, rerr := s.cupid.GetReviews(ctx, id, opts.ReviewCount); rerr != nil {
+			status, miss := upstreamMiss(rerr)
+			if !miss {
+				return failStep(rep, domain.StepReviews, rerr)
+			}
+			_ = s.repo.LogMiss(ctx, id, status, "reviews")
 			if s.cache != nil {
 				s.invalidateReviews(ctx, id)
 			}
-		case strings.Contains(low, "403") || strings.Contains(low, "forbidden") ||
-			strings.Contains(low, "401") || strings.Contains(low, "unauthorized"):
-			_ = s.repo.LogMiss(ctx, id, 403, "reviews")
+			setStep(&rep, domain.IngestStep{Step: domain.StepReviews, Outcome: domain.StepMissed, Status: status, Reason: "reviews"})
+		} else {
+			// success: even if zero reviews, invalidate cache to drop any stale entries
+			if len(revs) > 0 {
+				if err := s.repo.UpsertReviews(ctx, mapReviews(id, revs)); err != nil {
+					// IMPORTANT: do not swallow this; surface so we know inserts failed
+					return failStep(rep, domain.StepReviews, fmt.Errorf("upsert reviews failed for %d: %w", id, err))
+				}
+			}
 			if s.cache != nil {
 				s.invalidateReviews(ctx, id)
 			}
-		default:
-			return rerr
-		}
-	} else {
-		// success: even if zero reviews, invalidate cache to drop any stale entries
-		if len(revs) > 0 {
-			if err := s.repo.UpsertReviews(ctx, mapReviews(id, revs)); err != nil {
-				// IMPORTANT: do not swallow this; surface so we know inserts failed
-				return fmt.Errorf("upsert reviews failed for %d: %w", id, err)
-			}
-		}
-		if s.cache != nil {
-			s.invalidateReviews(ctx, id)
+			setStep(&rep, domain.IngestStep{Step: domain.StepReviews, Outcome: domain.StepOK, Stored: len(revs)})
 		}
 	}
 
-	// 3) Translations: try en, fr, es; log misses per-language; continue on 404/401/403.
-	for _, lang := range []string{"en", "fr", "es"} {
-		tr, terr := s.cupid.GetTranslation(ctx, id, lang)
-		if terr != nil {
-			low := strings.ToLower(terr.Error())
-
-			if errors.Is(terr, domain.ErrNotFound) || strings.Contains(low, "not found") {
-				_ = s.repo.LogMiss(ctx, id, 404, "i18n:"+lang)
-				// Invalidate this language cache so we don't serve a stale cached translation.
-				if s.cache != nil {
-					s.invalidateHotelLang(ctx, id, lang)
+	// 3) Translations: every requested language; log misses per-language; continue on 404/403.
+	if opts.Has(domain.PartTranslations) {
+		for _, lang := range langs {
+			step := domain.StepI18nPrefix + lang
+			tr, terr := s.cupid.GetTranslation(ctx, id, lang)
+			if terr != nil {
+				status, miss := upstreamMiss(terr)
+				if !miss {
+					// Unknown/unexpected error: surface it.
+					return failStep(rep, step, terr)
 				}
-				continue
-			}
-			if strings.Contains(low, "403") || strings.Contains(low, "forbidden") ||
-				strings.Contains(low, "401") || strings.Contains(low, "unauthorized") {
-				_ = s.repo.LogMiss(ctx, id, 403, "i18n:"+lang)
+				_ = s.repo.LogMiss(ctx, id, status, step)
+				// Views of every language may hold this translation via fallback.
 				if s.cache != nil {
-					s.invalidateHotelLang(ctx, id, lang)
+					s.invalidateHotelAllLangs(ctx, id)
 				}
+				setStep(&rep, domain.IngestStep{Step: step, Outcome: domain.StepMissed, Status: status, Reason: step})
 				continue
 			}
 
-			// Unknown/unexpected error: surface it.
-			return terr
+			// Upsert this language; other languages may fall back to it, so evict them all.
+			if err := s.repo.UpsertI18n(ctx, mapI18n(id, lang, tr)); err != nil {
+				return failStep(rep, step, err)
+			}
+			if s.cache != nil {
+				s.invalidateHotelAllLangs(ctx, id)
+				s.bumpGeneration(ctx, catalogGenKey)
+			}
+			setStep(&rep, domain.IngestStep{Step: step, Outcome: domain.StepOK, Stored: 1})
 		}
+	}
 
-		// Upsert this language and evict only that language's hotel cache.
-		if err := s.repo.UpsertI18n(ctx, mapI18n(id, lang, tr)); err != nil {
-			return err
+	rep.Outcome = domain.IngestOK
+	for _, st := range rep.Steps {
+		if st.Outcome == domain.StepMissed {
+			rep.Outcome = domain.IngestPartial
 		}
-		if s.cache != nil {
-			s.invalidateHotelLang(ctx, id, lang)
+	}
+	return rep, nil
+}
+
+// upstreamMiss classifies a Cupid error: a definite 404 (not found) or 403
+// (inactive) is a miss ingestion records and moves past. Anything else is
+// unexpected, 401 included: a rejected API key says nothing about the hotel.
+func upstreamMiss(err error) (status int, miss bool) {
+	switch {
+	case errors.Is(err, domain.ErrUpstreamNotFound):
+		return 404, true
+	case errors.Is(err, domain.ErrUpstreamForbidden):
+		return 403, true
+	}
+	return 0, false
+}
+
+// newIngestReport lists every step opts asks for as skipped until it runs.
+func newIngestReport(id int64, opts domain.IngestOptions, langs []string) domain.IngestReport {
+	rep := domain.IngestReport{PropertyID: id, Steps: []domain.IngestStep{}}
+	if opts.Has(domain.PartProperty) {
+		rep.Steps = append(rep.Steps, domain.IngestStep{Step: domain.StepProperty, Outcome: domain.StepSkipped})
+	}
+	if opts.Has(domain.PartReviews) {
+		rep.Steps = append(rep.Steps, domain.IngestStep{Step: domain.StepReviews, Outcome: domain.StepSkipped})
+	}
+	if opts.Has(domain.PartTranslations) {
+		for _, l := range langs {
+			rep.Steps = append(rep.Steps, domain.IngestStep{Step: domain.StepI18nPrefix + l, Outcome: domain.StepSkipped})
 		}
 	}
+	return rep
+}
 
-	return nil
+// setStep records the outcome of the step named st.Step.
+func setStep(rep *domain.IngestReport, st domain.IngestStep) {
+	for i := range rep.Steps {
+		if rep.Steps[i].Step == st.Step {
+			rep.Steps[i] = st
+			return
+		}
+	}
+}
+
+// failStep marks the step failed with err and returns both.
+func failStep(rep domain.IngestReport, step string, err error) (domain.IngestReport, error) {
+	setStep(&rep, domain.IngestStep{Step: step, Outcome: domain.StepFailed, Reason: err.Error()})
+	rep.Outcome = domain.IngestFailed
+	return rep, err
 }
 
 // invalidate hotel caches
 func (s *IngestionService) invalidateHotelAllLangs(ctx context.Context, id int64) {
-	for _, l := range []string{"en", "fr", "es"} {
+	for _, l := range s.langs.Supported {
 		s.invalidateHotelLang(ctx, id, l)
 	}
 }
 
 func (s *IngestionService) invalidateHotelLang(ctx context.Context, id int64, lang string) {
-	_ = s.cache.Del(ctx, fmt.Sprintf("hotel:%d:%s", id, strings.ToLower(lang)))
+	_ = s.cache.Del(ctx, hotelKey(id, lang))
+	_ = s.cache.Del(ctx, hotelVersionKey(id, lang))
 }
 
-// invalidate the most common review cache variants
+// bumpGeneration stores a fresh stamp under key; readers embed it in their
+// cache keys, so every entry keyed on the old stamp is orphaned (and expires by TTL).
+func (s *IngestionService) bumpGeneration(ctx context.Context, key string) {
+	_ = s.cache.Set(ctx, key, time.Now().UnixNano(), 0)
+}
+
+// invalidateReviews orphans every cached review page of the property
+// (any limit/sort/filter/cursor combination).
 func (s *IngestionService) invalidateReviews(ctx context.Context, id int64) {
-	// Your API default is limit=50, sort=-created_at. Invalidate that first.
-	_ = s.cache.Del(ctx, fmt.Sprintf("reviews:%d:%d:%s", id, 50, "-created_at"))
-	// Optionally clear a couple more common limits to be safe:
-	for _, lim := range []int{100, 200} {
-		_ = s.cache.Del(ctx, fmt.Sprintf("reviews:%d:%d:%s", id, lim, "-created_at"))
-	}
+	s.bumpGeneration(ctx, reviewsGenKey(id))
 }
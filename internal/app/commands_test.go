@@ -0,0 +1,150 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"cupid_hotel/internal/app"
+	"cupid_hotel/internal/domain"
+)
+
+// fakeCupid answers every call with an empty payload (or the property set
+// for the id), or with the error set for the step ("property", "reviews",
+// "i18n:<lang>").
+type fakeCupid struct {
+	errs  map[string]error
+	props map[int64]map[string]any
+}
+
+func (f *fakeCupid) GetProperty(_ context.Context, id int64) (map[string]any, error) {
+	if p, ok := f.props[id]; ok {
+		return p, f.errs[domain.StepProperty]
+	}
+	return map[string]any{}, f.errs[domain.StepProperty]
+}
+func (f *fakeCupid) GetTranslation(_ context.Context, _ int64, lang string) (map[string]any, error) {
+	return map[string]any{}, f.errs[domain.StepI18nPrefix+lang]
+}
+func (f *fakeCupid) GetReviews(context.Context, int64, int) ([]map[string]any, error) {
+	return nil, f.errs[domain.StepReviews]
+}
+
+func TestRefreshHotel_ReportsEachStep(t *testing.T) {
+	outcomes := func(r domain.IngestReport) []string {
+		var out []string
+		for _, s := range r.Steps {
+			out = append(out, s.Step+" "+s.Outcome)
+		}
+		return out
+	}
+	cases := []struct {
+		name    string
+		errs    map[string]error
+		opts    domain.IngestOptions
+		outcome string
+		steps   []string
+		err     bool
+	}{
+		{
+			name:    "all stored",
+			outcome: domain.IngestOK,
+			steps:   []string{"property ok", "reviews ok", "i18n:en ok", "i18n:fr ok", "i18n:es ok"},
+		},
+		{
+			name:    "missed property is deactivated",
+			errs:    map[string]error{domain.StepProperty: domain.ErrUpstreamNotFound},
+			outcome: domain.IngestDeactivated,
+			steps:   []string{"property missed", "reviews skipped", "i18n:en skipped", "i18n:fr skipped", "i18n:es skipped"},
+		},
+		{
+			name:    "failure stops the hotel",
+			errs:    map[string]error{domain.StepI18nPrefix + "fr": errors.New("cupid: 502 Bad Gateway")},
+			outcome: domain.IngestFailed,
+			steps:   []string{"property ok", "reviews ok", "i18n:en ok", "i18n:fr failed", "i18n:es skipped"},
+			err:     true,
+		},
+		{
+			name:    "only the parts and languages asked for",
+			opts:    domain.IngestOptions{Langs: []string{"fr"}, Parts: []string{domain.PartReviews, domain.PartTranslations}},
+			outcome: domain.IngestOK,
+			steps:   []string{"reviews ok", "i18n:fr ok"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ing := app.NewIngestionService(&fakeCupid{errs: tc.errs}, &fakeRepo{}, &fakeCache{})
+			rep, err := ing.RefreshHotel(context.Background(), 7, tc.opts)
+			if (err != nil) != tc.err || rep.PropertyID != 7 || rep.Outcome != tc.outcome {
+				t.Fatalf("got %+v, err %v", rep, err)
+			}
+			if got := outcomes(rep); !slices.Equal(got, tc.steps) {
+				t.Fatalf("steps %v, want %v", got, tc.steps)
+			}
+		})
+	}
+}
+
+func TestRefreshHotel_MapsFacilitiesByIDThenName(t *testing.T) {
+	facilities := func(fs ...any) map[string]any { return map[string]any{"facilities": fs} }
+	cupid := &fakeCupid{props: map[int64]map[string]any{
+		// listed ids map by id whatever the name says, or without one
+		1: facilities(
+			map[string]any{"facility_id": 107},
+			map[string]any{"facility_id": 301, "name": "Piscina climatizada"},
+			map[string]any{"facility_id": 54, "name": "Parking"},
+		),
+		// unlisted ids fall back to the name
+		2: facilities(
+			map[string]any{"facility_id": 9999, "name": "Free WiFi"},
+			map[string]any{"facility_id": 9998, "name": "Bien-être"},
+		),
+		// "no" only negates as the leading word
+		3: facilities("Free WiFi, no charge", "No parking", "Pets not allowed", "No smoking"),
+		// loose words name no amenity
+		4: facilities("Internet services", "Meeting rooms", "Children's playground"),
+	}}
+	want := map[int64][]string{1: {"pool", "spa", "wifi"}, 2: {"wifi"}, 3: {"non_smoking", "wifi"}, 4: nil}
+	// a fresh service per order: codes must not depend on what was ingested before
+	for _, order := range [][]int64{{1, 2, 3, 4}, {4, 3, 2, 1}} {
+		repo := &fakeRepo{}
+		ing := app.NewIngestionService(cupid, repo, &fakeCache{})
+		for _, id := range order {
+			if _, err := ing.RefreshHotel(context.Background(), id, domain.IngestOptions{Parts: []string{domain.PartProperty}}); err != nil {
+				t.Fatalf("refresh %d: %v", id, err)
+			}
+			got := repo.upserted[len(repo.upserted)-1].AmenityCodes
+			if len(got) == 0 && len(want[id]) == 0 {
+				continue
+			}
+			if !slices.Equal(got, want[id]) {
+				t.Fatalf("hotel %d codes %v, want %v", id, got, want[id])
+			}
+		}
+	}
+}
+
+// Only a definite 404/403 deactivates; a 401 is our key being rejected.
+func TestRefreshHotel_DeactivatesOnlyOnNotFoundOrForbidden(t *testing.T) {
+	cases := []struct {
+		err         error
+		outcome     string
+		deactivated bool
+	}{
+		{fmt.Errorf("cupid: %w", domain.ErrUpstreamNotFound), domain.IngestDeactivated, true},
+		{fmt.Errorf("cupid: %w", domain.ErrUpstreamForbidden), domain.IngestDeactivated, true},
+		{fmt.Errorf("cupid: %w", domain.ErrUpstreamUnauthorized), domain.IngestFailed, false},
+		{fmt.Errorf("db: %w", domain.ErrNotFound), domain.IngestFailed, false},
+		{errors.New("bad status 400: property not found in request"), domain.IngestFailed, false},
+	}
+	for _, tc := range cases {
+		repo := &fakeRepo{}
+		ing := app.NewIngestionService(&fakeCupid{errs: map[string]error{domain.StepProperty: tc.err}}, repo, &fakeCache{})
+		rep, _ := ing.RefreshHotel(context.Background(), 7, domain.IngestOptions{})
+		if rep.Outcome != tc.outcome || (len(repo.deactivated) > 0) != tc.deactivated {
+			t.Fatalf("%v: outcome %s, deactivated %v", tc.err, rep.Outcome, repo.deactivated)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+
+	"cupid_hotel/internal/domain"
+)
+
+// ExportHotels streams the catalogue for bulk export, bypassing the cache.
+// Canonical amenities are labelled in q.Lang; translated fields are the
+// stored q.Lang values, without the per-field fallback of GetHotel.
+func (s *QueryService) ExportHotels(ctx context.Context, q domain.ExportQuery, fn func(domain.HotelView) error) (*string, error) {
+	labels, err := s.amenityLabels(ctx, q.Lang)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ExportHotels(ctx, q, func(hv domain.HotelView) error {
+		for i, a := range hv.CanonicalAmenities {
+			if label, ok := labels[a.Code]; ok {
+				hv.CanonicalAmenities[i].Label = label
+			} else {
+				hv.CanonicalAmenities[i].Label = a.Code
+			}
+		}
+		return fn(hv)
+	})
+}
+
+// ExportReviews streams reviews for bulk export, bypassing the cache.
+func (s *QueryService) ExportReviews(ctx context.Context, q domain.ExportQuery, fn func(domain.Review) error) (*string, error) {
+	return s.repo.ExportReviews(ctx, q, fn)
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+
+	"cupid_hotel/internal/domain"
+)
+
+// translatedFields are the per-language columns of a hotel view, keyed by the
+// names reported in HotelView.FieldLanguages.
+var translatedFields = []struct {
+	name  string
+	field func(*domain.HotelView) **string
+}{
+	{"name", func(h *domain.HotelView) **string { return &h.Name }},
+	{"description", func(h *domain.HotelView) **string { return &h.Description }},
+	{"policies", func(h *domain.HotelView) **string { return &h.Policies }},
+}
+
+// applyFallback fills translated fields that are missing in lang from the next
+// languages of its chain (one batched repo read per extra language) and
+// records which language served each field.
+func (s *QueryService) applyFallback(ctx context.Context, views []domain.HotelView, lang string) error {
+	chain := s.langs.Chain(lang)
+	for i := range views {
+		views[i].FieldLanguages = map[string]string{}
+		fill(&views[i], views[i], chain[0])
+	}
+
+	for _, fb := range chain[1:] {
+		var ids []int64
+		at := map[int64]int{}
+		for i := range views {
+			if hasGaps(views[i]) {
+				ids = append(ids, views[i].ID)
+				at[views[i].ID] = i
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		alts, err := s.repo.GetHotels(ctx, ids, fb)
+		if err != nil {
+			return err
+		}
+		for _, alt := range alts {
+			if i, ok := at[alt.ID]; ok {
+				fill(&views[i], alt, fb)
+			}
+		}
+	}
+	return nil
+}
+
+// fill copies each field still missing in dst from src, tagging it with lang.
+func fill(dst *domain.HotelView, src domain.HotelView, lang string) {
+	for _, tf := range translatedFields {
+		if _, done := dst.FieldLanguages[tf.name]; done {
+			continue
+		}
+		if v := *tf.field(&src); v != nil {
+			*tf.field(dst) = v
+			dst.FieldLanguages[tf.name] = lang
+		}
+	}
+}
+
+func hasGaps(hv domain.HotelView) bool {
+	for _, tf := range translatedFields {
+		if *tf.field(&hv) == nil {
+			return true
+		}
+	}
+	return false
+}
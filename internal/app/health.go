@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cupid_hotel/internal/domain"
+)
+
+// Check probes one dependency; Probe returns an optional detail for the
+// report. Optional checks cover dependencies the service degrades without
+// (e.g. Redis: reads skip the cache) rather than fails.
+type Check struct {
+	Name     string
+	Optional bool
+	Probe    func(ctx context.Context) (string, error)
+}
+
+// HealthService answers readiness probes.
+type HealthService struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewHealthService runs every check with its own timeout, so one hung
+// dependency cannot stall the probe.
+func NewHealthService(timeout time.Duration, checks ...Check) *HealthService {
+	return &HealthService{checks: checks, timeout: timeout}
+}
+
+// Ready runs all checks concurrently.
+func (s *HealthService) Ready(ctx context.Context) domain.Readiness {
+	res := make([]domain.CheckResult, len(s.checks))
+	var wg sync.WaitGroup
+	for i, c := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+			start := time.Now()
+			detail, err := c.Probe(ctx)
+			res[i] = domain.CheckResult{Name: c.Name, OK: err == nil, Optional: c.Optional, Detail: detail, Duration: time.Since(start)}
+			if err != nil {
+				res[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	r := domain.Readiness{Ready: true, Checks: res}
+	for _, c := range res {
+		switch {
+		case c.OK:
+		case c.Optional:
+			r.Degraded = true
+		default:
+			r.Ready = false
+		}
+	}
+	return r
+}
@@ -0,0 +1,459 @@
+package app
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/semaphore"
+
+	"cupid_hotel/internal/domain"
+)
+
+// Job limits.
+const (
+	maxJobHotels   = 10000
+	maxReviewCount = 1000
+)
+
+// IngestJobConfig tunes on-demand ingestion.
+type IngestJobConfig struct {
+	ReviewCount int           // reviews fetched per hotel when a job does not say
+	Workers     int           // hotels ingested at once, across all jobs
+	SaveEvery   time.Duration // how often running jobs store progress and check for cancellation
+	StaleAfter  time.Duration // jobs without progress for this long are swept to interrupted
+}
+
+// IngestJobService runs ingestion on demand for operators: a hotel refreshed
+// inline, or batches in the background as jobs. Job state is stored, so any
+// API replica can answer a poll or take a cancellation; the work runs in the
+// replica that accepted the job.
+type IngestJobService struct {
+	ing  *IngestionService
+	repo domain.IngestJobRepository
+	cfg  IngestJobConfig
+	sem  *semaphore.Weighted
+
+	mu      sync.Mutex
+	running map[string]jobRun // jobs running in this replica
+	closed  bool              // shutting down: no new jobs
+	wg      sync.WaitGroup    // one per running job
+}
+
+// jobRun controls a job running in this replica.
+type jobRun struct {
+	stop  context.CancelCauseFunc // ends dispatching; hotels in flight finish
+	abort context.CancelFunc      // cancels the hotels in flight too
+}
+
+// errShutdown is the cause of stopping jobs that get interrupted.
+var errShutdown = errors.New("ingest jobs: shutting down")
+
+func NewIngestJobService(ing *IngestionService, r domain.IngestJobRepository, cfg IngestJobConfig) *IngestJobService {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.SaveEvery <= 0 {
+		cfg.SaveEvery = time.Second
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = max(time.Minute, 30*cfg.SaveEvery)
+	}
+	return &IngestJobService{
+		ing:     ing,
+		repo:    r,
+		cfg:     cfg,
+		sem:     semaphore.NewWeighted(int64(cfg.Workers)),
+		running: map[string]jobRun{},
+	}
+}
+
+// Refresh re-ingests one hotel and reports each step. Upstream and storage
+// failures are part of the report, not errors.
+func (s *IngestJobService) Refresh(ctx context.Context, id int64) (domain.IngestReport, error) {
+	if err := validPropertyID(id); err != nil {
+		return domain.IngestReport{}, err
+	}
+	rep, err := s.ing.RefreshHotel(ctx, id, domain.IngestOptions{ReviewCount: s.cfg.ReviewCount})
+	if err != nil {
+		log.Warn().Int64("id", id).Err(err).Msg("refresh failed")
+	}
+	return rep, nil
+}
+
+// StartRefresh queues a job refreshing one hotel and returns it at once.
+func (s *IngestJobService) StartRefresh(ctx context.Context, id int64) (domain.IngestJob, error) {
+	if err := validPropertyID(id); err != nil {
+		return domain.IngestJob{}, err
+	}
+	return s.Start(ctx, []int64{id}, domain.IngestOptions{})
+}
+
+// Start validates a batch, queues it as a job and returns the job at once.
+// Duplicate ids are ingested once; zero options take the defaults.
+func (s *IngestJobService) Start(ctx context.Context, ids []int64, opts domain.IngestOptions) (domain.IngestJob, error) {
+	j, dispatch, work, err := s.prepare(ctx, ids, opts)
+	if err != nil {
+		return domain.IngestJob{}, err
+	}
+	go s.run(dispatch, work, j)
+	return j, nil
+}
+
+// Run ingests a batch as a job in the calling goroutine and returns the job
+// finished. Cancelling ctx interrupts it as Shutdown does: hotels in flight
+// finish, the rest are left out and the job ends interrupted, so a later
+// run can take up its Remaining hotels.
+func (s *IngestJobService) Run(ctx context.Context, ids []int64, opts domain.IngestOptions) (domain.IngestJob, error) {
+	j, dispatch, work, err := s.prepare(ctx, ids, opts)
+	if err != nil {
+		return domain.IngestJob{}, err
+	}
+	defer context.AfterFunc(ctx, func() { s.stop(j.ID, errShutdown) })()
+	return s.run(dispatch, work, j), nil
+}
+
+// prepare validates and stores a job and registers it to run here. Hotels
+// are dispatched under the first context returned and ingested under the
+// second; neither ends with ctx, as the job outlives the request that
+// started it.
+func (s *IngestJobService) prepare(ctx context.Context, ids []int64, opts domain.IngestOptions) (domain.IngestJob, context.Context, context.Context, error) {
+	ids, opts, err := s.validJob(ids, opts)
+	if err != nil {
+		return domain.IngestJob{}, nil, nil, err
+	}
+	jobID, err := newJobID()
+	if err != nil {
+		return domain.IngestJob{}, nil, nil, err
+	}
+	j := domain.IngestJob{
+		ID:          jobID,
+		Status:      domain.JobQueued,
+		PropertyIDs: ids,
+		Options:     opts,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return domain.IngestJob{}, nil, nil, fmt.Errorf("%w: %w", domain.ErrUnavailable, errShutdown)
+	}
+	work, abort := context.WithCancel(context.WithoutCancel(ctx))
+	dispatch, stop := context.WithCancelCause(work)
+	s.running[j.ID] = jobRun{stop: stop, abort: abort}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	if err := s.repo.CreateIngestJob(ctx, j); err != nil {
+		s.finish(j.ID)
+		return domain.IngestJob{}, nil, nil, err
+	}
+	return j, dispatch, work, nil
+}
+
+func (s *IngestJobService) Job(ctx context.Context, id string) (domain.IngestJob, error) {
+	return s.repo.GetIngestJob(ctx, id)
+}
+
+// Cancel asks the job to stop: hotels not yet started are left out, those in
+// flight finish. A job on another replica notices within SaveEvery; one
+// whose replica died is swept to interrupted first. Finished jobs are
+// returned unchanged.
+func (s *IngestJobService) Cancel(ctx context.Context, id string) (domain.IngestJob, error) {
+	s.sweep(ctx)
+	j, err := s.repo.GetIngestJob(ctx, id)
+	if err != nil || j.Finished() {
+		return j, err
+	}
+	if err := s.repo.CancelIngestJob(ctx, id); err != nil {
+		return domain.IngestJob{}, err
+	}
+	s.stop(id, nil)
+	return s.repo.GetIngestJob(ctx, id)
+}
+
+// Shutdown interrupts the jobs running in this replica and refuses new ones
+// with ErrUnavailable. Hotels in flight may finish until ctx is done, and
+// are cancelled then; either way Shutdown returns once every job stored its
+// final state. Interrupted jobs keep the reports of the hotels that finished.
+func (s *IngestJobService) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	for _, r := range s.running {
+		r.stop(errShutdown)
+	}
+	s.mu.Unlock()
+
+	idle := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	for _, r := range s.running {
+		r.abort()
+	}
+	s.mu.Unlock()
+	<-idle
+	return ctx.Err()
+}
+
+// SweepStale marks interrupted, every StaleAfter/2 until ctx ends, the jobs
+// whose replica stopped storing progress (it crashed or was killed). Their
+// reports are kept, so the ingestor can -resume them.
+func (s *IngestJobService) SweepStale(ctx context.Context) {
+	t := time.NewTicker(s.cfg.StaleAfter / 2)
+	defer t.Stop()
+	for {
+		s.sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *IngestJobService) sweep(ctx context.Context) {
+	n, err := s.repo.InterruptStaleIngestJobs(ctx, s.cfg.StaleAfter)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Msg("ingest jobs: sweeping stale jobs failed")
+		}
+		return
+	}
+	if n > 0 {
+		log.Warn().Int64("jobs", n).Dur("stale_after", s.cfg.StaleAfter).Msg("ingest jobs: stale jobs marked interrupted")
+	}
+}
+
+// stop ends dispatching of a job running in this replica.
+func (s *IngestJobService) stop(id string, cause error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.running[id]; ok {
+		r.stop(cause)
+	}
+}
+
+// finish unregisters a job that stopped running.
+func (s *IngestJobService) finish(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.running[id]; ok {
+		r.abort()
+		delete(s.running, id)
+		s.wg.Done()
+	}
+}
+
+// run ingests the job's hotels, at most Workers at a time across all jobs,
+// and returns the job finished. Cancelling ctx ends dispatching and lets
+// hotels in flight finish; cancelling work cancels those too, and leaves
+// them without a report.
+func (s *IngestJobService) run(ctx, work context.Context, j domain.IngestJob) domain.IngestJob {
+	defer s.finish(j.ID)
+	store := context.WithoutCancel(work)
+
+	var mu sync.Mutex // guards j from here on
+	saved := 0        // reports stored; owned by watch until it returns
+	started := time.Now().UTC()
+	j.Status, j.StartedAt = domain.JobRunning, &started
+	s.save(store, j, &saved)
+
+	done, watched := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(watched)
+		s.watch(ctx, j.ID, &mu, &j, &saved, done)
+	}()
+
+	var wg sync.WaitGroup
+	for _, id := range j.PropertyIDs {
+		if ctx.Err() != nil || s.sem.Acquire(ctx, 1) != nil {
+			break
+		}
+		if ctx.Err() != nil { // a slot freed up as the job was cancelled
+			s.sem.Release(1)
+			break
+		}
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			defer s.sem.Release(1)
+			rep, err := s.ing.RefreshHotel(work, id, j.Options)
+			if err != nil && work.Err() != nil {
+				log.Warn().Str("job", j.ID).Int64("id", id).Msg("ingest job: hotel cancelled in flight")
+				return
+			}
+			if err != nil {
+				log.Warn().Str("job", j.ID).Int64("id", id).Err(err).Msg("ingest job: hotel failed")
+			}
+			mu.Lock()
+			j.Reports = append(j.Reports, rep)
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	close(done)
+	<-watched // no progress write may land after the final one
+
+	mu.Lock()
+	defer mu.Unlock()
+	finished := time.Now().UTC()
+	j.FinishedAt = &finished
+	switch {
+	case len(j.Reports) < len(j.PropertyIDs) && errors.Is(context.Cause(ctx), errShutdown):
+		j.Status = domain.JobInterrupted
+	case len(j.Reports) < len(j.PropertyIDs):
+		j.Status = domain.JobCancelled
+	case slices.ContainsFunc(j.Reports, func(r domain.IngestReport) bool { return r.Outcome == domain.IngestFailed }):
+		j.Status = domain.JobFailed
+	default:
+		j.Status = domain.JobSucceeded
+	}
+	s.save(store, j, &saved)
+	log.Info().Str("job", j.ID).Str("status", j.Status).
+		Int("hotels", len(j.PropertyIDs)).Int("done", len(j.Reports)).Msg("ingest job finished")
+	return j
+}
+
+// watch stores the progress of a running job every SaveEvery, and stops its
+// dispatching once a cancellation was recorded (possibly by another replica).
+func (s *IngestJobService) watch(ctx context.Context, id string, mu *sync.Mutex, j *domain.IngestJob, saved *int, done <-chan struct{}) {
+	store := context.WithoutCancel(ctx)
+	t := time.NewTicker(s.cfg.SaveEvery)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+		}
+		mu.Lock()
+		snap := *j
+		snap.Reports = slices.Clone(j.Reports)
+		mu.Unlock()
+		s.save(store, snap, saved)
+
+		if ctx.Err() == nil {
+			if cancelled, err := s.repo.IngestJobCancelRequested(store, id); err != nil {
+				log.Warn().Str("job", id).Err(err).Msg("ingest job: cancellation check failed")
+			} else if cancelled {
+				s.stop(id, nil)
+			}
+		}
+	}
+}
+
+// save stores job progress: the reports added since the last save, then the
+// job itself (its heartbeat). A failed write only costs pollers an update;
+// unsaved reports go out with the next save.
+func (s *IngestJobService) save(ctx context.Context, j domain.IngestJob, saved *int) {
+	if fresh := j.Reports[*saved:]; len(fresh) > 0 {
+		if err := s.repo.AddIngestJobReports(ctx, j.ID, fresh); err != nil {
+			log.Error().Str("job", j.ID).Err(err).Msg("store ingest job reports failed")
+		} else {
+			*saved = len(j.Reports)
+		}
+	}
+	if err := s.repo.UpdateIngestJob(ctx, j); err != nil {
+		log.Error().Str("job", j.ID).Err(err).Msg("store ingest job failed")
+	}
+}
+
+// validJob checks a batch and fills in option defaults.
+func (s *IngestJobService) validJob(ids []int64, opts domain.IngestOptions) ([]int64, domain.IngestOptions, error) {
+	ve := &domain.ValidationError{}
+	switch {
+	case len(ids) == 0:
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: "property_ids", Reason: "property_ids must list at least one id"})
+	case len(ids) > maxJobHotels:
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: "property_ids", Reason: fmt.Sprintf("property_ids must list at most %d ids", maxJobHotels)})
+	}
+	var uniq []int64
+	seen := map[int64]bool{}
+	for _, id := range ids {
+		if id <= 0 {
+			ve.Fields = append(ve.Fields, domain.FieldError{Field: "property_ids", Reason: fmt.Sprintf("property_ids must be positive numbers, got %d", id)})
+			break
+		}
+		if !seen[id] {
+			seen[id] = true
+			uniq = append(uniq, id)
+		}
+	}
+
+	if opts.ReviewCount < 0 || opts.ReviewCount > maxReviewCount {
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: "review_count", Reason: fmt.Sprintf("review_count must be between 1 and %d", maxReviewCount)})
+	} else if opts.ReviewCount == 0 {
+		opts.ReviewCount = s.cfg.ReviewCount
+	}
+
+	supported := s.ing.Languages()
+	if opts.Langs != nil {
+		var langs []string
+		for _, l := range opts.Langs {
+			l = strings.ToLower(l)
+			if !supported.IsSupported(l) {
+				ve.Fields = append(ve.Fields, domain.FieldError{
+					Field: "languages", Reason: fmt.Sprintf("unsupported language %q; one of %s", l, strings.Join(supported.Supported, ", ")),
+				})
+			} else if !slices.Contains(langs, l) {
+				langs = append(langs, l)
+			}
+		}
+		opts.Langs = langs
+	}
+	if opts.Parts != nil {
+		var parts []string
+		for _, p := range domain.Parts {
+			if slices.Contains(opts.Parts, p) {
+				parts = append(parts, p)
+			}
+		}
+		for _, p := range opts.Parts {
+			if !slices.Contains(domain.Parts, p) {
+				ve.Fields = append(ve.Fields, domain.FieldError{
+					Field: "parts", Reason: fmt.Sprintf("unknown part %q; one of %s", p, strings.Join(domain.Parts, ", ")),
+				})
+			}
+		}
+		if len(opts.Parts) == 0 {
+			ve.Fields = append(ve.Fields, domain.FieldError{Field: "parts", Reason: "parts must list at least one part, or be omitted for all"})
+		}
+		opts.Parts = parts
+	}
+
+	if len(ve.Fields) > 0 {
+		return nil, domain.IngestOptions{}, ve
+	}
+	return uniq, opts, nil
+}
+
+func validPropertyID(id int64) error {
+	if id <= 0 {
+		return &domain.ValidationError{Fields: []domain.FieldError{{Field: "id", Reason: "id must be a positive number"}}}
+	}
+	return nil
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
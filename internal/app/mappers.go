@@ -26,6 +26,15 @@ var reviewAliases = map[string][]string{
 	"rating":       {"rating", "rate", "score", "rating.value", "scores.overall", "overall_score", "average_score"},
 }
 
+var imageAliases = map[string][]string{
+	"url":      {"url", "hd_url", "src", "link"},
+	"caption":  {"image_description", "caption", "description", "title", "name"},
+	"category": {"image_class1", "category", "class", "type", "tag"},
+	"primary":  {"main_photo", "is_main", "primary", "is_primary"},
+	"width":    {"width", "image_width", "dimensions.width"},
+	"height":   {"height", "image_height", "dimensions.height"},
+}
+
 var i18nAliases = map[string][]string{
 	"name":        {"name", "hotel_name", "translations.name"},
 	"description": {"description", "markdown_description", "translations.description", "description_long"},
@@ -214,8 +223,15 @@ func mapProperty(p map[string]any) domain.Hotel {
 	}
 
 	return domain.Hotel{
-		ID:      id,
-		BrandID: firstInt64Flexible(p, "chain_id", "brand_id"),
+		ID: id,
+		BrandID: func() *int64 {
+			// Cupid sends chain_id 0 for independent hotels.
+			if v := firstInt64Flexible(p, "chain_id", "brand_id"); v != nil && *v > 0 {
+				return v
+			}
+			return nil
+		}(),
+		BrandName: firstNonEmptyAlias(p, map[string][]string{"brand": {"chain", "chain_name", "brand", "brand_name"}}, "brand"),
 		Stars: func() *int {
 			// int from rating-ish fields
 			if f := getFloatFlexible(p, "stars", "rating.stars", "rating"); f != nil {
@@ -277,10 +293,91 @@ func mapProperty(p map[string]any) domain.Hotel {
 			}
 			return nil
 		}(),
-		Amenities: firstSliceStrings(p, "facilities", "amenities"),
-		Images:    firstSliceStrings(p, "photos", "images"),
-		RawJSON:   raw,
+		Amenities:    firstSliceStrings(p, "facilities", "amenities"),
+		Images:       firstSliceStrings(p, "photos", "images"),
+		RawJSON:      raw,
+		AmenityCodes: mapAmenityCodes(p),
+		Photos:       mapImages(p),
+	}
+}
+
+/********** images mapper **********/
+
+// mapImages keeps the full photo objects (firstSliceStrings only keeps URLs).
+// Provider order becomes SortOrder. Exactly one image ends up primary: the
+// first flagged one, else the first photo.
+func mapImages(p map[string]any) []domain.HotelImage {
+	var raw []any
+	for _, k := range []string{"photos", "images"} {
+		if arr, ok := lookupAny(p, k).([]any); ok && len(arr) > 0 {
+			raw = arr
+			break
+		}
+	}
+
+	out := make([]domain.HotelImage, 0, len(raw))
+	primary := -1
+	for _, it := range raw {
+		var im domain.HotelImage
+		flagged := false
+		switch t := it.(type) {
+		case string:
+			im.URL = t
+		case map[string]any:
+			im.URL = deref(firstNonEmptyAlias(t, imageAliases, "url"))
+			im.Caption = firstNonEmptyAlias(t, imageAliases, "caption")
+			if c := firstNonEmptyAlias(t, imageAliases, "category"); c != nil {
+				cat := strings.ToLower(strings.TrimSpace(*c))
+				im.Category = &cat
+			}
+			if w := firstInt64Flexible(t, imageAliases["width"]...); w != nil {
+				x := int(*w)
+				im.Width = &x
+			}
+			if h := firstInt64Flexible(t, imageAliases["height"]...); h != nil {
+				x := int(*h)
+				im.Height = &x
+			}
+			flagged = truthy(t, imageAliases["primary"]...)
+		}
+		if strings.TrimSpace(im.URL) == "" {
+			continue
+		}
+		if flagged && primary < 0 {
+			primary = len(out)
+		}
+		im.SortOrder = len(out)
+		out = append(out, im)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	if primary < 0 {
+		primary = 0
+	}
+	out[primary].IsPrimary = true
+	return out
+}
+
+// truthy reports whether any path holds true, 1 or "true".
+func truthy(m map[string]any, paths ...string) bool {
+	for _, k := range paths {
+		switch v := lookupAny(m, k).(type) {
+		case bool:
+			if v {
+				return true
+			}
+		case float64:
+			if v != 0 {
+				return true
+			}
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil && b {
+				return true
+			}
+		}
 	}
+	return false
 }
 
 /********** reviews mapper **********/
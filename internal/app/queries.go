@@ -2,8 +2,11 @@ package app
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"cupid_hotel/internal/domain"
@@ -13,14 +16,47 @@ type QueryService struct {
 	repo     domain.HotelRepository
 	cache    domain.Cache
 	cacheTTL time.Duration
+	langs    domain.Languages
 }
 
 func NewQueryService(r domain.HotelRepository, c domain.Cache, ttl time.Duration) *QueryService {
-	return &QueryService{repo: r, cache: c, cacheTTL: ttl}
+	return &QueryService{repo: r, cache: c, cacheTTL: ttl, langs: domain.DefaultLanguages()}
+}
+
+// SetLanguages replaces the language registry (supported set and fallback chains).
+func (s *QueryService) SetLanguages(l domain.Languages) { s.langs = l }
+
+func (s *QueryService) Languages() domain.Languages { return s.langs }
+
+// hotelKey is the cache key of one localized hotel view (shared with ingestion).
+func hotelKey(id int64, lang string) string {
+	return fmt.Sprintf("hotel:%d:%s", id, strings.ToLower(lang))
+}
+
+// hotelVersionKey caches the version of one localized view; it is evicted
+// together with hotelKey.
+func hotelVersionKey(id int64, lang string) string {
+	return fmt.Sprintf("hotel:%d:%s:version", id, strings.ToLower(lang))
+}
+
+// HotelVersion returns the stored version behind GetHotel(id, lang): the
+// property and every translation on lang's fallback chain.
+func (s *QueryService) HotelVersion(ctx context.Context, id int64, lang string) (domain.Version, error) {
+	key := hotelVersionKey(id, lang)
+	var v domain.Version
+	if ok, _ := s.cache.Get(ctx, key, &v); ok {
+		return v, nil
+	}
+	v, err := s.repo.HotelVersion(ctx, id, s.langs.Chain(lang))
+	if err != nil {
+		return domain.Version{}, err
+	}
+	_ = s.cache.Set(ctx, key, v, int(s.cacheTTL.Seconds()))
+	return v, nil
 }
 
 func (s *QueryService) GetHotel(ctx context.Context, id int64, lang string) (domain.HotelView, error) {
-	key := fmt.Sprintf("hotel:%d:%s", id, lang)
+	key := hotelKey(id, lang)
 	var hv domain.HotelView
 	if ok, _ := s.cache.Get(ctx, key, &hv); ok {
 		return hv, nil
@@ -29,12 +65,91 @@ func (s *QueryService) GetHotel(ctx context.Context, id int64, lang string) (dom
 	if err != nil {
 		return domain.HotelView{}, err
 	}
+	views := []domain.HotelView{h}
+	if err := s.applyFallback(ctx, views, lang); err != nil {
+		return domain.HotelView{}, err
+	}
+	if err := s.labelAmenities(ctx, views, lang); err != nil {
+		return domain.HotelView{}, err
+	}
+	h = views[0]
 	_ = s.cache.Set(ctx, key, h, int(s.cacheTTL.Seconds()))
 	return h, nil
 }
 
+// GetHotels resolves many hotels with one cache MGET and, for the misses, one
+// repo query. Results follow the request order (duplicates included); unknown
+// ids get a nil Hotel.
+func (s *QueryService) GetHotels(ctx context.Context, ids []int64, lang string) (domain.HotelBatch, error) {
+	// Unique ids, first-seen order.
+	uniq := make([]int64, 0, len(ids))
+	seen := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		if _, dup := seen[id]; !dup {
+			seen[id] = struct{}{}
+			uniq = append(uniq, id)
+		}
+	}
+
+	found := make(map[int64]domain.HotelView, len(uniq))
+	keys := make([]string, len(uniq))
+	dst := make([]any, len(uniq))
+	views := make([]domain.HotelView, len(uniq))
+	for i, id := range uniq {
+		keys[i] = hotelKey(id, lang)
+		dst[i] = &views[i]
+	}
+	hits, _ := s.cache.MGet(ctx, keys, dst)
+
+	var misses []int64
+	for i, id := range uniq {
+		if i < len(hits) && hits[i] {
+			found[id] = views[i]
+		} else {
+			misses = append(misses, id)
+		}
+	}
+
+	if len(misses) > 0 {
+		loaded, err := s.repo.GetHotels(ctx, misses, lang)
+		if err != nil {
+			return domain.HotelBatch{}, err
+		}
+		if err := s.applyFallback(ctx, loaded, lang); err != nil {
+			return domain.HotelBatch{}, err
+		}
+		if err := s.labelAmenities(ctx, loaded, lang); err != nil {
+			return domain.HotelBatch{}, err
+		}
+		fill := make(map[string]any, len(loaded))
+		for _, hv := range loaded {
+			found[hv.ID] = hv
+			fill[hotelKey(hv.ID, lang)] = hv
+		}
+		_ = s.cache.MSet(ctx, fill, int(s.cacheTTL.Seconds()))
+	}
+
+	out := domain.HotelBatch{Items: make([]domain.HotelLookup, len(ids))}
+	for i, id := range ids {
+		out.Items[i].ID = id
+		if hv, ok := found[id]; ok {
+			hv := hv
+			out.Items[i].Found = true
+			out.Items[i].Hotel = &hv
+		}
+	}
+	return out, nil
+}
+
 func (s *QueryService) ListReviews(ctx context.Context, id int64, pg domain.PageQuery) (domain.ReviewsPage, error) {
-	key := fmt.Sprintf("reviews:%d:%d:%s", id, pg.Limit, pg.Sort)
+	var minRating string
+	if pg.MinRating != nil {
+		minRating = fmt.Sprint(*pg.MinRating)
+	}
+	key := fmt.Sprintf("reviews:%d:%d:%s", id, s.generation(ctx, reviewsGenKey(id)), hashKey(
+		fmt.Sprint(pg.Limit), pg.Sort, derefStr(pg.Cursor),
+		derefStr(pg.Lang), derefStr(pg.Source), minRating,
+	))
 	var out domain.ReviewsPage
 	if ok, _ := s.cache.Get(ctx, key, &out); ok {
 		return out, nil
@@ -55,6 +170,264 @@ func (s *QueryService) ListReviews(ctx context.Context, id int64, pg domain.Page
 	return copyRS, nil
 }
 
+// ListHotels serves a filtered page of hotels. List keys embed the catalogue
+// generation, so any property/i18n upsert makes older pages unreachable.
+func (s *QueryService) ListHotels(ctx context.Context, q domain.HotelsQuery) (domain.HotelsPage, error) {
+	q = canonicalAmenityFilter(q)
+	key := fmt.Sprintf("hotels:%d:%s", s.generation(ctx, catalogGenKey), hashKey(
+		q.Lang, derefStr(q.Q), derefStr(q.Country), derefStr(q.City),
+		derefInt(q.Stars), derefInt64(q.BrandID), strings.Join(q.Amenities, ","), q.AmenityMatch,
+		fmt.Sprint(q.Limit), derefStr(q.Cursor),
+	))
+	var out domain.HotelsPage
+	if ok, _ := s.cache.Get(ctx, key, &out); ok {
+		return out, nil
+	}
+	hp, err := s.repo.ListHotels(ctx, q)
+	if err != nil {
+		return domain.HotelsPage{}, err
+	}
+	_ = s.cache.Set(ctx, key, hp, int(s.cacheTTL.Seconds()))
+	return hp, nil
+}
+
+// HotelFacets is cached like listings: keyed on the catalog generation, so any
+// property upsert orphans every cached facet set.
+func (s *QueryService) HotelFacets(ctx context.Context, q domain.HotelsQuery) (domain.HotelFacets, error) {
+	q = canonicalAmenityFilter(q)
+	key := fmt.Sprintf("facets:%d:%s", s.generation(ctx, catalogGenKey), hashKey(
+		q.Lang, derefStr(q.Q), derefStr(q.Country), derefStr(q.City),
+		derefInt(q.Stars), derefInt64(q.BrandID), strings.Join(q.Amenities, ","), q.AmenityMatch, fmt.Sprint(q.Limit),
+	))
+	var out domain.HotelFacets
+	if ok, _ := s.cache.Get(ctx, key, &out); ok {
+		return out, nil
+	}
+	f, err := s.repo.HotelFacets(ctx, q)
+	if err != nil {
+		return domain.HotelFacets{}, err
+	}
+	labels, err := s.amenityLabels(ctx, q.Lang)
+	if err != nil {
+		return domain.HotelFacets{}, err
+	}
+	for i, fc := range f.Amenity {
+		f.Amenity[i].Label = labels[fc.Value]
+	}
+	_ = s.cache.Set(ctx, key, f, int(s.cacheTTL.Seconds()))
+	return f, nil
+}
+
+// ListImages returns the hotel's gallery, primary first. Keyed on the catalog
+// generation: galleries are rewritten with their property.
+func (s *QueryService) ListImages(ctx context.Context, id int64) ([]domain.HotelImage, error) {
+	key := fmt.Sprintf("images:%d:%d", s.generation(ctx, catalogGenKey), id)
+	var out []domain.HotelImage
+	if ok, _ := s.cache.Get(ctx, key, &out); ok {
+		return out, nil
+	}
+	imgs, err := s.repo.ListImages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.cache.Set(ctx, key, imgs, int(s.cacheTTL.Seconds()))
+	return imgs, nil
+}
+
+// ListBrands returns every chain with its hotel count. Counts move with
+// property upserts, so the key embeds the catalog generation.
+func (s *QueryService) ListBrands(ctx context.Context) ([]domain.Brand, error) {
+	key := fmt.Sprintf("brands:%d", s.generation(ctx, catalogGenKey))
+	var out []domain.Brand
+	if ok, _ := s.cache.Get(ctx, key, &out); ok {
+		return out, nil
+	}
+	brands, err := s.repo.ListBrands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.cache.Set(ctx, key, brands, int(s.cacheTTL.Seconds()))
+	return brands, nil
+}
+
+// GetBrand returns one brand; ErrNotFound for unknown ids.
+func (s *QueryService) GetBrand(ctx context.Context, id int64) (domain.Brand, error) {
+	return s.repo.GetBrand(ctx, id)
+}
+
+// ListAmenities returns the canonical amenity vocabulary labelled in lang.
+func (s *QueryService) ListAmenities(ctx context.Context, lang string) ([]domain.Amenity, error) {
+	defs, err := s.taxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := s.amenityLabels(ctx, lang)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.Amenity, len(defs))
+	for i, d := range defs {
+		out[i] = domain.Amenity{Code: d.Code, Label: labels[d.Code]}
+	}
+	return out, nil
+}
+
+// canonicalAmenityFilter maps amenity filter values to codes (sorted, unique)
+// so equivalent filters share cache entries.
+func canonicalAmenityFilter(q domain.HotelsQuery) domain.HotelsQuery {
+	if len(q.Amenities) == 0 {
+		return q
+	}
+	set := map[string]struct{}{}
+	for _, a := range q.Amenities {
+		if c := canonicalAmenity(a); c != "" {
+			set[c] = struct{}{}
+		}
+	}
+	q.Amenities = sortedKeys(set)
+	if q.AmenityMatch == "" {
+		q.AmenityMatch = domain.AmenityMatchAll
+	}
+	return q
+}
+
+// SearchHotels runs a full-text query and decorates hits with highlighted
+// snippets. Descriptions are dropped from hits; the snippet replaces them.
+func (s *QueryService) SearchHotels(ctx context.Context, q domain.SearchQuery) (domain.SearchPage, error) {
+	key := fmt.Sprintf("search:%d:%s", s.generation(ctx, catalogGenKey), hashKey(
+		q.Lang, q.Q, q.Mode, derefStr(q.Country), derefStr(q.City),
+		derefInt(q.Stars), fmt.Sprint(q.Limit), derefStr(q.Cursor),
+	))
+	var out domain.SearchPage
+	if ok, _ := s.cache.Get(ctx, key, &out); ok {
+		return out, nil
+	}
+	sp, err := s.repo.SearchHotels(ctx, q)
+	if err != nil {
+		return domain.SearchPage{}, err
+	}
+
+	re := termsRegexp(searchTerms(q.Q))
+	for i := range sp.Items {
+		hv := &sp.Items[i].Hotel
+		hl := map[string]string{}
+		if snip := highlight(derefStr(hv.Name), re); snip != "" {
+			hl["name"] = snip
+		}
+		if snip := highlight(derefStr(hv.Description), re); snip != "" {
+			hl["description"] = snip
+		}
+		sp.Items[i].Highlights = hl
+		hv.Description = nil
+	}
+
+	_ = s.cache.Set(ctx, key, sp, int(s.cacheTTL.Seconds()))
+	return sp, nil
+}
+
+// NearbyHotels serves a geo query (radius and/or bbox) ordered by distance.
+func (s *QueryService) NearbyHotels(ctx context.Context, q domain.NearbyQuery) (domain.NearbyPage, error) {
+	parts := []string{q.Lang, fmt.Sprint(q.Lat), fmt.Sprint(q.Lon), fmt.Sprint(q.Limit)}
+	if q.RadiusKm != nil {
+		parts = append(parts, fmt.Sprint("r", *q.RadiusKm))
+	}
+	if q.BBox != nil {
+		parts = append(parts, fmt.Sprint("b", *q.BBox))
+	}
+	key := fmt.Sprintf("nearby:%d:%s", s.generation(ctx, catalogGenKey), hashKey(parts...))
+	var out domain.NearbyPage
+	if ok, _ := s.cache.Get(ctx, key, &out); ok {
+		return out, nil
+	}
+	np, err := s.repo.NearbyHotels(ctx, q)
+	if err != nil {
+		return domain.NearbyPage{}, err
+	}
+	_ = s.cache.Set(ctx, key, np, int(s.cacheTTL.Seconds()))
+	return np, nil
+}
+
+// ListChanges reads the change feed. Never cached: mirrors poll it to learn
+// what changed since their last token.
+func (s *QueryService) ListChanges(ctx context.Context, since int64, limit int) (domain.ChangesPage, error) {
+	return s.repo.ListChanges(ctx, since, limit)
+}
+
+// generation returns the current value of a generation counter (0 if unset).
+func (s *QueryService) generation(ctx context.Context, key string) int64 {
+	var g int64
+	if ok, _ := s.cache.Get(ctx, key, &g); ok {
+		return g
+	}
+	return 0
+}
+
+// hashKey folds user-supplied parameters into a fixed-size cache key segment.
+func hashKey(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func derefStr(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+func derefInt(p *int) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprint(*p)
+}
+
+func derefInt64(p *int64) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprint(*p)
+}
+
+// ReviewsVersion returns the version of a hotel's reviews; it shares the
+// per-property reviews generation with the pages it describes.
+func (s *QueryService) ReviewsVersion(ctx context.Context, id int64) (domain.Version, error) {
+	key := fmt.Sprintf("reviews:%d:%d:version", id, s.generation(ctx, reviewsGenKey(id)))
+	var v domain.Version
+	if ok, _ := s.cache.Get(ctx, key, &v); ok {
+		return v, nil
+	}
+	v, err := s.repo.ReviewsVersion(ctx, id)
+	if err != nil {
+		return domain.Version{}, err
+	}
+	_ = s.cache.Set(ctx, key, v, int(s.cacheTTL.Seconds()))
+	return v, nil
+}
+
+// ReviewSummary combines SQL aggregates with pros/cons mined from aspects.
+// It shares the per-property reviews generation, so review upserts evict it.
+func (s *QueryService) ReviewSummary(ctx context.Context, id int64) (domain.ReviewSummary, error) {
+	key := fmt.Sprintf("reviews:%d:%d:summary", id, s.generation(ctx, reviewsGenKey(id)))
+	var out domain.ReviewSummary
+	if ok, _ := s.cache.Get(ctx, key, &out); ok {
+		return out, nil
+	}
+
+	sum, err := s.repo.ReviewSummary(ctx, id)
+	if err != nil {
+		return domain.ReviewSummary{}, err
+	}
+	aspects, err := s.repo.ReviewAspects(ctx, id)
+	if err != nil {
+		return domain.ReviewSummary{}, err
+	}
+	sum.TopPros, sum.TopCons = mineAspects(aspects, topPhrases)
+
+	_ = s.cache.Set(ctx, key, sum, int(s.cacheTTL.Seconds()))
+	return sum, nil
+}
+
 func deepCopyReviewsPage(in domain.ReviewsPage) domain.ReviewsPage {
 	out := domain.ReviewsPage{NextCursor: in.NextCursor}
 	if n := len(in.Items); n > 0 {
@@ -2,6 +2,7 @@ package app_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,22 +13,150 @@ import (
 // ---- fakes ----
 
 type fakeRepo struct {
-	hv domain.HotelView
-	rp domain.ReviewsPage
+	hv  domain.HotelView
+	rp  domain.ReviewsPage
+	hp  domain.HotelsPage
+	sp  domain.SearchPage
+	np  domain.NearbyPage
+	hf  domain.HotelFacets
+	tx  []domain.AmenityDef
+	im  []domain.HotelImage
+	br  []domain.Brand
+	sc  []domain.SimilarCandidate
+	rs  domain.ReviewSummary
+	ra  [][]byte
+	ver domain.Version
+
+	byID    map[int64]domain.HotelView
+	i18n    map[string]domain.HotelView // per-language view of one hotel, when set
+	lastIDs []int64
+
+	lastHQ    domain.HotelsQuery
+	lastNQ    domain.NearbyQuery
+	lastPQ    domain.PageQuery
+	listCalls int
+	revCalls  int
+	verCalls  int
+	verLangs  []string
+	upserted  []domain.Hotel
+
+	deactivated []int64
 }
 
-func (f *fakeRepo) UpsertProperty(ctx context.Context, h domain.Hotel) error    { return nil }
+func (f *fakeRepo) UpsertProperty(ctx context.Context, h domain.Hotel) error {
+	f.upserted = append(f.upserted, h)
+	return nil
+}
 func (f *fakeRepo) UpsertI18n(ctx context.Context, i domain.HotelI18n) error    { return nil }
 func (f *fakeRepo) UpsertReviews(ctx context.Context, rs []domain.Review) error { return nil }
+func (f *fakeRepo) DeactivateProperty(ctx context.Context, id int64) error {
+	f.deactivated = append(f.deactivated, id)
+	return nil
+}
 func (f *fakeRepo) GetHotel(ctx context.Context, id int64, lang string) (domain.HotelView, error) {
+	if hv, ok := f.i18n[lang]; ok {
+		return hv, nil
+	}
 	return f.hv, nil
 }
+func (f *fakeRepo) GetHotels(ctx context.Context, ids []int64, lang string) ([]domain.HotelView, error) {
+	f.lastIDs = ids
+	var out []domain.HotelView
+	if f.i18n != nil {
+		if hv, ok := f.i18n[lang]; ok && len(ids) > 0 && ids[0] == hv.ID {
+			out = append(out, hv)
+		}
+		return out, nil
+	}
+	for _, id := range ids {
+		if hv, ok := f.byID[id]; ok {
+			out = append(out, hv)
+		}
+	}
+	return out, nil
+}
 func (f *fakeRepo) ListHotels(ctx context.Context, q domain.HotelsQuery) (domain.HotelsPage, error) {
-	return domain.HotelsPage{}, nil
+	f.lastHQ = q
+	f.listCalls++
+	return f.hp, nil
+}
+func (f *fakeRepo) HotelFacets(ctx context.Context, q domain.HotelsQuery) (domain.HotelFacets, error) {
+	f.lastHQ = q
+	f.listCalls++
+	return f.hf, nil
+}
+func (f *fakeRepo) AmenityTaxonomy(ctx context.Context) ([]domain.AmenityDef, error) {
+	return f.tx, nil
+}
+func (f *fakeRepo) ListImages(ctx context.Context, id int64) ([]domain.HotelImage, error) {
+	if f.im == nil {
+		return nil, domain.ErrNotFound
+	}
+	return f.im, nil
+}
+func (f *fakeRepo) SimilarCandidates(ctx context.Context, q domain.SimilarQuery, afterID int64, max int) ([]domain.SimilarCandidate, error) {
+	out := []domain.SimilarCandidate{}
+	for _, c := range f.sc {
+		if c.Hotel.ID > afterID && len(out) < max {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+func (f *fakeRepo) ListBrands(ctx context.Context) ([]domain.Brand, error) { return f.br, nil }
+func (f *fakeRepo) GetBrand(ctx context.Context, id int64) (domain.Brand, error) {
+	for _, b := range f.br {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return domain.Brand{}, domain.ErrNotFound
+}
+func (f *fakeRepo) SearchHotels(ctx context.Context, q domain.SearchQuery) (domain.SearchPage, error) {
+	return f.sp, nil
+}
+func (f *fakeRepo) NearbyHotels(ctx context.Context, q domain.NearbyQuery) (domain.NearbyPage, error) {
+	f.lastNQ = q
+	return f.np, nil
 }
 func (f *fakeRepo) ListReviews(ctx context.Context, id int64, pg domain.PageQuery) (domain.ReviewsPage, error) {
+	f.lastPQ = pg
+	f.revCalls++
 	return f.rp, nil
 }
+func (f *fakeRepo) ReviewSummary(ctx context.Context, id int64) (domain.ReviewSummary, error) {
+	return f.rs, nil
+}
+func (f *fakeRepo) ReviewAspects(ctx context.Context, id int64) ([][]byte, error) {
+	return f.ra, nil
+}
+func (f *fakeRepo) ExportHotels(ctx context.Context, q domain.ExportQuery, fn func(domain.HotelView) error) (*string, error) {
+	for _, hv := range f.hp.Items {
+		if err := fn(hv); err != nil {
+			return nil, err
+		}
+	}
+	return f.hp.NextCursor, nil
+}
+func (f *fakeRepo) ExportReviews(ctx context.Context, q domain.ExportQuery, fn func(domain.Review) error) (*string, error) {
+	for _, rv := range f.rp.Items {
+		if err := fn(rv); err != nil {
+			return nil, err
+		}
+	}
+	return f.rp.NextCursor, nil
+}
+func (f *fakeRepo) ListChanges(ctx context.Context, since int64, limit int) (domain.ChangesPage, error) {
+	return domain.ChangesPage{Next: since}, nil
+}
+func (f *fakeRepo) HotelVersion(ctx context.Context, id int64, langs []string) (domain.Version, error) {
+	f.verCalls++
+	f.verLangs = langs
+	return f.ver, nil
+}
+func (f *fakeRepo) ReviewsVersion(ctx context.Context, id int64) (domain.Version, error) {
+	return f.ver, nil
+}
 func (f *fakeRepo) LogMiss(ctx context.Context, id int64, status int, reason string) error {
 	// no-op for tests
 	return nil
@@ -50,6 +179,28 @@ func (c *fakeCache) Get(ctx context.Context, key string, dst any) (bool, error)
 		*d = v.(domain.HotelView)
 	case *domain.ReviewsPage:
 		*d = v.(domain.ReviewsPage)
+	case *domain.HotelsPage:
+		*d = v.(domain.HotelsPage)
+	case *domain.SearchPage:
+		*d = v.(domain.SearchPage)
+	case *domain.NearbyPage:
+		*d = v.(domain.NearbyPage)
+	case *domain.HotelFacets:
+		*d = v.(domain.HotelFacets)
+	case *[]domain.AmenityDef:
+		*d = v.([]domain.AmenityDef)
+	case *[]domain.HotelImage:
+		*d = v.([]domain.HotelImage)
+	case *[]domain.Brand:
+		*d = v.([]domain.Brand)
+	case *domain.SimilarPage:
+		*d = v.(domain.SimilarPage)
+	case *domain.ReviewSummary:
+		*d = v.(domain.ReviewSummary)
+	case *domain.Version:
+		*d = v.(domain.Version)
+	case *int64:
+		*d = v.(int64)
 	}
 	return true, nil
 }
@@ -61,6 +212,19 @@ func (c *fakeCache) Set(ctx context.Context, key string, v any, ttlSec int) erro
 	return nil
 }
 func (c *fakeCache) Del(ctx context.Context, key string) error { return nil }
+func (c *fakeCache) MGet(ctx context.Context, keys []string, dst []any) ([]bool, error) {
+	hits := make([]bool, len(keys))
+	for i, k := range keys {
+		hits[i], _ = c.Get(ctx, k, dst[i])
+	}
+	return hits, nil
+}
+func (c *fakeCache) MSet(ctx context.Context, kv map[string]any, ttlSec int) error {
+	for k, v := range kv {
+		_ = c.Set(ctx, k, v, ttlSec)
+	}
+	return nil
+}
 
 // ---- tests ----
 
@@ -118,6 +282,139 @@ func TestListReviews_Cache(t *testing.T) {
 	}
 }
 
+func TestListReviews_CacheKeyCoversParams(t *testing.T) {
+	repo := &fakeRepo{rp: domain.ReviewsPage{Items: []domain.Review{{PropertyID: 1}}}}
+	cache := &fakeCache{}
+	q := app.NewQueryService(repo, cache, 10*time.Minute)
+	ctx := context.Background()
+
+	base := domain.PageQuery{Limit: 10, Sort: domain.SortNewest}
+	variants := []domain.PageQuery{
+		base,
+		{Limit: 10, Sort: domain.SortRatingDesc},
+		{Limit: 10, Sort: domain.SortNewest, Cursor: ptr("c1")},
+		{Limit: 10, Sort: domain.SortNewest, Lang: ptr("fr")},
+		{Limit: 10, Sort: domain.SortNewest, Source: ptr("booking")},
+		{Limit: 10, Sort: domain.SortNewest, MinRating: pfloat(8)},
+	}
+	for i, pg := range variants {
+		if _, err := q.ListReviews(ctx, 1, pg); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if repo.revCalls != i+1 {
+			t.Fatalf("variant %d shared a cache entry: %+v", i, pg)
+		}
+	}
+
+	// Same params again -> cache; after a reviews generation bump -> repo.
+	_, _ = q.ListReviews(ctx, 1, base)
+	if repo.revCalls != len(variants) {
+		t.Fatalf("expected cache hit, repo calls=%d", repo.revCalls)
+	}
+	_ = cache.Set(ctx, "reviews:1:gen", int64(99), 0)
+	_, _ = q.ListReviews(ctx, 1, base)
+	if repo.revCalls != len(variants)+1 {
+		t.Fatalf("expected repo call after generation bump, repo calls=%d", repo.revCalls)
+	}
+}
+
+func TestReviewSummary_MinesAspects(t *testing.T) {
+	repo := &fakeRepo{
+		rs: domain.ReviewSummary{PropertyID: 5, Count: 3},
+		ra: [][]byte{
+			[]byte(`{"pros":["Great location, friendly staff."],"cons":["Small rooms"]}`),
+			[]byte(`{"pros":["great location!","Great  Location"],"cons":["small rooms; noisy street"]}`),
+			[]byte(`{"pros":["Friendly staff"]}`),
+			[]byte(`not json`),
+		},
+	}
+	q := app.NewQueryService(repo, &fakeCache{}, 10*time.Minute)
+
+	sum, err := q.ReviewSummary(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	wantPros := []domain.PhraseCount{{Phrase: "friendly staff", Count: 2}, {Phrase: "great location", Count: 2}}
+	if len(sum.TopPros) != 2 || sum.TopPros[0] != wantPros[0] || sum.TopPros[1] != wantPros[1] {
+		t.Fatalf("unexpected pros: %+v", sum.TopPros)
+	}
+	if len(sum.TopCons) != 2 || sum.TopCons[0] != (domain.PhraseCount{Phrase: "small rooms", Count: 2}) {
+		t.Fatalf("unexpected cons: %+v", sum.TopCons)
+	}
+	if sum.Count != 3 {
+		t.Fatalf("expected SQL aggregates to pass through, got %+v", sum)
+	}
+}
+
+func TestListHotels_CacheKeyedOnGeneration(t *testing.T) {
+	repo := &fakeRepo{
+		hp: domain.HotelsPage{Items: []domain.HotelView{{ID: 7, Name: ptr("Alpha")}}},
+	}
+	cache := &fakeCache{}
+	q := app.NewQueryService(repo, cache, 10*time.Minute)
+	ctx := context.Background()
+	hq := domain.HotelsQuery{Lang: "en", Country: ptr("FR"), Limit: 20}
+
+	if _, err := q.ListHotels(ctx, hq); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := q.ListHotels(ctx, hq); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if repo.listCalls != 1 {
+		t.Fatalf("expected second call to be served from cache, repo calls=%d", repo.listCalls)
+	}
+
+	// A different filter set must not share the cached page.
+	if _, err := q.ListHotels(ctx, domain.HotelsQuery{Lang: "en", Country: ptr("ES"), Limit: 20}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if repo.listCalls != 2 {
+		t.Fatalf("expected a repo call for a new filter set, repo calls=%d", repo.listCalls)
+	}
+
+	// Bumping the catalogue generation (done by ingestion) orphans old pages.
+	_ = cache.Set(ctx, "catalog:gen", int64(2), 0)
+	if _, err := q.ListHotels(ctx, hq); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if repo.listCalls != 3 {
+		t.Fatalf("expected a repo call after generation bump, repo calls=%d", repo.listCalls)
+	}
+}
+
+func TestSearchHotels_Highlights(t *testing.T) {
+	repo := &fakeRepo{
+		sp: domain.SearchPage{Items: []domain.SearchHit{{
+			Hotel: domain.HotelView{
+				ID:          3,
+				Name:        ptr("Grand Hôtel du Lac"),
+				Description: ptr("Un hôtel <calme> au bord du lac, avec spa."),
+			},
+			Score: 1.5,
+		}}},
+	}
+	q := app.NewQueryService(repo, &fakeCache{}, 10*time.Minute)
+
+	out, err := q.SearchHotels(context.Background(), domain.SearchQuery{Lang: "fr", Q: "+lac spa", Mode: domain.SearchBoolean, Limit: 10})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Items) != 1 {
+		t.Fatalf("unexpected items: %+v", out.Items)
+	}
+	hit := out.Items[0]
+	if got := hit.Highlights["name"]; got != "Grand Hôtel du <em>Lac</em>" {
+		t.Fatalf("name highlight: %q", got)
+	}
+	if got := hit.Highlights["description"]; got != "Un hôtel &lt;calme&gt; au bord du <em>lac</em>, avec <em>spa</em>." {
+		t.Fatalf("description highlight: %q", got)
+	}
+	if hit.Hotel.Description != nil {
+		t.Fatalf("expected description to be replaced by the snippet")
+	}
+}
+
 func ptr[T any](v T) *T { return &v }
 func deref(p *string) string {
 	if p == nil {
@@ -126,3 +423,165 @@ func deref(p *string) string {
 	return *p
 }
 func pfloat(f float64) *float64 { return &f }
+
+func TestGetHotels_MultiGetThenRepoForMisses(t *testing.T) {
+	repo := &fakeRepo{byID: map[int64]domain.HotelView{
+		1: {ID: 1, Name: ptr("One")},
+		2: {ID: 2, Name: ptr("Two")},
+	}}
+	cache := &fakeCache{}
+	q := app.NewQueryService(repo, cache, 10*time.Minute)
+
+	// Warm hotel 1 only.
+	if _, err := q.GetHotels(context.Background(), []int64{1}, "en"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := q.GetHotels(context.Background(), []int64{2, 9, 1, 2}, "en")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(repo.lastIDs) != 2 || repo.lastIDs[0] != 2 || repo.lastIDs[1] != 9 {
+		t.Fatalf("repo should only see misses once each, got %v", repo.lastIDs)
+	}
+	if len(out.Items) != 4 {
+		t.Fatalf("expected one slot per requested id, got %d", len(out.Items))
+	}
+	want := []struct {
+		id    int64
+		found bool
+	}{{2, true}, {9, false}, {1, true}, {2, true}}
+	for i, w := range want {
+		it := out.Items[i]
+		if it.ID != w.id || it.Found != w.found || (it.Hotel != nil) != w.found {
+			t.Fatalf("slot %d: got %+v, want id=%d found=%v", i, it, w.id, w.found)
+		}
+	}
+
+	// Everything found is now cached: a repeat hits only the unknown id.
+	repo.lastIDs = nil
+	if _, err := q.GetHotels(context.Background(), []int64{1, 2, 9}, "en"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(repo.lastIDs) != 1 || repo.lastIDs[0] != 9 {
+		t.Fatalf("expected only id 9 to reach the repo, got %v", repo.lastIDs)
+	}
+}
+
+func TestGetHotel_PerFieldFallback(t *testing.T) {
+	repo := &fakeRepo{i18n: map[string]domain.HotelView{
+		"fr": {ID: 5, Language: "fr", Name: ptr("Hôtel Cinq")},
+		"en": {ID: 5, Language: "en", Name: ptr("Hotel Five"), Description: ptr("A fine hotel")},
+	}}
+	q := app.NewQueryService(repo, &fakeCache{}, 10*time.Minute)
+	q.SetLanguages(domain.Languages{Supported: []string{"en", "fr"}, Default: "en", Fallback: map[string][]string{"fr": {"en"}}})
+
+	h, err := q.GetHotel(context.Background(), 5, "fr")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if deref(h.Name) != "Hôtel Cinq" || deref(h.Description) != "A fine hotel" || h.Policies != nil {
+		t.Fatalf("unexpected merge: name=%q desc=%q", deref(h.Name), deref(h.Description))
+	}
+	want := map[string]string{"name": "fr", "description": "en"}
+	if len(h.FieldLanguages) != len(want) || h.FieldLanguages["name"] != "fr" || h.FieldLanguages["description"] != "en" {
+		t.Fatalf("FieldLanguages = %v, want %v", h.FieldLanguages, want)
+	}
+}
+
+func TestGetHotel_LabelsCanonicalAmenities(t *testing.T) {
+	repo := &fakeRepo{
+		hv: domain.HotelView{ID: 3, Name: ptr("N"), CanonicalAmenities: []domain.Amenity{{Code: "pool"}, {Code: "spa"}}},
+		tx: []domain.AmenityDef{
+			{Code: "pool", Labels: map[string]string{"en": "Swimming pool", "fr": "Piscine"}},
+			{Code: "spa", Labels: map[string]string{"en": "Spa"}},
+		},
+	}
+	q := app.NewQueryService(repo, &fakeCache{}, 10*time.Minute)
+
+	h, err := q.GetHotel(context.Background(), 3, "fr")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// fr label when present, else the chain falls back to en.
+	if got := h.CanonicalAmenities; len(got) != 2 || got[0].Label != "Piscine" || got[1].Label != "Spa" {
+		t.Fatalf("unexpected labels: %+v", got)
+	}
+}
+
+func TestSimilarHotels_BlendedRankingWithFallback(t *testing.T) {
+	amen := func(codes ...string) []domain.Amenity {
+		var out []domain.Amenity
+		for _, c := range codes {
+			out = append(out, domain.Amenity{Code: c})
+		}
+		return out
+	}
+	repo := &fakeRepo{
+		hv: domain.HotelView{ID: 3, Stars: ptr(4), CanonicalAmenities: amen("pool", "spa", "wifi")},
+		sc: []domain.SimilarCandidate{
+			{Hotel: domain.HotelView{ID: 11, Stars: ptr(2)}},
+			{Hotel: domain.HotelView{ID: 12, Stars: ptr(4), Name: ptr("Gamma"), CanonicalAmenities: amen("pool")},
+				Rating: ptr(8.0), DistanceKm: ptr(0.5)},
+			{Hotel: domain.HotelView{ID: 10, Stars: ptr(4), CanonicalAmenities: amen("pool", "spa", "wifi")},
+				Rating: ptr(9.0), DistanceKm: ptr(1.0)},
+		},
+		byID: map[int64]domain.HotelView{10: {ID: 10, Name: ptr("Alpha")}},
+	}
+	q := app.NewQueryService(repo, &fakeCache{}, 10*time.Minute)
+
+	out, err := q.SimilarHotels(context.Background(), domain.SimilarQuery{ID: 3, Lang: "fr", Limit: 2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Items) != 2 || out.Items[0].Hotel.ID != 10 || out.Items[1].Hotel.ID != 12 {
+		t.Fatalf("unexpected ranking: %+v", out.Items)
+	}
+	if s := out.Items[0].Score; s < 0.94 || s > 0.95 {
+		t.Fatalf("score = %v, want ~0.947", s)
+	}
+	// Missing fr name falls back to en, as for GetHotel.
+	top := out.Items[0].Hotel
+	if deref(top.Name) != "Alpha" || top.FieldLanguages["name"] != "en" {
+		t.Fatalf("expected en fallback name, got %v (%v)", deref(top.Name), top.FieldLanguages)
+	}
+}
+
+// The best match sits past the first page of candidates: every hotel of the
+// scope is scored, not only the first page in id order.
+func TestSimilarHotels_ScoresEveryCandidate(t *testing.T) {
+	repo := &fakeRepo{hv: domain.HotelView{ID: 1, Stars: ptr(5), CanonicalAmenities: []domain.Amenity{{Code: "spa"}}}}
+	for id := int64(2); id <= 1200; id++ {
+		repo.sc = append(repo.sc, domain.SimilarCandidate{Hotel: domain.HotelView{ID: id, Stars: ptr(1)}})
+	}
+	repo.sc[1100].Hotel.Stars = ptr(5)
+	repo.sc[1100].Hotel.CanonicalAmenities = []domain.Amenity{{Code: "spa"}}
+	q := app.NewQueryService(repo, &fakeCache{}, 10*time.Minute)
+
+	out, err := q.SimilarHotels(context.Background(), domain.SimilarQuery{ID: 1, Lang: "en", Limit: 3})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Items) != 3 || out.Items[0].Hotel.ID != 1102 || out.Items[1].Hotel.ID != 2 {
+		t.Fatalf("unexpected ranking: %+v", out.Items)
+	}
+}
+
+func TestHotelVersion_CoversFallbackChainAndIsCached(t *testing.T) {
+	repo := &fakeRepo{ver: domain.Version{Tag: "v1", Modified: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}}
+	q := app.NewQueryService(repo, &fakeCache{}, 10*time.Minute)
+	q.SetLanguages(domain.Languages{Supported: []string{"en", "fr"}, Default: "en", Fallback: map[string][]string{"fr": {"en"}}})
+
+	v, err := q.HotelVersion(context.Background(), 5, "fr")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v.Tag != "v1" || strings.Join(repo.verLangs, ",") != "fr,en" {
+		t.Fatalf("version = %+v, langs = %v", v, repo.verLangs)
+	}
+
+	repo.ver.Tag = "v2"
+	if v, _ := q.HotelVersion(context.Background(), 5, "fr"); v.Tag != "v1" || repo.verCalls != 1 {
+		t.Fatalf("expected cached version, got %+v after %d repo calls", v, repo.verCalls)
+	}
+}
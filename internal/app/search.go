@@ -0,0 +1,93 @@
+package app
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+/********** full-text highlighting **********/
+
+// snippetWidth is the approximate snippet length in bytes around the first hit.
+const snippetWidth = 160
+
+// searchTerms extracts plain words from a (possibly boolean-mode) query.
+func searchTerms(q string) []string {
+	clean := strings.Map(func(r rune) rune {
+		switch r {
+		case '+', '-', '<', '>', '(', ')', '~', '*', '"', '@':
+			return ' '
+		}
+		return r
+	}, q)
+	var out []string
+	for _, f := range strings.Fields(clean) {
+		if utf8.RuneCountInString(f) >= 2 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// termsRegexp builds a case-insensitive alternation of the terms (nil if none).
+func termsRegexp(terms []string) *regexp.Regexp {
+	if len(terms) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// highlight returns an HTML-escaped snippet of text around the first match of
+// re, with every match wrapped in <em>. Empty when nothing matches.
+func highlight(text string, re *regexp.Regexp) string {
+	if re == nil || text == "" {
+		return ""
+	}
+	first := re.FindStringIndex(text)
+	if first == nil {
+		return ""
+	}
+
+	// Window around the first hit, snapped to rune boundaries.
+	start, end := 0, len(text)
+	if len(text) > snippetWidth {
+		start = first[0] - snippetWidth/3
+		if start < 0 {
+			start = 0
+		}
+		end = start + snippetWidth
+		if end > len(text) {
+			end = len(text)
+		}
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+	}
+	window := text[start:end]
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	last := 0
+	for _, m := range re.FindAllStringIndex(window, -1) {
+		b.WriteString(html.EscapeString(window[last:m[0]]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(window[m[0]:m[1]]))
+		b.WriteString("</em>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(window[last:]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
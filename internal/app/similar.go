@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"cupid_hotel/internal/domain"
+)
+
+// similarCandidates is the page size candidates are fetched in; every hotel
+// of the scope is scored, a page at a time.
+const similarCandidates = 500
+
+// Blend weights of the similarity score; they sum to 1 so scores stay in [0, 1].
+const (
+	weightAmenities = 0.4
+	weightStars     = 0.2
+	weightRating    = 0.2
+	weightDistance  = 0.2
+)
+
+// similarDistanceKm is the distance at which the proximity signal halves.
+const similarDistanceKm = 5.0
+
+// SimilarHotels ranks the hotels around q.ID (same city, or q.RadiusKm) by a
+// blended score. Names follow lang with the same fallback as GetHotel.
+// Cached on the catalog generation; review ratings only refresh with the TTL.
+func (s *QueryService) SimilarHotels(ctx context.Context, q domain.SimilarQuery) (domain.SimilarPage, error) {
+	radius := ""
+	if q.RadiusKm != nil {
+		radius = fmt.Sprint(*q.RadiusKm)
+	}
+	key := fmt.Sprintf("similar:%d:%s", s.generation(ctx, catalogGenKey), hashKey(
+		fmt.Sprint(q.ID), q.Lang, radius, fmt.Sprint(q.Limit),
+	))
+	var out domain.SimilarPage
+	if ok, _ := s.cache.Get(ctx, key, &out); ok {
+		return out, nil
+	}
+
+	ref, err := s.GetHotel(ctx, q.ID, q.Lang)
+	if err != nil {
+		return domain.SimilarPage{}, err
+	}
+	// keep the best q.Limit hits seen so far
+	hits := []domain.SimilarHit{}
+	for after := int64(0); ; {
+		cands, err := s.repo.SimilarCandidates(ctx, q, after, similarCandidates)
+		if err != nil {
+			return domain.SimilarPage{}, err
+		}
+		for _, c := range cands {
+			hits = append(hits, domain.SimilarHit{Hotel: c.Hotel, Score: similarityScore(ref, c), DistanceKm: c.DistanceKm})
+		}
+		sort.SliceStable(hits, func(i, j int) bool {
+			if hits[i].Score != hits[j].Score {
+				return hits[i].Score > hits[j].Score
+			}
+			return hits[i].Hotel.ID < hits[j].Hotel.ID
+		})
+		if len(hits) > q.Limit {
+			hits = hits[:q.Limit]
+		}
+		if len(cands) < similarCandidates {
+			break
+		}
+		after = cands[len(cands)-1].Hotel.ID
+	}
+
+	views := make([]domain.HotelView, len(hits))
+	for i := range hits {
+		views[i] = hits[i].Hotel
+	}
+	if err := s.applyFallback(ctx, views, q.Lang); err != nil {
+		return domain.SimilarPage{}, err
+	}
+	if err := s.labelAmenities(ctx, views, q.Lang); err != nil {
+		return domain.SimilarPage{}, err
+	}
+	for i := range hits {
+		hits[i].Hotel = views[i]
+	}
+
+	out = domain.SimilarPage{Items: hits}
+	_ = s.cache.Set(ctx, key, out, int(s.cacheTTL.Seconds()))
+	return out, nil
+}
+
+// similarityScore blends amenity overlap (Jaccard), star closeness, review
+// rating and proximity. Unknown stars or rating count as neutral (0.5);
+// unknown distance earns nothing.
+func similarityScore(ref domain.HotelView, c domain.SimilarCandidate) float64 {
+	stars := 0.5
+	if ref.Stars != nil && c.Hotel.Stars != nil {
+		stars = 1 - math.Min(math.Abs(float64(*ref.Stars-*c.Hotel.Stars))/4, 1)
+	}
+	rating := 0.5
+	if c.Rating != nil {
+		rating = math.Max(0, math.Min(*c.Rating/10, 1))
+	}
+	dist := 0.0
+	if c.DistanceKm != nil {
+		dist = 1 / (1 + *c.DistanceKm/similarDistanceKm)
+	}
+	return weightAmenities*amenityJaccard(ref.CanonicalAmenities, c.Hotel.CanonicalAmenities) +
+		weightStars*stars + weightRating*rating + weightDistance*dist
+}
+
+// amenityJaccard is |A∩B| / |A∪B| over amenity codes; 0 when both are empty.
+func amenityJaccard(a, b []domain.Amenity) float64 {
+	set := make(map[string]bool, len(a))
+	for _, x := range a {
+		set[x.Code] = true
+	}
+	inter, union := 0, len(set)
+	seen := map[string]bool{}
+	for _, y := range b {
+		if seen[y.Code] {
+			continue
+		}
+		seen[y.Code] = true
+		if set[y.Code] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
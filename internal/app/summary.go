@@ -0,0 +1,81 @@
+package app
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+
+	"cupid_hotel/internal/domain"
+)
+
+/********** pros/cons phrase mining **********/
+
+// topPhrases is how many pros/cons phrases a summary reports.
+const topPhrases = 10
+
+// mineAspects counts normalized pros/cons phrases across reviews' aspects JSON
+// (as built by mapReviews). Each phrase counts at most once per review.
+func mineAspects(aspects [][]byte, top int) (pros, cons []domain.PhraseCount) {
+	prosN, consN := map[string]int{}, map[string]int{}
+	for _, raw := range aspects {
+		var a struct {
+			Pros []string `json:"pros"`
+			Cons []string `json:"cons"`
+		}
+		if err := json.Unmarshal(raw, &a); err != nil {
+			continue // legacy/opaque rows
+		}
+		countPhrases(prosN, a.Pros)
+		countPhrases(consN, a.Cons)
+	}
+	return rankPhrases(prosN, top), rankPhrases(consN, top)
+}
+
+func countPhrases(into map[string]int, items []string) {
+	seen := map[string]struct{}{}
+	for _, it := range items {
+		for _, p := range splitPhrases(it) {
+			if _, dup := seen[p]; dup {
+				continue
+			}
+			seen[p] = struct{}{}
+			into[p]++
+		}
+	}
+}
+
+// splitPhrases breaks free text ("Great location, friendly staff.") into
+// normalized phrases ("great location", "friendly staff").
+func splitPhrases(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == '.' || r == '!' || r == '\n' || r == '•'
+	}) {
+		p := strings.ToLower(strings.Join(strings.Fields(part), " "))
+		p = strings.TrimFunc(p, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		// Very short fragments are noise; very long ones are sentences, not phrases.
+		if n := len([]rune(p)); n < 3 || n > 60 {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func rankPhrases(counts map[string]int, top int) []domain.PhraseCount {
+	out := make([]domain.PhraseCount, 0, len(counts))
+	for p, n := range counts {
+		out = append(out, domain.PhraseCount{Phrase: p, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Phrase < out[j].Phrase
+	})
+	if len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
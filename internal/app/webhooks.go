@@ -0,0 +1,257 @@
+package app
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"cupid_hotel/internal/domain"
+)
+
+// minSecretLen is the shortest caller-chosen signing secret accepted.
+const minSecretLen = 16
+
+// WebhookService manages partner subscriptions.
+type WebhookService struct {
+	repo domain.WebhookRepository
+}
+
+func NewWebhookService(r domain.WebhookRepository) *WebhookService {
+	return &WebhookService{repo: r}
+}
+
+// Create validates and stores a subscription. Without a secret one is
+// generated; the returned subscription is the only place it is shown.
+func (s *WebhookService) Create(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	ve := &domain.ValidationError{}
+	if u, err := url.Parse(sub.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: "url", Reason: "url must be an absolute http(s) URL"})
+	}
+	if len(sub.Events) == 0 {
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: "events", Reason: "events must list at least one event type"})
+	}
+	var events []string
+	for _, e := range sub.Events {
+		if !slices.Contains(domain.EventTypes, e) {
+			ve.Fields = append(ve.Fields, domain.FieldError{
+				Field: "events", Reason: fmt.Sprintf("unknown event %q; one of %s", e, strings.Join(domain.EventTypes, ", ")),
+			})
+		} else if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+	if sub.Secret != "" && len(sub.Secret) < minSecretLen {
+		ve.Fields = append(ve.Fields, domain.FieldError{Field: "secret", Reason: fmt.Sprintf("secret must be at least %d characters", minSecretLen)})
+	}
+	if len(ve.Fields) > 0 {
+		return domain.WebhookSubscription{}, ve
+	}
+
+	sub.Events = events
+	if sub.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := crand.Read(b); err != nil {
+			return domain.WebhookSubscription{}, err
+		}
+		sub.Secret = "whsec_" + hex.EncodeToString(b)
+	}
+	return s.repo.CreateWebhook(ctx, sub)
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return s.repo.ListWebhooks(ctx)
+}
+
+func (s *WebhookService) Get(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	return s.repo.GetWebhook(ctx, id)
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id int64) error {
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// Enable re-activates a disabled subscription. Deliveries given up while it
+// was disabled are not replayed; the change feed covers the gap.
+func (s *WebhookService) Enable(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	if err := s.repo.EnableWebhook(ctx, id); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	return s.repo.GetWebhook(ctx, id)
+}
+
+// Attempts returns the delivery log of a subscription, newest first.
+func (s *WebhookService) Attempts(ctx context.Context, id int64, limit int) ([]domain.WebhookAttempt, error) {
+	return s.repo.ListWebhookAttempts(ctx, id, limit)
+}
+
+// DispatcherConfig tunes WebhookDispatcher; zero fields take the defaults
+// of NewWebhookDispatcher.
+type DispatcherConfig struct {
+	Interval     time.Duration // poll period of the outbox and of due deliveries
+	Batch        int           // events fanned out and deliveries sent per poll
+	MaxAttempts  int           // attempts before a delivery is given up
+	BaseBackoff  time.Duration // delay after the first failed attempt; doubles per attempt
+	MaxBackoff   time.Duration
+	DisableAfter int           // consecutive failed attempts that disable a subscription
+	Lease        time.Duration // how long a claimed delivery is hidden from other dispatchers
+}
+
+// WebhookDispatcher drains the outbox into deliveries and POSTs them. Any
+// number of dispatchers may run against the same database.
+type WebhookDispatcher struct {
+	repo   domain.WebhookRepository
+	sender domain.WebhookSender
+	cfg    DispatcherConfig
+}
+
+func NewWebhookDispatcher(r domain.WebhookRepository, s domain.WebhookSender, cfg DispatcherConfig) *WebhookDispatcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.Batch <= 0 {
+		cfg.Batch = 50
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 10 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Hour
+	}
+	if cfg.DisableAfter <= 0 {
+		cfg.DisableAfter = 20
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+	return &WebhookDispatcher{repo: r, sender: s, cfg: cfg}
+}
+
+// Run polls until ctx is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	t := time.NewTicker(d.cfg.Interval)
+	defer t.Stop()
+	for {
+		if _, err := d.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("webhook dispatch failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce fans out pending events, then attempts every due delivery once,
+// concurrently. It returns the number of attempts made.
+func (d *WebhookDispatcher) RunOnce(ctx context.Context) (int, error) {
+	if _, err := d.repo.FanOutEvents(ctx, d.cfg.Batch); err != nil {
+		return 0, err
+	}
+	ds, err := d.repo.ClaimDeliveries(ctx, d.cfg.Batch, d.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(ds))
+	for i, dl := range ds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.deliver(ctx, dl)
+		}()
+	}
+	wg.Wait()
+	return len(ds), errors.Join(errs...)
+}
+
+// deliver makes one attempt and records its outcome: 2xx delivers, anything
+// else retries with exponential backoff until MaxAttempts.
+func (d *WebhookDispatcher) deliver(ctx context.Context, dl domain.WebhookDelivery) error {
+	body, err := json.Marshal(newEventPayload(dl.Event))
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	status, err := d.sender.Send(ctx, dl, body)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down: not the endpoint's fault. The lease expires and a
+		// dispatcher tries again.
+		return nil
+	}
+	a := domain.WebhookAttempt{
+		DeliveryID:     dl.ID,
+		SubscriptionID: dl.Subscription.ID,
+		EventID:        dl.Event.ID,
+		EventType:      dl.Event.Type,
+		Attempt:        dl.Attempts + 1,
+		StatusCode:     status,
+		Duration:       time.Since(start),
+		At:             start,
+	}
+	o := domain.WebhookOutcome{DisableAfter: d.cfg.DisableAfter}
+	switch {
+	case err != nil:
+		a.Error = err.Error()
+	case status >= 200 && status < 300:
+		o.Delivered = true
+	default:
+		a.Error = fmt.Sprintf("unexpected status %d", status)
+	}
+	if !o.Delivered && a.Attempt < d.cfg.MaxAttempts {
+		o.RetryIn = d.Backoff(a.Attempt)
+	}
+
+	// Record even when ctx was cancelled after the response, so a delivered
+	// event is not sent again.
+	disabled, err := d.repo.RecordAttempt(context.WithoutCancel(ctx), a, o)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		log.Warn().Int64("webhook", a.SubscriptionID).Str("url", dl.Subscription.URL).
+			Int("after", d.cfg.DisableAfter).Msg("webhook disabled after consecutive failures")
+	}
+	return nil
+}
+
+// Backoff is the delay after the given failed attempt (1-based):
+// BaseBackoff doubled per attempt, capped at MaxBackoff.
+func (d *WebhookDispatcher) Backoff(attempt int) time.Duration {
+	b := d.cfg.BaseBackoff
+	for i := 1; i < attempt && b < d.cfg.MaxBackoff; i++ {
+		b *= 2
+	}
+	return min(b, d.cfg.MaxBackoff)
+}
+
+// eventPayload is the JSON body of a delivery. Data names the hotel (and
+// language) to refetch; it deliberately carries no content.
+type eventPayload struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      eventData `json:"data"`
+}
+
+type eventData struct {
+	HotelID int64   `json:"hotel_id"`
+	Lang    *string `json:"lang,omitempty"`
+}
+
+func newEventPayload(e domain.Event) eventPayload {
+	return eventPayload{ID: e.ID, Type: e.Type, CreatedAt: e.At.UTC(), Data: eventData{HotelID: e.PropertyID, Lang: e.Lang}}
+}
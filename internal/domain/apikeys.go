@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// API key scopes.
+const (
+	ScopeRead   = "read"   // catalogue, reviews and change feed
+	ScopeExport = "export" // bulk exports
+	ScopeAdmin  = "admin"  // webhooks and API keys; implies every other scope
+)
+
+// Scopes lists every scope a key may be granted.
+var Scopes = []string{ScopeRead, ScopeExport, ScopeAdmin}
+
+// APIKey is a consumer credential. Only a hash of the secret is stored;
+// Prefix (its first characters) identifies it in listings and logs.
+type APIKey struct {
+	ID        int64
+	Name      string
+	Prefix    string
+	Scopes    []string
+	Quota     Quota // zero fields take the service defaults
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// Allows reports whether the key grants scope.
+func (k APIKey) Allows(scope string) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, ScopeAdmin)
+}
+
+// Quota is a token bucket: Burst requests at once, refilled at Rate per second.
+type Quota struct {
+	Rate  float64
+	Burst int
+}
+
+// RateDecision is the outcome of taking one token.
+type RateDecision struct {
+	Allowed    bool
+	Limit      int           // bucket size
+	Remaining  int           // whole tokens left
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // when denied: until the next token
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, k APIKey, hash string) (APIKey, error)
+	APIKeyByHash(ctx context.Context, hash string) (APIKey, error) // ErrNotFound for unknown or revoked keys
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error // ErrNotFound
+}
+
+// RateLimiter takes one token from the bucket named key.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, q Quota) (RateDecision, error)
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// Change feed entities and operations.
+const (
+	ChangeProperty    = "property"
+	ChangeTranslation = "translation"
+	ChangeReviews     = "reviews" // the hotel's review set changed: created = reviews added
+
+	ChangeCreated     = "created"
+	ChangeUpdated     = "updated"
+	ChangeDeactivated = "deactivated"
+)
+
+// Change is one committed write. Seq grows in commit order and doubles as
+// the feed token: a reader that resumes after Seq never misses a change.
+type Change struct {
+	Seq        int64
+	Entity     string
+	Op         string
+	PropertyID int64
+	Lang       *string // translations only
+	At         time.Time
+}
+
+type ChangesPage struct {
+	Items   []Change
+	Next    int64 // token to resume from: the last Seq, or the requested one when empty
+	HasMore bool
+}
@@ -1,5 +1,58 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
-var ErrNotFound = errors.New("not found")
+// Error kinds shared by the repo, services and adapters. Wrap them with %w to
+// add context; callers classify with errors.Is.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrUnavailable     = errors.New("unavailable")     // dependency down or timed out; retry later
+	ErrGone            = errors.New("gone")            // known, but withdrawn by the provider
+	ErrRateLimited     = errors.New("rate limited")    // caller exceeded its quota
+	ErrUnauthenticated = errors.New("unauthenticated") // no API key, or an unknown or revoked one
+	ErrForbidden       = errors.New("forbidden")       // the API key lacks the scope
+)
+
+// Answers of an upstream provider (Cupid) the app acts on. They describe our
+// calls to the provider, not the caller's request, so they are kept apart
+// from the kinds above: an API caller sees any of them as a bad gateway.
+var (
+	ErrUpstream             = errors.New("upstream error")
+	ErrUpstreamNotFound     = fmt.Errorf("%w: not found", ErrUpstream)
+	ErrUpstreamUnauthorized = fmt.Errorf("%w: unauthorized", ErrUpstream) // our provider credential was rejected
+	ErrUpstreamForbidden    = fmt.Errorf("%w: forbidden", ErrUpstream)
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor error = Invalid("cursor", "cursor is malformed, expired or does not match sort")
+
+// FieldError is one rejected input field.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+// ValidationError reports rejected inputs; it is an ErrInvalidArgument.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Invalid builds a ValidationError for a single field.
+func Invalid(field, reason string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Reason: reason}}}
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Reason
+	}
+	return "invalid argument: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() error { return ErrInvalidArgument }
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// CheckResult is the outcome of probing one dependency. Optional
+// dependencies are reported but do not make the service unready.
+type CheckResult struct {
+	Name     string
+	OK       bool
+	Optional bool
+	Detail   string // e.g. the schema version found
+	Error    string
+	Duration time.Duration
+}
+
+// Readiness says whether the service can take traffic: every required
+// check passed. Degraded means an optional one failed.
+type Readiness struct {
+	Ready    bool
+	Degraded bool
+	Checks   []CheckResult // in registration order
+}
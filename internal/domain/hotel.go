@@ -1,16 +1,42 @@
 package domain
 
 type Hotel struct {
-	ID         int64
-	BrandID    *int64
-	Stars      *int
-	Lat, Lon   *float64
-	Country    *string
-	City       *string
-	AddressRaw *string
-	Amenities  []string
-	Images     []string
-	RawJSON    []byte // full Cupid property payload
+	ID           int64
+	BrandID      *int64
+	BrandName    *string // chain name, upserted into brands
+	Stars        *int
+	Lat, Lon     *float64
+	Country      *string
+	City         *string
+	AddressRaw   *string
+	Amenities    []string
+	Images       []string
+	RawJSON      []byte   // full Cupid property payload
+	AmenityCodes []string // canonical amenity codes (property_amenities)
+	Photos       []HotelImage
+}
+
+// HotelImage is one gallery photo. SortOrder follows the provider's order;
+// at most one image per hotel is primary.
+type HotelImage struct {
+	URL           string
+	Caption       *string
+	Category      *string // room, exterior, lobby, ...
+	Width, Height *int
+	SortOrder     int
+	IsPrimary     bool
+}
+
+// AmenityDef is one entry of the canonical amenity taxonomy.
+type AmenityDef struct {
+	Code   string
+	Labels map[string]string // lang -> label
+}
+
+// Amenity is a canonical amenity as served: code plus label in one language.
+type Amenity struct {
+	Code  string
+	Label string
 }
 
 type HotelI18n struct {
@@ -22,3 +48,10 @@ type HotelI18n struct {
 	Address     *string
 	ExtrasJSON  []byte // full localized payload for future fields
 }
+
+// Brand is a hotel chain. HotelCount is filled by brand listings.
+type Brand struct {
+	ID         int64
+	Name       *string
+	HotelCount int
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Ingestion steps of one property, in the order they run; translations are
+// StepI18nPrefix + language.
+const (
+	StepProperty   = "property"
+	StepReviews    = "reviews"
+	StepI18nPrefix = "i18n:"
+)
+
+// Parts of a property an ingestion may refresh.
+const (
+	PartProperty     = "property"
+	PartReviews      = "reviews"
+	PartTranslations = "translations"
+)
+
+// Parts lists every part, in the order they are ingested.
+var Parts = []string{PartProperty, PartReviews, PartTranslations}
+
+// IngestOptions narrow what ingesting a property fetches. Nil Langs and Parts
+// mean every supported language and every part.
+type IngestOptions struct {
+	ReviewCount int
+	Langs       []string
+	Parts       []string
+}
+
+// Has reports whether part is to be ingested.
+func (o IngestOptions) Has(part string) bool {
+	return o.Parts == nil || slices.Contains(o.Parts, part)
+}
+
+// Step outcomes.
+const (
+	StepOK      = "ok"      // fetched and stored
+	StepMissed  = "missed"  // upstream answered 404 or 401/403; logged to ingest_misses
+	StepFailed  = "failed"  // unexpected error; ingestion of the property stopped here
+	StepSkipped = "skipped" // not run: an earlier step missed the property or failed
+)
+
+// Overall outcomes of an IngestReport.
+const (
+	IngestOK          = "ok"          // every step stored data
+	IngestPartial     = "partial"     // reviews or some translations were missed
+	IngestDeactivated = "deactivated" // the property itself was missed and deactivated
+	IngestFailed      = "failed"      // a step failed; see its Reason
+)
+
+// IngestStep is the outcome of one step. Status and Reason are those of a
+// miss as logged (e.g. 404 "i18n:fr"), or Reason is the error of a failure.
+type IngestStep struct {
+	Step    string
+	Outcome string
+	Status  int
+	Reason  string
+	Stored  int // rows written: reviews upserted, 1 for the property or a translation
+}
+
+// IngestReport tells what ingesting one property did, step by step.
+type IngestReport struct {
+	PropertyID int64
+	Outcome    string
+	Steps      []IngestStep
+}
+
+// Ingest job statuses.
+const (
+	JobQueued      = "queued"
+	JobRunning     = "running"
+	JobSucceeded   = "succeeded"   // every property was ingested; misses included
+	JobFailed      = "failed"      // at least one property failed, or the job itself did
+	JobCancelled   = "cancelled"   // stopped on request; properties not started were left out
+	JobInterrupted = "interrupted" // stopped by a shutdown of the process running it; likewise
+)
+
+// IngestJob is ingestion run in the background on request. Reports holds
+// one entry per finished property, in completion order.
+type IngestJob struct {
+	ID                string
+	Status            string
+	PropertyIDs       []int64
+	Options           IngestOptions
+	Reports           []IngestReport
+	Error             string // why the job itself failed, if it did
+	CreatedAt         time.Time
+	StartedAt         *time.Time
+	FinishedAt        *time.Time
+	CancelRequestedAt *time.Time
+}
+
+// Finished reports whether the job reached a final status.
+func (j IngestJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled || j.Status == JobInterrupted
+}
+
+// Remaining lists the properties the job has no report for, in job order.
+func (j IngestJob) Remaining() []int64 {
+	done := make(map[int64]bool, len(j.Reports))
+	for _, r := range j.Reports {
+		done[r.PropertyID] = true
+	}
+	var out []int64
+	for _, id := range j.PropertyIDs {
+		if !done[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+type IngestJobRepository interface {
+	CreateIngestJob(ctx context.Context, j IngestJob) error
+	GetIngestJob(ctx context.Context, id string) (IngestJob, error) // ErrNotFound
+	UpdateIngestJob(ctx context.Context, j IngestJob) error         // status, error and times; also the job's heartbeat
+	// AddIngestJobReports stores the reports of hotels the job finished;
+	// storing one again replaces it.
+	AddIngestJobReports(ctx context.Context, id string, reps []IngestReport) error
+	// CancelIngestJob records a cancellation request (the first one wins);
+	// the replica running the job picks it up. ErrNotFound for unknown ids.
+	CancelIngestJob(ctx context.Context, id string) error
+	IngestJobCancelRequested(ctx context.Context, id string) (bool, error)
+	// InterruptStaleIngestJobs marks interrupted the unfinished jobs without
+	// a heartbeat for staleAfter (their replica died) and returns how many.
+	InterruptStaleIngestJobs(ctx context.Context, staleAfter time.Duration) (int64, error)
+}
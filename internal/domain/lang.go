@@ -0,0 +1,41 @@
+package domain
+
+import "strings"
+
+// Languages is the language registry: which content languages we serve and
+// how a missing translation falls back.
+type Languages struct {
+	Supported []string            // lowercase tags, e.g. en, fr, es
+	Default   string              // served when nothing else matches; last link of every chain
+	Fallback  map[string][]string // per-language chain, e.g. fr -> [en]
+}
+
+func DefaultLanguages() Languages {
+	return Languages{Supported: []string{"en", "fr", "es"}, Default: "en"}
+}
+
+func (l Languages) IsSupported(tag string) bool {
+	tag = strings.ToLower(tag)
+	for _, s := range l.Supported {
+		if s == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Chain is the lookup order for lang: lang itself, its configured fallbacks,
+// then the default. Duplicates are dropped.
+func (l Languages) Chain(lang string) []string {
+	lang = strings.ToLower(lang)
+	out := []string{lang}
+	seen := map[string]bool{lang: true}
+	for _, fb := range append(append([]string{}, l.Fallback[lang]...), l.Default) {
+		fb = strings.ToLower(fb)
+		if fb != "" && !seen[fb] {
+			seen[fb] = true
+			out = append(out, fb)
+		}
+	}
+	return out
+}
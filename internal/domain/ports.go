@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type HotelRepository interface {
 	// Write paths
@@ -8,11 +11,39 @@ type HotelRepository interface {
 	UpsertI18n(ctx context.Context, i HotelI18n) error
 	UpsertReviews(ctx context.Context, rs []Review) error
 	LogMiss(ctx context.Context, id int64, status int, reason string) error
+	DeactivateProperty(ctx context.Context, id int64) error // reads answer ErrGone until the next UpsertProperty
 
 	// Read paths
-	GetHotel(ctx context.Context, id int64, lang string) (HotelView, error)
+	GetHotel(ctx context.Context, id int64, lang string) (HotelView, error)       // ErrNotFound / ErrGone
+	GetHotels(ctx context.Context, ids []int64, lang string) ([]HotelView, error) // found ones only, any order
 	ListHotels(ctx context.Context, q HotelsQuery) (HotelsPage, error)
+	HotelFacets(ctx context.Context, q HotelsQuery) (HotelFacets, error) // q.Limit caps buckets per facet
+	AmenityTaxonomy(ctx context.Context) ([]AmenityDef, error)
+	ListImages(ctx context.Context, id int64) ([]HotelImage, error) // primary first; ErrNotFound for unknown hotels
+	ListBrands(ctx context.Context) ([]Brand, error)
+	GetBrand(ctx context.Context, id int64) (Brand, error) // ErrNotFound for unknown brands
+	SearchHotels(ctx context.Context, q SearchQuery) (SearchPage, error)
+	NearbyHotels(ctx context.Context, q NearbyQuery) (NearbyPage, error)
+	// SimilarCandidates returns up to max hotels in the scope of q with ids
+	// above afterID, in id order (ErrNotFound for an unknown q.ID); ranking is
+	// left to the caller.
+	SimilarCandidates(ctx context.Context, q SimilarQuery, afterID int64, max int) ([]SimilarCandidate, error)
 	ListReviews(ctx context.Context, id int64, pg PageQuery) (ReviewsPage, error)
+	ReviewSummary(ctx context.Context, id int64) (ReviewSummary, error) // SQL aggregates; phrases left empty
+	ReviewAspects(ctx context.Context, id int64) ([][]byte, error)      // raw aspects JSON per review
+
+	// Exports stream rows in id order to fn straight off the result set (no
+	// buffering). When q.Limit cuts the export short, the returned token
+	// continues after the last row; it is nil once everything was sent.
+	ExportHotels(ctx context.Context, q ExportQuery, fn func(HotelView) error) (*string, error)
+	ExportReviews(ctx context.Context, q ExportQuery, fn func(Review) error) (*string, error)
+
+	// ListChanges returns up to limit feed entries with Seq > since, in order.
+	ListChanges(ctx context.Context, since int64, limit int) (ChangesPage, error)
+
+	// Versions back HTTP validators; both answer ErrNotFound / ErrGone like the reads.
+	HotelVersion(ctx context.Context, id int64, langs []string) (Version, error) // property + translations in langs
+	ReviewsVersion(ctx context.Context, id int64) (Version, error)
 }
 
 type CupidClient interface {
@@ -25,6 +56,22 @@ type Cache interface {
 	Get(ctx context.Context, key string, dst any) (bool, error)
 	Set(ctx context.Context, key string, v any, ttlSec int) error
 	Del(ctx context.Context, key string) error
+
+	// MGet fetches keys in one round-trip, decoding hit i into dst[i];
+	// the returned slice reports which keys were hits.
+	MGet(ctx context.Context, keys []string, dst []any) ([]bool, error)
+	// MSet writes all entries with the same TTL in one round-trip.
+	MSet(ctx context.Context, kv map[string]any, ttlSec int) error
+}
+
+// Version identifies the stored state of a resource. Tag changes whenever
+// the stored content does; Modified is the last change (zero if unknown).
+// Language is the Content-Language of a localized representation ("fr, en"
+// when fields fall back); empty means the requested language.
+type Version struct {
+	Tag      string
+	Modified time.Time
+	Language string
 }
 
 // Read models & queries
@@ -40,25 +87,130 @@ type HotelView struct {
 	Policies    *string
 	Amenities   []string
 	Images      []string
+	MainPhoto   *string // primary image URL (thumbnail)
+	Brand       *Brand  // nil when the hotel has no chain
 	Language    string
+	// CanonicalAmenities are the taxonomy codes of the hotel with labels in
+	// the served language; Amenities keeps the raw provider strings.
+	CanonicalAmenities []Amenity
+	// FieldLanguages reports which language actually served each translated
+	// field (name, description, policies) after fallback; absent = no value.
+	FieldLanguages map[string]string
 }
 
 type Coords struct{ Lat, Lon float64 }
 
+// HotelLookup is one slot of a batch lookup; Hotel is nil when the id is unknown.
+type HotelLookup struct {
+	ID    int64
+	Found bool
+	Hotel *HotelView
+}
+
+type HotelBatch struct {
+	Items []HotelLookup // in request order
+}
+
+// FacetCount is one bucket of a facet ("Paris", 42). Label is set for
+// amenity codes (localized).
+type FacetCount struct {
+	Value string
+	Label string
+	Count int
+}
+
+// HotelFacets counts hotels per filter dimension. Counts are disjunctive: each
+// facet applies every filter except its own, so picking one city still shows
+// the other cities' counts.
+type HotelFacets struct {
+	Country []FacetCount
+	City    []FacetCount
+	Stars   []FacetCount
+	Amenity []FacetCount
+}
+
 type HotelsQuery struct {
 	Lang          string
 	Q             *string
 	Country, City *string
 	Stars         *int
-	Amenity       *string
+	BrandID       *int64
+	Amenities     []string // canonical codes
+	AmenityMatch  string   // AmenityMatchAll (default) or AmenityMatchAny
+	Limit         int
+	Cursor        *string
+}
+
+const (
+	AmenityMatchAll = "all"
+	AmenityMatchAny = "any"
+)
+
+// Full-text search modes (MySQL MATCH ... AGAINST).
+const (
+	SearchNatural = "natural"
+	SearchBoolean = "boolean"
+)
+
+type SearchQuery struct {
+	Lang          string
+	Q             string
+	Mode          string // SearchNatural|SearchBoolean
+	Country, City *string
+	Stars         *int
 	Limit         int
 	Cursor        *string
 }
 
+// BBox is a map viewport in degrees.
+type BBox struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+type NearbyQuery struct {
+	Lang     string
+	Lat, Lon float64  // reference point; distances are measured from here
+	RadiusKm *float64 // optional when BBox is set
+	BBox     *BBox    // optional when RadiusKm is set
+	Limit    int
+}
+
+// SimilarQuery asks for hotels like ID: from the same city, or within
+// RadiusKm of it when set.
+type SimilarQuery struct {
+	ID       int64
+	Lang     string
+	RadiusKm *float64
+	Limit    int
+}
+
+// ExportQuery selects the rows of a bulk export. Deactivated hotels (and
+// their reviews) are left out.
+type ExportQuery struct {
+	Lang         string     // hotels: content language; reviews: only reviews in it ("" = all)
+	UpdatedSince *time.Time // only rows written at or after this time
+	Limit        int        // rows per response; 0 = no limit
+	Cursor       *string    // continuation token of a previous export
+	AfterID      int64      // only rows with a greater id: the last one a client received
+}
+
+// Review sort orders accepted by PageQuery.Sort.
+const (
+	SortNewest     = "-created_at"
+	SortOldest     = "created_at"
+	SortRatingDesc = "-rating"
+	SortRatingAsc  = "rating"
+)
+
 type PageQuery struct {
 	Limit  int
 	Cursor *string
 	Sort   string
+
+	// Review filters (all optional).
+	Lang      *string
+	Source    *string
+	MinRating *float64
 }
 
 type HotelsPage struct {
@@ -66,6 +218,44 @@ type HotelsPage struct {
 	NextCursor *string
 }
 
+type SearchHit struct {
+	Hotel      HotelView
+	Score      float64
+	Highlights map[string]string // field -> HTML-escaped snippet with <em> marks
+}
+
+type SearchPage struct {
+	Items      []SearchHit
+	NextCursor *string
+}
+
+type NearbyHit struct {
+	Hotel      HotelView
+	DistanceKm float64
+}
+
+type NearbyPage struct {
+	Items []NearbyHit
+}
+
+// SimilarCandidate carries the raw signals of one candidate; Hotel includes
+// its canonical amenity codes.
+type SimilarCandidate struct {
+	Hotel      HotelView
+	Rating     *float64 // mean review rating (0-10), nil without rated reviews
+	DistanceKm *float64 // from the reference hotel, nil when either lacks coordinates
+}
+
+type SimilarHit struct {
+	Hotel      HotelView
+	Score      float64 // blended similarity in [0, 1]
+	DistanceKm *float64
+}
+
+type SimilarPage struct {
+	Items []SimilarHit
+}
+
 type ReviewsPage struct {
 	Items      []Review
 	NextCursor *string
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type Review struct {
 	ID          int64
 	PropertyID  int64
@@ -12,4 +14,30 @@ type Review struct {
 	AspectsJSON []byte // {"pros":[...],"cons":[...]} — optional
 	Source      *string
 	RawJSON     []byte
+	CreatedAt   time.Time // set on reads; ingestion leaves it zero (DB default)
+}
+
+// ReviewSummary aggregates all reviews of one property.
+type ReviewSummary struct {
+	PropertyID   int64
+	Count        int      // all reviews
+	RatedCount   int      // reviews with a rating
+	MeanRating   *float64 // nil when no review is rated
+	MedianRating *float64
+	Histogram    []RatingBucket // ascending by Rating; only non-empty buckets
+	ByLang       map[string]int // "" = unknown
+	BySource     map[string]int // "" = unknown
+	TopPros      []PhraseCount
+	TopCons      []PhraseCount
+}
+
+// RatingBucket counts ratings r with Rating <= r < Rating+1.
+type RatingBucket struct {
+	Rating int
+	Count  int
+}
+
+type PhraseCount struct {
+	Phrase string
+	Count  int
 }
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Webhook event types.
+const (
+	EventHotelCreated     = "hotel.created"
+	EventHotelUpdated     = "hotel.updated" // property or translation
+	EventHotelDeactivated = "hotel.deactivated"
+	EventReviewsAdded     = "reviews.added"   // new reviews (existing ones may have changed too)
+	EventReviewsUpdated   = "reviews.updated" // existing reviews changed, none added
+)
+
+// EventTypes lists every event a subscription may ask for.
+var EventTypes = []string{
+	EventHotelCreated, EventHotelUpdated, EventHotelDeactivated, EventReviewsAdded, EventReviewsUpdated,
+}
+
+// EventFor names the webhook event a committed change emits.
+func EventFor(c Change) string {
+	switch {
+	case c.Entity == ChangeReviews && c.Op == ChangeCreated:
+		return EventReviewsAdded
+	case c.Entity == ChangeReviews:
+		return EventReviewsUpdated
+	case c.Op == ChangeDeactivated:
+		return EventHotelDeactivated
+	case c.Entity == ChangeProperty && c.Op == ChangeCreated:
+		return EventHotelCreated
+	}
+	return EventHotelUpdated
+}
+
+// Event is one outbox entry, written in the transaction of the change that
+// emitted it.
+type Event struct {
+	ID         int64
+	Type       string
+	PropertyID int64
+	Lang       *string // translation changes only
+	At         time.Time
+}
+
+// WebhookSubscription is a partner endpoint. Secret keys the HMAC signature
+// of every delivery. Subscriptions are disabled after too many consecutive
+// failed attempts and stay so until re-enabled.
+type WebhookSubscription struct {
+	ID                  int64
+	URL                 string
+	Secret              string
+	Events              []string
+	ConsecutiveFailures int
+	DisabledAt          *time.Time
+	DisabledReason      *string
+	CreatedAt           time.Time
+}
+
+// WebhookDelivery is one event owed to one subscription.
+type WebhookDelivery struct {
+	ID           int64
+	Attempts     int // made so far
+	Subscription WebhookSubscription
+	Event        Event
+}
+
+// WebhookAttempt is one POST of a delivery, as kept in the delivery log.
+type WebhookAttempt struct {
+	DeliveryID     int64
+	SubscriptionID int64
+	EventID        int64
+	EventType      string
+	Attempt        int // 1-based
+	StatusCode     int // 0 when no response was received
+	Error          string
+	Duration       time.Duration
+	At             time.Time
+}
+
+// WebhookOutcome is the dispatcher's verdict on an attempt.
+type WebhookOutcome struct {
+	Delivered    bool
+	RetryIn      time.Duration // when not delivered; 0 gives the delivery up
+	DisableAfter int           // consecutive failed attempts that disable the subscription
+}
+
+type WebhookRepository interface {
+	// Subscriptions
+	CreateWebhook(ctx context.Context, s WebhookSubscription) (WebhookSubscription, error)
+	ListWebhooks(ctx context.Context) ([]WebhookSubscription, error)
+	GetWebhook(ctx context.Context, id int64) (WebhookSubscription, error) // ErrNotFound
+	DeleteWebhook(ctx context.Context, id int64) error                     // ErrNotFound
+	EnableWebhook(ctx context.Context, id int64) error                     // clears the failure count; ErrNotFound
+	ListWebhookAttempts(ctx context.Context, id int64, limit int) ([]WebhookAttempt, error)
+
+	// Dispatch. FanOutEvents turns up to max outbox events into deliveries
+	// for the enabled subscriptions that want them. ClaimDeliveries leases up
+	// to max due deliveries for lease, so concurrent dispatchers skip them.
+	FanOutEvents(ctx context.Context, max int) (int, error)
+	ClaimDeliveries(ctx context.Context, max int, lease time.Duration) ([]WebhookDelivery, error)
+	// RecordAttempt logs a and applies o; it reports whether o disabled the subscription.
+	RecordAttempt(ctx context.Context, a WebhookAttempt, o WebhookOutcome) (bool, error)
+}
+
+// WebhookSender POSTs a signed payload to the delivery's subscription and
+// returns the response status (0 and an error when none was received).
+type WebhookSender interface {
+	Send(ctx context.Context, d WebhookDelivery, body []byte) (int, error)
+}
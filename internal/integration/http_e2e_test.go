@@ -11,7 +11,6 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -59,7 +58,7 @@ func applyMigrations(t *testing.T, db *sql.DB) {
 	if len(files) == 0 {
 		t.Fatalf("no .sql files in %s", dir)
 	}
-	sort.Strings(files)
+	mysqlrepo.SortMigrations(files)
 	for _, f := range files {
 		sqlBytes, err := os.ReadFile(f)
 		if err != nil {
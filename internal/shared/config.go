@@ -1,10 +1,13 @@
 package shared
 
 import (
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"cupid_hotel/internal/domain"
 	"github.com/rs/zerolog/log"
 )
 
@@ -21,6 +24,42 @@ type Config struct {
 	Workers     int
 	ReviewCount int
 	CacheTTL    time.Duration
+
+	// Language registry: SUPPORTED_LANGS="en,fr,es,de", DEFAULT_LANG=en and
+	// LANG_FALLBACK="fr>en,es>fr>en" (the default language always ends a chain).
+	SupportedLangs []string
+	DefaultLang    string
+	LangFallback   map[string][]string
+
+	// Webhook dispatch: WEBHOOK_DISPATCH=false leaves it to other replicas.
+	WebhookDispatch     bool
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookDisableAfter int
+
+	// API keys: API_AUTH=false serves without keys (local development only).
+	// Keys without their own quota get RATE_LIMIT_RPS / RATE_LIMIT_BURST;
+	// RATE_LIMIT_SHARED=true keeps the buckets in Redis so every replica
+	// enforces one quota instead of one each. Requests without a known key
+	// share a bucket per client IP: RATE_LIMIT_CLIENT_RPS / _BURST.
+	APIAuth        bool
+	RateLimitRPS   float64
+	RateLimitBurst int
+	RateLimitRedis bool
+	ClientRPS      float64
+	ClientBurst    int
+
+	// TRUSTED_PROXIES="10.0.0.0/8,192.0.2.7": peers (CIDRs or addresses)
+	// whose X-Forwarded-For names the client for per-client limits. Empty
+	// keys every client on its connection address.
+	TrustedProxies []netip.Prefix
+
+	// Readiness: each dependency check of /readyz gets READY_TIMEOUT_MS.
+	ReadyTimeout time.Duration
+
+	// Shutdown: on SIGINT/SIGTERM requests and hotels in flight get
+	// SHUTDOWN_GRACE_SECONDS to finish before they are cancelled.
+	ShutdownGrace time.Duration
 }
 
 func Load() Config {
@@ -32,6 +71,14 @@ func Load() Config {
 		}
 		return def
 	}
+	atof := func(k string, def float64) float64 {
+		if v := os.Getenv(k); v != "" {
+			if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+				return f
+			}
+		}
+		return def
+	}
 	c := Config{
 		AppEnv:      env("APP_ENV", "prod"),
 		HTTPAddr:    env("HTTP_ADDR", ":8080"),
@@ -44,6 +91,26 @@ func Load() Config {
 		Workers:     atoi("INGEST_WORKERS", 8),
 		ReviewCount: atoi("INGEST_REVIEW_COUNT", 100),
 		CacheTTL:    time.Duration(atoi("CACHE_TTL_SECONDS", 900)) * time.Second,
+
+		SupportedLangs: parseList(env("SUPPORTED_LANGS", "en,fr,es")),
+		DefaultLang:    strings.ToLower(env("DEFAULT_LANG", "en")),
+		LangFallback:   parseFallback(env("LANG_FALLBACK", "")),
+
+		WebhookDispatch:     env("WEBHOOK_DISPATCH", "true") != "false",
+		WebhookTimeout:      time.Duration(atoi("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+		WebhookMaxAttempts:  atoi("WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookDisableAfter: atoi("WEBHOOK_DISABLE_AFTER", 20),
+
+		APIAuth:        env("API_AUTH", "true") != "false",
+		RateLimitRPS:   atof("RATE_LIMIT_RPS", 10),
+		RateLimitBurst: atoi("RATE_LIMIT_BURST", 20),
+		RateLimitRedis: env("RATE_LIMIT_SHARED", "false") == "true",
+		ClientRPS:      atof("RATE_LIMIT_CLIENT_RPS", 1),
+		ClientBurst:    atoi("RATE_LIMIT_CLIENT_BURST", 10),
+		TrustedProxies: parsePrefixes(env("TRUSTED_PROXIES", "")),
+
+		ReadyTimeout:  time.Duration(atoi("READY_TIMEOUT_MS", 1000)) * time.Millisecond,
+		ShutdownGrace: time.Duration(atoi("SHUTDOWN_GRACE_SECONDS", 20)) * time.Second,
 	}
 	if c.CupidKey == "" {
 		log.Warn().Msg("CUPID_API_KEY is empty")
@@ -51,6 +118,80 @@ func Load() Config {
 	return c
 }
 
+// Languages builds the language registry shared by ingestion, cache
+// invalidation, request validation and the served spec.
+func (c Config) Languages() domain.Languages {
+	l := domain.Languages{Supported: c.SupportedLangs, Default: c.DefaultLang, Fallback: map[string][]string{}}
+	if len(l.Supported) == 0 {
+		l.Supported = domain.DefaultLanguages().Supported
+	}
+	if !l.IsSupported(l.Default) {
+		log.Warn().Str("default", l.Default).Strs("supported", l.Supported).Msg("DEFAULT_LANG is not in SUPPORTED_LANGS; using the first one")
+		l.Default = l.Supported[0]
+	}
+	for lang, chain := range c.LangFallback {
+		var ok []string
+		for _, fb := range chain {
+			if l.IsSupported(fb) {
+				ok = append(ok, fb)
+			} else {
+				log.Warn().Str("lang", lang).Str("fallback", fb).Msg("LANG_FALLBACK names an unsupported language; skipped")
+			}
+		}
+		l.Fallback[lang] = ok
+	}
+	return l
+}
+
+// parseList splits "a, B,c" into lowercase, de-duplicated items.
+func parseList(v string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, t := range strings.Split(v, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" && !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// parsePrefixes parses "10.0.0.0/8, 192.0.2.7" into prefixes; a bare address
+// is a single-host prefix. Invalid entries are logged and skipped.
+func parsePrefixes(v string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, t := range strings.Split(v, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if a, err := netip.ParseAddr(t); err == nil {
+			out = append(out, netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen()))
+		} else if p, err := netip.ParsePrefix(t); err == nil {
+			out = append(out, p.Masked())
+		} else {
+			log.Warn().Str("entry", t).Msg("TRUSTED_PROXIES entry is not an address or CIDR; skipped")
+		}
+	}
+	return out
+}
+
+// parseFallback parses "fr>en,es>fr>en" into {fr: [en], es: [fr, en]}.
+func parseFallback(v string) map[string][]string {
+	out := map[string][]string{}
+	for _, chain := range strings.Split(v, ",") {
+		var tags []string
+		for _, t := range strings.Split(chain, ">") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				tags = append(tags, t)
+			}
+		}
+		if len(tags) > 1 {
+			out[tags[0]] = tags[1:]
+		}
+	}
+	return out
+}
+
 func env(k, def string) string {
 	if v := os.Getenv(k); v != "" {
 		return v
@@ -0,0 +1,59 @@
+package mysql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"cupid_hotel/internal/domain"
+)
+
+// Cursors are opaque to clients: a small JSON struct, base64url-encoded.
+// Keeping the shape private lets us change keyset columns without breaking callers.
+
+func encodeCursor(v any) *string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	s := base64.RawURLEncoding.EncodeToString(b)
+	return &s
+}
+
+func decodeCursor(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return domain.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return domain.ErrInvalidCursor
+	}
+	return nil
+}
+
+// hotelsCursor is the keyset position for hotel listings (ordered by id).
+type hotelsCursor struct {
+	ID int64 `json:"id"`
+}
+
+// searchCursor is an offset: relevance scores are floats recomputed per query,
+// so they make a poor keyset.
+type searchCursor struct {
+	Offset int `json:"o"`
+}
+
+// reviewsCursor is the keyset position for review listings. Sort is recorded
+// so a cursor cannot be replayed against a different ordering.
+type reviewsCursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"t,omitempty"`
+	Rating    float64   `json:"r,omitempty"`
+	ID        int64     `json:"id"`
+}
+
+// exportCursor continues a bulk export after ID. Kind ("hotels"/"reviews")
+// keeps a token from being replayed against the other export.
+type exportCursor struct {
+	Kind string `json:"k"`
+	ID   int64  `json:"id"`
+}
@@ -0,0 +1,18 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cupid_hotel/internal/domain"
+)
+
+// Crafted cursors are rejected before any SQL runs (the Repo has no DB).
+func TestSearchHotels_RejectsNegativeOffset(t *testing.T) {
+	cur := encodeCursor(searchCursor{Offset: -10})
+	_, err := (&Repo{}).SearchHotels(context.Background(), domain.SearchQuery{Q: "spa", Lang: "en", Limit: 10, Cursor: cur})
+	if !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Fatalf("got %v, want ErrInvalidCursor", err)
+	}
+}
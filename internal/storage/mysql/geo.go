@@ -0,0 +1,79 @@
+package mysql
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"cupid_hotel/internal/domain"
+)
+
+// kmPerDegree is the length of one degree of latitude (and of longitude at the equator).
+const kmPerDegree = 111.32
+
+// MySQL's SRID 4326 uses latitude-longitude axis order in WKT.
+
+func pointWKT(lat, lon float64) string {
+	return "POINT(" + fmtCoord(lat) + " " + fmtCoord(lon) + ")"
+}
+
+// geoWKT returns the value stored in properties.geo; POINT(0 0) stands in for
+// missing or out-of-range coordinates because the column is NOT NULL.
+func geoWKT(lat, lon *float64) string {
+	if lat == nil || lon == nil || math.Abs(*lat) > 90 || math.Abs(*lon) > 180 {
+		return "POINT(0 0)"
+	}
+	return pointWKT(*lat, *lon)
+}
+
+func bboxWKT(b domain.BBox) string {
+	c := func(lat, lon float64) string { return fmtCoord(lat) + " " + fmtCoord(lon) }
+	return "POLYGON((" +
+		c(b.MinLat, b.MinLon) + "," +
+		c(b.MinLat, b.MaxLon) + "," +
+		c(b.MaxLat, b.MaxLon) + "," +
+		c(b.MaxLat, b.MinLon) + "," +
+		c(b.MinLat, b.MinLon) + "))"
+}
+
+// radiusBBoxes approximates the boxes enclosing a circle: one, or two when
+// the circle crosses the antimeridian. They are only a prefilter for the
+// spatial index, the exact distance check happens in SQL.
+func radiusBBoxes(lat, lon, km float64) []domain.BBox {
+	dLat := km / kmPerDegree
+	minLat, maxLat := math.Max(lat-dLat, -89.999), math.Min(lat+dLat, 89.999)
+	dLon := 180.0
+	if c := math.Cos(lat * math.Pi / 180); c > 1e-6 && lat+dLat < 90 && lat-dLat > -90 {
+		dLon = km / (kmPerDegree * c)
+	}
+	if dLon >= 180 { // every longitude, e.g. around a pole
+		return []domain.BBox{{MinLat: minLat, MaxLat: maxLat, MinLon: -180, MaxLon: 180}}
+	}
+	minLon, maxLon := lon-dLon, lon+dLon
+	switch {
+	case minLon < -180:
+		return []domain.BBox{
+			{MinLat: minLat, MaxLat: maxLat, MinLon: minLon + 360, MaxLon: 180},
+			{MinLat: minLat, MaxLat: maxLat, MinLon: -180, MaxLon: maxLon},
+		}
+	case maxLon > 180:
+		return []domain.BBox{
+			{MinLat: minLat, MaxLat: maxLat, MinLon: minLon, MaxLon: 180},
+			{MinLat: minLat, MaxLat: maxLat, MinLon: -180, MaxLon: maxLon - 360},
+		}
+	}
+	return []domain.BBox{{MinLat: minLat, MaxLat: maxLat, MinLon: minLon, MaxLon: maxLon}}
+}
+
+// withinSQL is the spatial prefilter for boxes, with their WKT as arguments.
+func withinSQL(boxes []domain.BBox) (string, []any) {
+	preds := make([]string, len(boxes))
+	args := make([]any, len(boxes))
+	for i, b := range boxes {
+		preds[i] = "MBRContains(ST_PolygonFromText(?, 4326), p.geo)"
+		args[i] = bboxWKT(b)
+	}
+	return "(" + strings.Join(preds, " OR ") + ")", args
+}
+
+func fmtCoord(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"testing"
+
+	"cupid_hotel/internal/domain"
+)
+
+func TestRadiusBBoxes_WrapsTheAntimeridian(t *testing.T) {
+	inside := func(boxes []domain.BBox, lat, lon float64) bool {
+		for _, b := range boxes {
+			if lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon {
+				return true
+			}
+		}
+		return false
+	}
+	cases := []struct {
+		name     string
+		lat, lon float64
+		n        int
+		in, out  [][2]float64
+	}{
+		{"one box", 48.85, 2.35, 1, [][2]float64{{48.9, 2.4}}, [][2]float64{{48.9, 3.5}}},
+		// Fiji, 50 km: Taveuni side of 180 and the other
+		{"east of the line", -16.8, 179.9, 2, [][2]float64{{-16.8, 179.95}, {-16.8, -179.8}}, [][2]float64{{-16.8, -178}, {-16.8, 179}}},
+		{"west of the line", -16.8, -179.9, 2, [][2]float64{{-16.8, 179.8}, {-16.8, -179.95}}, [][2]float64{{-16.8, 178}}},
+		{"around the pole", 89.9, 10, 1, [][2]float64{{89.95, -170}}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			boxes := radiusBBoxes(tc.lat, tc.lon, 50)
+			if len(boxes) != tc.n {
+				t.Fatalf("got %d boxes %+v, want %d", len(boxes), boxes, tc.n)
+			}
+			for _, p := range tc.in {
+				if !inside(boxes, p[0], p[1]) {
+					t.Errorf("%v not covered by %+v", p, boxes)
+				}
+			}
+			for _, p := range tc.out {
+				if inside(boxes, p[0], p[1]) {
+					t.Errorf("%v covered by %+v", p, boxes)
+				}
+			}
+			for _, b := range boxes {
+				if b.MinLon < -180 || b.MaxLon > 180 || b.MinLon > b.MaxLon {
+					t.Errorf("box out of range: %+v", b)
+				}
+			}
+		})
+	}
+}
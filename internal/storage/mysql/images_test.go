@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"cupid_hotel/internal/domain"
+)
+
+func TestStorableImages_FitsColumns(t *testing.T) {
+	caption := strings.Repeat("é", imageCaptionMax+5)
+	category := strings.Repeat("x", imageCategoryMax+1)
+	imgs := []domain.HotelImage{
+		{URL: "https://img/1.jpg", Caption: &caption, Category: &category, SortOrder: 0},
+		{URL: "https://img/" + strings.Repeat("a", imageURLMax), SortOrder: 1},
+		{URL: "https://img/3.jpg", SortOrder: 2},
+	}
+	got := storableImages(imgs)
+	if len(got) != 2 || got[0].SortOrder != 0 || got[1].SortOrder != 2 {
+		t.Fatalf("got %+v, want images 0 and 2", got)
+	}
+	if n := utf8.RuneCountInString(*got[0].Caption); n != imageCaptionMax {
+		t.Fatalf("caption %d chars, want %d", n, imageCaptionMax)
+	}
+	if n := len(*got[0].Category); n != imageCategoryMax {
+		t.Fatalf("category %d chars, want %d", n, imageCategoryMax)
+	}
+	if utf8.RuneCountInString(caption) != imageCaptionMax+5 {
+		t.Fatal("input caption was modified")
+	}
+}
@@ -0,0 +1,162 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cupid_hotel/internal/domain"
+)
+
+func (r *Repo) CreateIngestJob(ctx context.Context, j domain.IngestJob) (err error) {
+	defer classifyErr(&err)
+	ids, _ := json.Marshal(j.PropertyIDs)
+	opts, _ := json.Marshal(j.Options)
+	reports, _ := json.Marshal(nonNilReports(j.Reports))
+	_, err = r.db.ExecContext(ctx, insertIngestJobSQL, j.ID, j.Status, string(ids), string(opts), string(reports), j.CreatedAt)
+	return err
+}
+
+func (r *Repo) GetIngestJob(ctx context.Context, id string) (_ domain.IngestJob, err error) {
+	defer classifyErr(&err)
+	var j domain.IngestJob
+	var ids, opts, reports []byte
+	var jobErr sql.NullString
+	var started, finished, cancelled sql.NullTime
+	err = r.db.QueryRowContext(ctx, ingestJobByIDSQL, id).
+		Scan(&j.ID, &j.Status, &ids, &opts, &reports, &jobErr, &j.CreatedAt, &started, &finished, &cancelled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.IngestJob{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.IngestJob{}, err
+	}
+	if err := json.Unmarshal(ids, &j.PropertyIDs); err != nil {
+		return domain.IngestJob{}, fmt.Errorf("ingest job %s property_ids: %w", id, err)
+	}
+	if opts != nil { // NULL for jobs created before options existed
+		if err := json.Unmarshal(opts, &j.Options); err != nil {
+			return domain.IngestJob{}, fmt.Errorf("ingest job %s options: %w", id, err)
+		}
+	}
+	if j.Reports, err = r.ingestJobReports(ctx, id); err != nil {
+		return domain.IngestJob{}, err
+	}
+	if len(j.Reports) == 0 { // jobs stored before reports got their own table
+		if err := json.Unmarshal(reports, &j.Reports); err != nil {
+			return domain.IngestJob{}, fmt.Errorf("ingest job %s reports: %w", id, err)
+		}
+	}
+	j.Error = jobErr.String
+	if started.Valid {
+		j.StartedAt = &started.Time
+	}
+	if finished.Valid {
+		j.FinishedAt = &finished.Time
+	}
+	if cancelled.Valid {
+		j.CancelRequestedAt = &cancelled.Time
+	}
+	return j, nil
+}
+
+func (r *Repo) ingestJobReports(ctx context.Context, id string) ([]domain.IngestReport, error) {
+	rows, err := r.db.QueryContext(ctx, ingestJobReportsSQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.IngestReport
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var rep domain.IngestReport
+		if err := json.Unmarshal(raw, &rep); err != nil {
+			return nil, fmt.Errorf("ingest job %s report: %w", id, err)
+		}
+		out = append(out, rep)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repo) UpdateIngestJob(ctx context.Context, j domain.IngestJob) (err error) {
+	defer classifyErr(&err)
+	var jobErr any
+	if j.Error != "" {
+		jobErr = j.Error
+	}
+	_, err = r.db.ExecContext(ctx, updateIngestJobSQL, j.Status, jobErr, j.StartedAt, j.FinishedAt, j.ID)
+	return err
+}
+
+// AddIngestJobReports stores reps in one bulk insert.
+func (r *Repo) AddIngestJobReports(ctx context.Context, id string, reps []domain.IngestReport) (err error) {
+	defer classifyErr(&err)
+	if len(reps) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(reps))
+	args := make([]any, 0, len(reps)*3)
+	for _, rep := range reps {
+		raw, err := json.Marshal(rep)
+		if err != nil {
+			return err
+		}
+		values = append(values, "(?, ?, ?)")
+		args = append(args, id, rep.PropertyID, string(raw))
+	}
+	_, err = r.db.ExecContext(ctx, insertIngestJobReportsPrefix+strings.Join(values, ",")+insertIngestJobReportsOnDup, args...)
+	return err
+}
+
+// InterruptStaleIngestJobs ends the unfinished jobs without a heartbeat for
+// staleAfter; the clock is the database's, so replicas need not agree on time.
+func (r *Repo) InterruptStaleIngestJobs(ctx context.Context, staleAfter time.Duration) (_ int64, err error) {
+	defer classifyErr(&err)
+	res, err := r.db.ExecContext(ctx, interruptStaleIngestJobsSQL,
+		domain.JobInterrupted, "the replica running the job stopped reporting progress",
+		domain.JobQueued, domain.JobRunning, int64(staleAfter.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *Repo) CancelIngestJob(ctx context.Context, id string) (err error) {
+	defer classifyErr(&err)
+	res, err := r.db.ExecContext(ctx, cancelIngestJobSQL, id)
+	if err != nil {
+		return err
+	}
+	// A repeat rewrites the same value (0 affected rows); tell that apart
+	// from an unknown id.
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := r.IngestJobCancelRequested(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repo) IngestJobCancelRequested(ctx context.Context, id string) (_ bool, err error) {
+	defer classifyErr(&err)
+	var requested bool
+	err = r.db.QueryRowContext(ctx, ingestJobCancelRequestedSQL, id).Scan(&requested)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, domain.ErrNotFound
+	}
+	return requested, err
+}
+
+func nonNilReports(rs []domain.IngestReport) []domain.IngestReport {
+	if rs == nil {
+		return []domain.IngestReport{}
+	}
+	return rs
+}
@@ -0,0 +1,125 @@
+package mysql
+
+import (
+	"cmp"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	mysqldrv "github.com/go-sql-driver/mysql"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// firstRecorded is the first migration that records its number in
+// schema_migrations (0016_schema_migrations.sql); every later one does too.
+const firstRecorded = 16
+
+// recordedVersions are the numbers, ascending, of the migrations shipped with
+// this binary that record themselves in schema_migrations.
+var recordedVersions = func() []int {
+	ents, err := migrations.ReadDir("migrations")
+	if err != nil {
+		panic(err)
+	}
+	var vs []int
+	for _, e := range ents {
+		if n, err := migrationNumber(e.Name()); err == nil && n >= firstRecorded {
+			vs = append(vs, n)
+		}
+	}
+	slices.Sort(vs)
+	return vs
+}()
+
+// SchemaVersion is the number of the newest migration shipped with this binary.
+var SchemaVersion = recordedVersions[len(recordedVersions)-1]
+
+// migrationNumber parses the 16 of "0016_schema_migrations.sql".
+func migrationNumber(name string) (int, error) {
+	prefix, _, ok := strings.Cut(name, "_")
+	if !ok {
+		return 0, fmt.Errorf("migration %q has no number prefix", name)
+	}
+	return strconv.Atoi(prefix)
+}
+
+// SortMigrations orders migration files by number, so 0010_versions.sql runs
+// after 0009_deactivation.sql. Names without
+// a number prefix go last, by name.
+func SortMigrations(paths []string) {
+	slices.SortStableFunc(paths, func(a, b string) int {
+		na, errA := migrationNumber(filepath.Base(a))
+		nb, errB := migrationNumber(filepath.Base(b))
+		switch {
+		case errA == nil && errB == nil:
+			return cmp.Compare(na, nb)
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	})
+}
+
+// Ping checks that a pooled connection answers.
+func (r *Repo) Ping(ctx context.Context) (err error) {
+	defer classifyErr(&err)
+	return r.db.PingContext(ctx)
+}
+
+// CheckSchema returns the applied schema version, failing when any migration
+// shipped with the binary is not recorded: the highest version alone would
+// pass a schema where a run skipped or failed an earlier file. A newer schema
+// passes: migrations are additive, so replicas of the previous release keep
+// working while a rollout finishes.
+func (r *Repo) CheckSchema(ctx context.Context) (_ int, err error) {
+	defer classifyErr(&err)
+	rows, err := r.db.QueryContext(ctx, schemaVersionsSQL)
+	var me *mysqldrv.MySQLError
+	if errors.As(err, &me) && me.Number == 1146 { // no such table
+		return 0, fmt.Errorf("schema_migrations is missing; apply migrations up to %d", SchemaVersion)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var applied []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return 0, err
+		}
+		applied = append(applied, v)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	v := 0
+	if len(applied) > 0 {
+		v = slices.Max(applied)
+	}
+	if missing := missingVersions(applied); len(missing) > 0 {
+		return v, fmt.Errorf("schema version %d, binary expects %d; migrations %v not applied", v, SchemaVersion, missing)
+	}
+	return v, nil
+}
+
+// missingVersions returns the shipped migrations absent from applied.
+func missingVersions(applied []int) []int {
+	var missing []int
+	for _, n := range recordedVersions {
+		if !slices.Contains(applied, n) {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
@@ -1,4 +1,4 @@
--- 2_indexes.sql — idempotent index & generated column setup (MySQL 8.0 safe)
+-- 0002_indexes.sql — idempotent index & generated column setup (MySQL 8.0 safe)
 
 -- FULLTEXT on property_i18n(name, description)
 SET @exists := (
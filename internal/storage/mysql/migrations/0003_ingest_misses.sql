@@ -1,4 +1,4 @@
--- 3_ingest_misses.sql — track failed fetches per property/reason
+-- 0003_ingest_misses.sql — track failed fetches per property/reason
 
 CREATE TABLE IF NOT EXISTS ingest_misses (
     id          BIGINT       NOT NULL,                 -- property id that failed
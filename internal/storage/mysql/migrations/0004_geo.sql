@@ -0,0 +1,53 @@
+-- 0004_geo.sql — spatial POINT column + SPATIAL index for radius / bbox search (MySQL 8.0 safe)
+-- geo uses SRID 4326, whose WKT axis order in MySQL is "POINT(lat lon)".
+-- Rows without coordinates hold POINT(0 0); queries also require lat/lon NOT NULL.
+
+-- Add geo as NULLable first so existing rows can be backfilled
+SET @col_exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'properties'
+    AND COLUMN_NAME  = 'geo'
+);
+SET @sql := IF(
+  @col_exists = 0,
+  'ALTER TABLE properties ADD COLUMN geo POINT SRID 4326 NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+-- Backfill from lat/lon; like geoWKT, missing or out-of-range coordinates get POINT(0 0)
+UPDATE properties
+SET geo = ST_PointFromText(
+  IF(lat IS NULL OR lon IS NULL OR ABS(lat) > 90 OR ABS(lon) > 180,
+     'POINT(0 0)', CONCAT('POINT(', lat, ' ', lon, ')')),
+  4326)
+WHERE geo IS NULL;
+
+-- SPATIAL indexes require NOT NULL
+SET @nullable := (
+  SELECT IS_NULLABLE FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'properties'
+    AND COLUMN_NAME  = 'geo'
+);
+SET @sql := IF(
+  @nullable = 'YES',
+  'ALTER TABLE properties MODIFY COLUMN geo POINT NOT NULL SRID 4326',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+-- SPATIAL index on geo
+SET @idx_exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'properties'
+    AND INDEX_NAME   = 'spx_properties_geo'
+);
+SET @sql := IF(
+  @idx_exists = 0,
+  'ALTER TABLE properties ADD SPATIAL INDEX spx_properties_geo (geo)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
@@ -0,0 +1,43 @@
+-- 0005_facet_indexes.sql — indexes backing list filters and facet GROUP BYs (idempotent)
+
+-- (country, city): country filter/facet and city-within-country
+SET @exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'properties'
+    AND INDEX_NAME   = 'idx_properties_country_city'
+);
+SET @sql := IF(
+  @exists = 0,
+  'ALTER TABLE properties ADD INDEX idx_properties_country_city (country, city)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+-- city alone (city filter without country)
+SET @exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'properties'
+    AND INDEX_NAME   = 'idx_properties_city'
+);
+SET @sql := IF(
+  @exists = 0,
+  'ALTER TABLE properties ADD INDEX idx_properties_city (city)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+-- stars
+SET @exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'properties'
+    AND INDEX_NAME   = 'idx_properties_stars'
+);
+SET @sql := IF(
+  @exists = 0,
+  'ALTER TABLE properties ADD INDEX idx_properties_stars (stars)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
@@ -0,0 +1,59 @@
+-- 0006_amenities.sql — canonical amenity taxonomy, localized labels, and the
+-- property <-> amenity join table (idempotent). Codes mirror the alias table in
+-- internal/app/amenities.go; properties are linked on the next ingestion run.
+
+CREATE TABLE IF NOT EXISTS amenities (
+    code        VARCHAR(32)   NOT NULL,
+    sort_order  INT           NOT NULL DEFAULT 0,
+    PRIMARY KEY (code)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS amenity_i18n (
+    code   VARCHAR(32)   NOT NULL,
+    lang   VARCHAR(10)   NOT NULL,
+    label  VARCHAR(128)  NOT NULL,
+    PRIMARY KEY (code, lang),
+    CONSTRAINT fk_amenity_i18n_code FOREIGN KEY (code)
+    REFERENCES amenities(code) ON DELETE CASCADE
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- (code, property_id) serves amenity filters; the PK serves per-hotel reads.
+CREATE TABLE IF NOT EXISTS property_amenities (
+    property_id  BIGINT       NOT NULL,
+    code         VARCHAR(32)  NOT NULL,
+    PRIMARY KEY (property_id, code),
+    KEY idx_property_amenities_code (code, property_id),
+    CONSTRAINT fk_property_amenities_property FOREIGN KEY (property_id)
+    REFERENCES properties(id) ON DELETE CASCADE,
+    CONSTRAINT fk_property_amenities_code FOREIGN KEY (code)
+    REFERENCES amenities(code) ON DELETE CASCADE
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO amenities (code, sort_order) VALUES
+  ('wifi', 10), ('pool', 20), ('spa', 30), ('parking', 40), ('pet_friendly', 50),
+  ('accessible', 60), ('gym', 70), ('restaurant', 80), ('bar', 90),
+  ('air_conditioning', 100), ('breakfast', 110), ('airport_shuttle', 120),
+  ('room_service', 130), ('front_desk_24h', 140), ('laundry', 150),
+  ('business_center', 160), ('family_rooms', 170), ('non_smoking', 180)
+ON DUPLICATE KEY UPDATE sort_order = VALUES(sort_order);
+
+INSERT INTO amenity_i18n (code, lang, label) VALUES
+  ('wifi', 'en', 'Wi-Fi'),                      ('wifi', 'fr', 'Wi-Fi'),                         ('wifi', 'es', 'Wifi'),
+  ('pool', 'en', 'Swimming pool'),              ('pool', 'fr', 'Piscine'),                       ('pool', 'es', 'Piscina'),
+  ('spa', 'en', 'Spa'),                         ('spa', 'fr', 'Spa'),                            ('spa', 'es', 'Spa'),
+  ('parking', 'en', 'Parking'),                 ('parking', 'fr', 'Parking'),                    ('parking', 'es', 'Aparcamiento'),
+  ('pet_friendly', 'en', 'Pets allowed'),       ('pet_friendly', 'fr', 'Animaux acceptés'),      ('pet_friendly', 'es', 'Se admiten mascotas'),
+  ('accessible', 'en', 'Wheelchair accessible'),('accessible', 'fr', 'Accès fauteuil roulant'),  ('accessible', 'es', 'Accesible en silla de ruedas'),
+  ('gym', 'en', 'Fitness centre'),              ('gym', 'fr', 'Salle de sport'),                 ('gym', 'es', 'Gimnasio'),
+  ('restaurant', 'en', 'Restaurant'),           ('restaurant', 'fr', 'Restaurant'),              ('restaurant', 'es', 'Restaurante'),
+  ('bar', 'en', 'Bar'),                         ('bar', 'fr', 'Bar'),                            ('bar', 'es', 'Bar'),
+  ('air_conditioning', 'en', 'Air conditioning'),('air_conditioning', 'fr', 'Climatisation'),   ('air_conditioning', 'es', 'Aire acondicionado'),
+  ('breakfast', 'en', 'Breakfast'),             ('breakfast', 'fr', 'Petit-déjeuner'),           ('breakfast', 'es', 'Desayuno'),
+  ('airport_shuttle', 'en', 'Airport shuttle'), ('airport_shuttle', 'fr', 'Navette aéroport'),   ('airport_shuttle', 'es', 'Traslado al aeropuerto'),
+  ('room_service', 'en', 'Room service'),       ('room_service', 'fr', 'Service en chambre'),    ('room_service', 'es', 'Servicio de habitaciones'),
+  ('front_desk_24h', 'en', '24-hour front desk'),('front_desk_24h', 'fr', 'Réception 24h/24'),  ('front_desk_24h', 'es', 'Recepción 24 horas'),
+  ('laundry', 'en', 'Laundry'),                 ('laundry', 'fr', 'Blanchisserie'),              ('laundry', 'es', 'Lavandería'),
+  ('business_center', 'en', 'Business centre'), ('business_center', 'fr', 'Centre d''affaires'), ('business_center', 'es', 'Centro de negocios'),
+  ('family_rooms', 'en', 'Family rooms'),       ('family_rooms', 'fr', 'Chambres familiales'),   ('family_rooms', 'es', 'Habitaciones familiares'),
+  ('non_smoking', 'en', 'Non-smoking'),         ('non_smoking', 'fr', 'Non-fumeurs'),            ('non_smoking', 'es', 'No fumadores')
+ON DUPLICATE KEY UPDATE label = VALUES(label);
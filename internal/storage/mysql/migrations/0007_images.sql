@@ -0,0 +1,16 @@
+-- 0007_images.sql — structured hotel photos (replaced wholesale on each property upsert)
+
+CREATE TABLE IF NOT EXISTS property_images (
+    property_id  BIGINT        NOT NULL,
+    sort_order   INT           NOT NULL,                        -- provider order, 0-based
+    url          VARCHAR(1024) NOT NULL,
+    caption      VARCHAR(512)  NULL,
+    category     VARCHAR(64)   NULL,                            -- room, exterior, lobby, ...
+    width        INT           NULL,
+    height       INT           NULL,
+    is_primary   TINYINT(1)    NOT NULL DEFAULT 0,
+    PRIMARY KEY (property_id, sort_order),
+    KEY idx_property_images_primary (property_id, is_primary, sort_order),
+    CONSTRAINT fk_property_images_property FOREIGN KEY (property_id)
+    REFERENCES properties(id) ON DELETE CASCADE
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,30 @@
+-- 0008_brands.sql — hotel chains (brands), keyed by the provider's chain id (idempotent)
+
+CREATE TABLE IF NOT EXISTS brands (
+    id          BIGINT        NOT NULL,                         -- Cupid chain id (properties.brand_id)
+    name        VARCHAR(255)  NULL,
+    created_at  TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Backfill from already ingested payloads (chain name lives in raw.chain).
+INSERT IGNORE INTO brands (id, name)
+SELECT p.brand_id, MAX(NULLIF(JSON_UNQUOTE(JSON_EXTRACT(p.raw, '$.chain')), 'null'))
+FROM properties p
+WHERE p.brand_id IS NOT NULL AND p.brand_id > 0
+GROUP BY p.brand_id;
+
+-- Index on properties.brand_id (brand listings and the brand_id filter)
+SET @exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'properties'
+    AND INDEX_NAME   = 'idx_properties_brand'
+);
+SET @sql := IF(
+  @exists = 0,
+  'ALTER TABLE properties ADD INDEX idx_properties_brand (brand_id, id)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
@@ -0,0 +1,16 @@
+-- 0009_deactivation.sql — soft-delete marker for properties Cupid no longer serves (idempotent)
+-- Set by the ingestor on a 404/403 from Cupid, cleared by the next successful upsert.
+-- Reads answer 410 Gone for these hotels and listings skip them.
+
+SET @col_exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'properties'
+    AND COLUMN_NAME  = 'deactivated_at'
+);
+SET @sql := IF(
+  @col_exists = 0,
+  'ALTER TABLE properties ADD COLUMN deactivated_at TIMESTAMP NULL DEFAULT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
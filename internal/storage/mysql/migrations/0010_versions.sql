@@ -0,0 +1,19 @@
+-- 0010_versions.sql — content hash of the last ingested property payload (idempotent)
+-- properties.updated_at now only moves when this hash changes, so it can back
+-- Last-Modified; ETags are derived from the hash and the translation timestamps.
+-- Runners apply files in name order (the compose initdb directory, make
+-- migrate, the integration tests); the zero-padded prefix makes that the
+-- numeric order, so this runs after 0009_deactivation.sql.
+
+SET @col_exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'properties'
+    AND COLUMN_NAME  = 'content_hash'
+);
+SET @sql := IF(
+  @col_exists = 0,
+  'ALTER TABLE properties ADD COLUMN content_hash CHAR(40) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
@@ -0,0 +1,24 @@
+-- 0011_changes.sql — change feed for downstream mirrors (idempotent)
+-- One row per committed write that changed something: a property created,
+-- updated or deactivated, a translation created or updated, or a hotel's
+-- reviews updated. Rows are written in the same transaction as the change.
+
+CREATE TABLE IF NOT EXISTS changes (
+    seq          BIGINT        NOT NULL,                         -- feed token; commit order
+    entity       VARCHAR(16)   NOT NULL,                         -- property | translation | reviews
+    op           VARCHAR(16)   NOT NULL,                         -- created | updated | deactivated
+    property_id  BIGINT        NOT NULL,
+    lang         VARCHAR(16)   NULL,                             -- translations only
+    changed_at   TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (seq)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Single-row sequence. Writers bump it last in their transaction and hold its
+-- row lock until commit, so sequence numbers become visible in order.
+CREATE TABLE IF NOT EXISTS change_seq (
+    id   TINYINT  NOT NULL,
+    seq  BIGINT   NOT NULL,
+    PRIMARY KEY (id)
+    ) ENGINE=InnoDB;
+
+INSERT IGNORE INTO change_seq (id, seq) VALUES (1, 0);
@@ -0,0 +1,60 @@
+-- 0012_webhooks.sql — webhook subscriptions, event outbox and delivery log (idempotent)
+-- Ingestion writes outbox events in the transaction of the change that
+-- emitted them; the dispatcher fans them out into one delivery per matching
+-- subscription and logs every POST attempt.
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id                    BIGINT         NOT NULL AUTO_INCREMENT,
+    url                   VARCHAR(2048)  NOT NULL,
+    secret                VARCHAR(255)   NOT NULL,               -- HMAC key; needed in clear to sign
+    events                JSON           NOT NULL,               -- ["hotel.updated", ...]
+    consecutive_failures  INT            NOT NULL DEFAULT 0,     -- failed attempts since the last success
+    disabled_at           TIMESTAMP      NULL,
+    disabled_reason       VARCHAR(255)   NULL,
+    created_at            TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    id             BIGINT        NOT NULL AUTO_INCREMENT,
+    event_type     VARCHAR(32)   NOT NULL,
+    property_id    BIGINT        NOT NULL,
+    lang           VARCHAR(16)   NULL,                           -- translation changes only
+    created_at     TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at  TIMESTAMP     NULL,                           -- set once fanned out into deliveries
+    PRIMARY KEY (id),
+    KEY idx_outbox_pending (dispatched_at, id)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               BIGINT        NOT NULL AUTO_INCREMENT,
+    subscription_id  BIGINT        NOT NULL,
+    event_id         BIGINT        NOT NULL,
+    status           VARCHAR(16)   NOT NULL DEFAULT 'pending',   -- pending | delivered | failed
+    attempts         INT           NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMP(3)  NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    delivered_at     TIMESTAMP     NULL,
+    created_at       TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_delivery (subscription_id, event_id),
+    KEY idx_delivery_due (status, next_attempt_at),
+    CONSTRAINT fk_delivery_subscription FOREIGN KEY (subscription_id)
+    REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_delivery_event FOREIGN KEY (event_id)
+    REFERENCES webhook_outbox(id) ON DELETE CASCADE
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id               BIGINT         NOT NULL AUTO_INCREMENT,
+    delivery_id      BIGINT         NOT NULL,
+    subscription_id  BIGINT         NOT NULL,
+    attempt          INT            NOT NULL,                    -- 1-based, per delivery
+    status_code      INT            NULL,                        -- NULL when no response was received
+    error            VARCHAR(512)   NULL,
+    duration_ms      INT            NOT NULL,
+    attempted_at     TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_attempts_subscription (subscription_id, id),
+    CONSTRAINT fk_attempt_delivery FOREIGN KEY (delivery_id)
+    REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,17 @@
+-- 0013_api_keys.sql — consumer API keys (idempotent)
+-- Only the SHA-256 of a key is stored; keys carry 256 random bits, so a fast
+-- hash is enough and lookups stay a single indexed read.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id            BIGINT        NOT NULL AUTO_INCREMENT,
+    name          VARCHAR(128)  NOT NULL,                        -- consumer, e.g. 'partner-x'
+    prefix        VARCHAR(16)   NOT NULL,                        -- first characters of the key, for display
+    key_hash      CHAR(64)      NOT NULL,                        -- hex SHA-256 of the full key
+    scopes        JSON          NOT NULL,                        -- ["read", "export", "admin"]
+    rate_per_sec  DOUBLE        NULL,                            -- NULL = service default
+    burst         INT           NULL,                            -- NULL = service default
+    created_at    TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at    TIMESTAMP     NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_api_keys_hash (key_hash)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,15 @@
+-- 0014_ingest_jobs.sql — on-demand ingestion jobs (idempotent)
+-- The API replica that accepts a job runs it and rewrites the row as it
+-- progresses, so polls can be answered by any replica.
+
+CREATE TABLE IF NOT EXISTS ingest_jobs (
+    id            CHAR(32)      NOT NULL,                        -- random hex
+    status        VARCHAR(16)   NOT NULL,                        -- queued | running | succeeded | failed
+    property_ids  JSON          NOT NULL,                        -- [1641879, ...]
+    reports       JSON          NOT NULL,                        -- one step-by-step report per finished hotel
+    error         VARCHAR(512)  NULL,                            -- why the job itself failed
+    created_at    TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at    TIMESTAMP     NULL,
+    finished_at   TIMESTAMP     NULL,
+    PRIMARY KEY (id)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
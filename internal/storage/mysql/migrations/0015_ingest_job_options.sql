@@ -0,0 +1,29 @@
+-- 0015_ingest_job_options.sql — batch ingest jobs: options and cancellation (idempotent)
+-- options narrow what each hotel refreshes; cancel_requested_at is set by
+-- DELETE /admin/ingest-jobs/{id} on any replica and polled by the one running it.
+
+SET @col_exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'ingest_jobs'
+    AND COLUMN_NAME  = 'options'
+);
+SET @sql := IF(
+  @col_exists = 0,
+  'ALTER TABLE ingest_jobs ADD COLUMN options JSON NULL AFTER property_ids',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'ingest_jobs'
+    AND COLUMN_NAME  = 'cancel_requested_at'
+);
+SET @sql := IF(
+  @col_exists = 0,
+  'ALTER TABLE ingest_jobs ADD COLUMN cancel_requested_at TIMESTAMP NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
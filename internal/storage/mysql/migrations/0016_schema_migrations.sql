@@ -0,0 +1,12 @@
+-- 0016_schema_migrations.sql — schema version ledger (idempotent)
+-- Every migration from this one on ends by recording its number here; the
+-- API's readiness probe checks that every one shipped in the binary is
+-- recorded.
+
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version     INT        NOT NULL,                             -- the file's number prefix
+    applied_at  TIMESTAMP  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (version)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT IGNORE INTO schema_migrations (version) VALUES (16);
@@ -0,0 +1,31 @@
+-- 0017_ingest_job_progress.sql — ingest job heartbeats and per-hotel reports (idempotent)
+-- heartbeat_at moves with every progress write of the replica running the
+-- job; unfinished jobs whose heartbeat stalls are swept to interrupted.
+-- Reports are one row per finished hotel, so progress writes only add the
+-- new ones; ingest_jobs.reports is kept for jobs stored before this.
+
+SET @col_exists := (
+  SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME   = 'ingest_jobs'
+    AND COLUMN_NAME  = 'heartbeat_at'
+);
+SET @sql := IF(
+  @col_exists = 0,
+  'ALTER TABLE ingest_jobs ADD COLUMN heartbeat_at TIMESTAMP NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS ingest_job_reports (
+    id           BIGINT        NOT NULL AUTO_INCREMENT,          -- finish order
+    job_id       CHAR(32)      NOT NULL,
+    property_id  BIGINT        NOT NULL,
+    report       JSON          NOT NULL,                         -- step-by-step report of one hotel
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_ingest_job_reports (job_id, property_id),
+    CONSTRAINT fk_ingest_job_reports_job FOREIGN KEY (job_id)
+    REFERENCES ingest_jobs(id) ON DELETE CASCADE
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT IGNORE INTO schema_migrations (version) VALUES (17);
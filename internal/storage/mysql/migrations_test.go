@@ -0,0 +1,87 @@
+package mysql
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Readiness compares schema_migrations with SchemaVersion, so every
+// migration from 16 on must record its own number.
+func TestMigrations_RecordTheirVersion(t *testing.T) {
+	ents, err := migrations.ReadDir("migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int]string{}
+	for _, e := range ents {
+		n, err := migrationNumber(e.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev, dup := seen[n]; dup {
+			t.Fatalf("%s and %s share number %d", prev, e.Name(), n)
+		}
+		seen[n] = e.Name()
+		if n < 16 {
+			continue
+		}
+		b, err := migrations.ReadFile("migrations/" + e.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("INSERT IGNORE INTO schema_migrations (version) VALUES (%d);", n); !strings.Contains(string(b), want) {
+			t.Errorf("%s does not record its version (%s)", e.Name(), want)
+		}
+	}
+	if SchemaVersion < 16 {
+		t.Fatalf("SchemaVersion = %d", SchemaVersion)
+	}
+}
+
+func TestSortMigrations_Numeric(t *testing.T) {
+	files := []string{"m/10_versions.sql", "m/1_init.sql", "m/16_schema_migrations.sql", "m/README", "m/2_indexes.sql", "m/9_deactivation.sql"}
+	SortMigrations(files)
+	want := []string{"m/1_init.sql", "m/2_indexes.sql", "m/9_deactivation.sql", "m/10_versions.sql", "m/16_schema_migrations.sql", "m/README"}
+	if !slices.Equal(files, want) {
+		t.Fatalf("got %v, want %v", files, want)
+	}
+}
+
+// The highest version alone is not enough: a gap means a file was skipped.
+func TestMissingVersions(t *testing.T) {
+	if got := missingVersions(recordedVersions); len(got) != 0 {
+		t.Fatalf("all applied: missing %v", got)
+	}
+	if got := missingVersions([]int{SchemaVersion}); !slices.Equal(got, recordedVersions[:len(recordedVersions)-1]) {
+		t.Fatalf("only newest applied: missing %v", got)
+	}
+	if got := missingVersions(nil); !slices.Equal(got, recordedVersions) {
+		t.Fatalf("none applied: missing %v", got)
+	}
+}
+
+// The compose stack hands the directory to the MySQL entrypoint, which runs
+// files in byte order; only a fixed-width prefix makes that numeric.
+func TestMigrations_ByteOrderIsNumeric(t *testing.T) {
+	ents, err := migrations.ReadDir("migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	padded := regexp.MustCompile(`^\d{4}_[a-z0-9_]+\.sql$`)
+	var names []string
+	for _, e := range ents {
+		if !padded.MatchString(e.Name()) {
+			t.Errorf("%s: want a four-digit prefix like 0018_name.sql", e.Name())
+		}
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	numeric := slices.Clone(names)
+	SortMigrations(numeric)
+	if !slices.Equal(names, numeric) {
+		t.Fatalf("byte order %v differs from numeric order %v", names, numeric)
+	}
+}
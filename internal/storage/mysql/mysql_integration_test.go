@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"testing"
 	"time"
 
@@ -56,7 +55,7 @@ func applyMigrations(t *testing.T, db *sql.DB) {
 	if len(files) == 0 {
 		t.Fatalf("no .sql files in %s", dir)
 	}
-	sort.Strings(files)
+	mysqlrepo.SortMigrations(files)
 
 	for _, f := range files {
 		sqlBytes, err := os.ReadFile(f)
@@ -184,6 +183,59 @@ func TestRepo_MySQL_UpsertAndQuery(t *testing.T) {
 		t.Fatalf("unexpected hotel view: %+v", hv)
 	}
 
+	// Change feed: each write logs what it did, in order; a write that
+	// changes nothing logs nothing.
+	var seen int64
+	feed := func(step string, want ...string) {
+		t.Helper()
+		page, err := repo.ListChanges(ctx, seen, 100)
+		if err != nil {
+			t.Fatalf("%s: ListChanges: %v", step, err)
+		}
+		got := []string{}
+		for _, c := range page.Items {
+			e := fmt.Sprintf("%s %s %d", c.Entity, c.Op, c.PropertyID)
+			if c.Lang != nil {
+				e += " " + *c.Lang
+			}
+			got = append(got, e)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("%s: feed %q, want %q", step, got, want)
+		}
+		seen = page.Next
+	}
+	feed("create", "property created 10001", "translation created 10001 fr", "reviews created 10001")
+
+	if err := repo.UpsertProperty(ctx, h); err != nil {
+		t.Fatalf("UpsertProperty again: %v", err)
+	}
+	if err := repo.UpsertI18n(ctx, i18); err != nil {
+		t.Fatalf("UpsertI18n again: %v", err)
+	}
+	if err := repo.UpsertReviews(ctx, []domain.Review{r1, r2}); err != nil {
+		t.Fatalf("UpsertReviews again: %v", err)
+	}
+	feed("no-op")
+
+	h.Stars, h.RawJSON = pint(4), []byte(`{"stars":4}`)
+	if err := repo.UpsertProperty(ctx, h); err != nil {
+		t.Fatalf("UpsertProperty update: %v", err)
+	}
+	r2.Rating = pfloat(8.0)
+	if err := repo.UpsertReviews(ctx, []domain.Review{r2}); err != nil {
+		t.Fatalf("UpsertReviews update: %v", err)
+	}
+	feed("update", "property updated 10001", "reviews updated 10001")
+
+	if err := repo.DeactivateProperty(ctx, 10001); err != nil {
+		t.Fatalf("DeactivateProperty: %v", err)
+	}
+	if err := repo.DeactivateProperty(ctx, 10001); err != nil {
+		t.Fatalf("DeactivateProperty again: %v", err)
+	}
+	feed("deactivate", "property deactivated 10001")
+
 	// Optional: small sleep to let CURRENT_TIMESTAMP settle in container clocks
 	time.Sleep(50 * time.Millisecond)
 }
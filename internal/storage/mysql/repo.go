@@ -2,9 +2,21 @@ package mysql
 
 import (
 	"context"
+	"crypto/sha1"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	mysqldrv "github.com/go-sql-driver/mysql"
 
 	"cupid_hotel/internal/domain"
 )
@@ -44,27 +56,340 @@ type Repo struct{ db *sql.DB }
 
 func New(db *sql.DB) *Repo { return &Repo{db: db} }
 
-func (r *Repo) UpsertProperty(ctx context.Context, h domain.Hotel) error {
+// classifyErr maps connection-level failures (refused, dropped or timed-out
+// connections) to domain.ErrUnavailable so callers can answer 503 rather than
+// 500; every exported method defers it. Other errors pass through.
+func classifyErr(err *error) {
+	e := *err
+	if e == nil || errors.Is(e, domain.ErrUnavailable) {
+		return
+	}
+	var netErr net.Error
+	if errors.Is(e, driver.ErrBadConn) || errors.Is(e, mysqldrv.ErrInvalidConn) ||
+		errors.Is(e, context.DeadlineExceeded) || errors.As(e, &netErr) {
+		*err = fmt.Errorf("%w: %w", domain.ErrUnavailable, e)
+	}
+}
+
+func (r *Repo) UpsertProperty(ctx context.Context, h domain.Hotel) (err error) {
+	defer classifyErr(&err)
 	amen, _ := json.Marshal(h.Amenities)
 	imgs, _ := json.Marshal(h.Images)
-	_, err := r.db.ExecContext(ctx, upsertPropertySQL,
+
+	// Row and amenity links change together so filters never see a half-written hotel.
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	if h.BrandID != nil {
+		if _, err := tx.ExecContext(ctx, upsertBrandSQL, *h.BrandID, valStr(h.BrandName)); err != nil {
+			return err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, upsertPropertySQL,
 		h.ID,
 		valInt64(h.BrandID),
 		valInt(h.Stars),
 		valF64(h.Lat),
 		valF64(h.Lon),
+		geoWKT(h.Lat, h.Lon),
 		valStr(h.Country),
 		valStr(h.City),
 		valStr(h.AddressRaw),
 		string(amen),
 		string(imgs),
 		string(h.RawJSON),
+		contentHash(h.RawJSON),
 	)
+	if err != nil {
+		return err
+	}
+	if err := replacePropertyAmenities(ctx, tx, h.ID, h.AmenityCodes); err != nil {
+		return err
+	}
+	if err := replacePropertyImages(ctx, tx, h.ID, h.Photos); err != nil {
+		return err
+	}
+	if op := upsertOp(res); op != "" {
+		if err := logChanges(ctx, tx, domain.Change{Entity: domain.ChangeProperty, Op: op, PropertyID: h.ID}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// upsertOp classifies an INSERT ... ON DUPLICATE KEY UPDATE of one row by its
+// affected-row count: 1 created, 2 updated, 0 unchanged (""). This relies on
+// the DSN not setting clientFoundRows.
+func upsertOp(res sql.Result) string {
+	n, _ := res.RowsAffected()
+	switch n {
+	case 1:
+		return domain.ChangeCreated
+	case 2:
+		return domain.ChangeUpdated
+	}
+	return ""
+}
+
+// logChanges appends cs to the change feed, and the webhook events they emit
+// to the outbox, inside tx. Call it last: the sequence row stays locked until
+// tx ends, which serializes feed writers so sequence order is commit order.
+func logChanges(ctx context.Context, tx *sql.Tx, cs ...domain.Change) error {
+	for _, c := range cs {
+		if _, err := tx.ExecContext(ctx, insertOutboxSQL, domain.EventFor(c), c.PropertyID, valStr(c.Lang)); err != nil {
+			return err
+		}
+	}
+	res, err := tx.ExecContext(ctx, bumpChangeSeqSQL, len(cs))
+	if err != nil {
+		return err
+	}
+	last, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return errors.New("change_seq is not initialized (see migration 0011_changes.sql)")
+	}
+	for i, c := range cs {
+		seq := last - int64(len(cs)-1-i)
+		if _, err := tx.ExecContext(ctx, insertChangeSQL, seq, c.Entity, c.Op, c.PropertyID, valStr(c.Lang)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contentHash fingerprints an ingested payload.
+func contentHash(b []byte) string {
+	sum := sha1.Sum(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Column sizes of property_images (see migration 0007_images.sql), in characters.
+const (
+	imageURLMax      = 1024
+	imageCaptionMax  = 512
+	imageCategoryMax = 64
+)
+
+// storableImages fits imgs to the property_images columns: captions and
+// categories are cut to size, images whose URL doesn't fit are dropped (a
+// cut URL points nowhere). Sort orders are kept, so they may have gaps.
+func storableImages(imgs []domain.HotelImage) []domain.HotelImage {
+	out := make([]domain.HotelImage, 0, len(imgs))
+	for _, im := range imgs {
+		if utf8.RuneCountInString(im.URL) > imageURLMax {
+			continue
+		}
+		im.Caption = clipRunes(im.Caption, imageCaptionMax)
+		im.Category = clipRunes(im.Category, imageCategoryMax)
+		out = append(out, im)
+	}
+	return out
+}
+
+// clipRunes cuts *p to at most n characters.
+func clipRunes(p *string, n int) *string {
+	if p == nil || utf8.RuneCountInString(*p) <= n {
+		return p
+	}
+	s := string([]rune(*p)[:n])
+	return &s
+}
+
+// replacePropertyImages swaps the hotel's gallery for imgs in one bulk insert.
+func replacePropertyImages(ctx context.Context, tx *sql.Tx, id int64, imgs []domain.HotelImage) error {
+	if _, err := tx.ExecContext(ctx, deletePropertyImagesSQL, id); err != nil {
+		return err
+	}
+	imgs = storableImages(imgs)
+	if len(imgs) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(imgs))
+	args := make([]any, 0, len(imgs)*8)
+	for _, im := range imgs {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, id, im.SortOrder, im.URL, valStr(im.Caption), valStr(im.Category),
+			valInt(im.Width), valInt(im.Height), im.IsPrimary)
+	}
+	_, err := tx.ExecContext(ctx, insertPropertyImagesPrefix+strings.Join(values, ","), args...)
 	return err
 }
 
-func (r *Repo) UpsertI18n(ctx context.Context, i domain.HotelI18n) error {
-	_, err := r.db.ExecContext(ctx, upsertI18nSQL,
+// ListImages returns the hotel's gallery, primary first. Unknown hotels are
+// ErrNotFound (deactivated ones ErrGone); a hotel without photos yields an
+// empty slice.
+func (r *Repo) ListImages(ctx context.Context, id int64) (_ []domain.HotelImage, err error) {
+	defer classifyErr(&err)
+	if err := r.checkProperty(ctx, id); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, listImagesSQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.HotelImage{}
+	for rows.Next() {
+		var im domain.HotelImage
+		var caption, category sql.NullString
+		var width, height sql.NullInt64
+		if err := rows.Scan(&im.URL, &caption, &category, &width, &height, &im.SortOrder, &im.IsPrimary); err != nil {
+			return nil, err
+		}
+		im.Caption, im.Category = nullStr(caption), nullStr(category)
+		if width.Valid {
+			w := int(width.Int64)
+			im.Width = &w
+		}
+		if height.Valid {
+			h := int(height.Int64)
+			im.Height = &h
+		}
+		out = append(out, im)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// checkProperty returns ErrNotFound for unknown ids and ErrGone for
+// deactivated hotels, so per-hotel reads do not answer 200 with empty data.
+func (r *Repo) checkProperty(ctx context.Context, id int64) error {
+	var deactivated bool
+	err := r.db.QueryRowContext(ctx, propertyStateSQL, id).Scan(&deactivated)
+	switch {
+	case err == sql.ErrNoRows:
+		return domain.ErrNotFound
+	case err != nil:
+		return err
+	case deactivated:
+		return domain.ErrGone
+	}
+	return nil
+}
+
+// DeactivateProperty marks a hotel Cupid no longer serves; the next
+// successful UpsertProperty reactivates it.
+func (r *Repo) DeactivateProperty(ctx context.Context, id int64) (err error) {
+	defer classifyErr(&err)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	res, err := tx.ExecContext(ctx, deactivatePropertySQL, id)
+	if err != nil {
+		return err
+	}
+	// Only the first deactivation changes the row.
+	if n, _ := res.RowsAffected(); n == 1 {
+		if err := logChanges(ctx, tx, domain.Change{Entity: domain.ChangeProperty, Op: domain.ChangeDeactivated, PropertyID: id}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// replacePropertyAmenities swaps the hotel's amenity links for codes; codes
+// missing from the taxonomy are skipped rather than failing the upsert.
+func replacePropertyAmenities(ctx context.Context, tx *sql.Tx, id int64, codes []string) error {
+	if _, err := tx.ExecContext(ctx, deletePropertyAmenitiesSQL, id); err != nil {
+		return err
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(codes)+1)
+	args = append(args, id)
+	for _, c := range codes {
+		args = append(args, c)
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(insertPropertyAmenitiesSQL, placeholders(len(codes))), args...)
+	return err
+}
+
+// ListBrands returns every brand with its hotel count, largest first.
+func (r *Repo) ListBrands(ctx context.Context) (_ []domain.Brand, err error) {
+	defer classifyErr(&err)
+	rows, err := r.db.QueryContext(ctx, listBrandsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.Brand{}
+	for rows.Next() {
+		var b domain.Brand
+		var name sql.NullString
+		if err := rows.Scan(&b.ID, &name, &b.HotelCount); err != nil {
+			return nil, err
+		}
+		b.Name = nullStr(name)
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repo) GetBrand(ctx context.Context, id int64) (_ domain.Brand, err error) {
+	defer classifyErr(&err)
+	var b domain.Brand
+	var name sql.NullString
+	err = r.db.QueryRowContext(ctx, getBrandSQL, id).Scan(&b.ID, &name, &b.HotelCount)
+	if err == sql.ErrNoRows {
+		return domain.Brand{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.Brand{}, err
+	}
+	b.Name = nullStr(name)
+	return b, nil
+}
+
+// AmenityTaxonomy returns every canonical amenity with its labels, in display order.
+func (r *Repo) AmenityTaxonomy(ctx context.Context) (_ []domain.AmenityDef, err error) {
+	defer classifyErr(&err)
+	rows, err := r.db.QueryContext(ctx, amenityTaxonomySQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.AmenityDef
+	for rows.Next() {
+		var code string
+		var lang, label sql.NullString
+		if err := rows.Scan(&code, &lang, &label); err != nil {
+			return nil, err
+		}
+		if len(out) == 0 || out[len(out)-1].Code != code {
+			out = append(out, domain.AmenityDef{Code: code, Labels: map[string]string{}})
+		}
+		if lang.Valid && label.Valid {
+			out[len(out)-1].Labels[lang.String] = label.String
+		}
+	}
+	return out, rows.Err()
+}
+
+func (r *Repo) UpsertI18n(ctx context.Context, i domain.HotelI18n) (err error) {
+	defer classifyErr(&err)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	res, err := tx.ExecContext(ctx, upsertI18nSQL,
 		i.PropertyID,
 		i.Lang, // string in your domain
 		i.Name,
@@ -73,10 +398,20 @@ func (r *Repo) UpsertI18n(ctx context.Context, i domain.HotelI18n) error {
 		i.Address,
 		string(i.ExtrasJSON),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if op := upsertOp(res); op != "" {
+		lang := i.Lang
+		if err := logChanges(ctx, tx, domain.Change{Entity: domain.ChangeTranslation, Op: op, PropertyID: i.PropertyID, Lang: &lang}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func (r *Repo) UpsertReviews(ctx context.Context, rs []domain.Review) error {
+func (r *Repo) UpsertReviews(ctx context.Context, rs []domain.Review) (err error) {
+	defer classifyErr(&err)
 	if len(rs) == 0 {
 		return nil
 	}
@@ -102,27 +437,170 @@ func (r *Repo) UpsertReviews(ctx context.Context, rs []domain.Review) error {
 		)
 	}
 	sqlStr := insertReviewsPrefix + strings.Join(values, ",") + insertReviewsOnDup
-	_, err := r.db.ExecContext(ctx, sqlStr, args...)
-	return err
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	var ids []int64
+	seen := map[int64]bool{}
+	for _, rv := range rs {
+		if !seen[rv.PropertyID] {
+			seen[rv.PropertyID] = true
+			ids = append(ids, rv.PropertyID)
+		}
+	}
+	before, err := countReviews(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return err
+	}
+	// One feed entry per hotel of the batch (the ingestor sends one hotel at a
+	// time): "created" when reviews were added, "updated" when only edited.
+	if n, _ := res.RowsAffected(); n > 0 {
+		after, err := countReviews(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+		cs := make([]domain.Change, len(ids))
+		for i, id := range ids {
+			op := domain.ChangeUpdated
+			if after[id] > before[id] {
+				op = domain.ChangeCreated
+			}
+			cs[i] = domain.Change{Entity: domain.ChangeReviews, Op: op, PropertyID: id}
+		}
+		if err := logChanges(ctx, tx, cs...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// countReviews counts the stored reviews of each hotel in ids. Both counts of
+// UpsertReviews read the transaction's snapshot, so their difference is the
+// number of rows it inserted.
+func countReviews(ctx context.Context, tx *sql.Tx, ids []int64) (map[int64]int, error) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(countReviewsSQL, placeholders(len(ids))), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[int64]int, len(ids))
+	for rows.Next() {
+		var id int64
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		out[id] = n
+	}
+	return out, rows.Err()
 }
 
-func (r *Repo) LogMiss(ctx context.Context, id int64, status int, reason string) error {
-	_, err := r.db.ExecContext(ctx, insertMissSQL, id, status, reason)
+// ListChanges pages through the change feed after since.
+func (r *Repo) ListChanges(ctx context.Context, since int64, limit int) (_ domain.ChangesPage, err error) {
+	defer classifyErr(&err)
+	rows, err := r.db.QueryContext(ctx, listChangesSQL, since, limit+1)
+	if err != nil {
+		return domain.ChangesPage{}, err
+	}
+	defer rows.Close()
+
+	page := domain.ChangesPage{Items: []domain.Change{}, Next: since}
+	for rows.Next() {
+		if len(page.Items) == limit {
+			page.HasMore = true
+			break
+		}
+		var c domain.Change
+		var lang sql.NullString
+		if err := rows.Scan(&c.Seq, &c.Entity, &c.Op, &c.PropertyID, &lang, &c.At); err != nil {
+			return domain.ChangesPage{}, err
+		}
+		c.Lang = nullStr(lang)
+		page.Items = append(page.Items, c)
+		page.Next = c.Seq
+	}
+	return page, rows.Err()
+}
+
+func (r *Repo) LogMiss(ctx context.Context, id int64, status int, reason string) (err error) {
+	defer classifyErr(&err)
+	_, err = r.db.ExecContext(ctx, insertMissSQL, id, status, reason)
 	return err
 }
 
-func (r *Repo) GetHotel(ctx context.Context, id int64, lang string) (domain.HotelView, error) {
+func (r *Repo) GetHotel(ctx context.Context, id int64, lang string) (_ domain.HotelView, err error) {
+	defer classifyErr(&err)
 	// Use the shared SELECT with both base and i18n address columns
 	row := r.db.QueryRowContext(ctx, getHotelSQL, lang, id)
+	hv, err := scanHotelView(row, lang)
+	if err == sql.ErrNoRows {
+		return domain.HotelView{}, domain.ErrNotFound
+	}
+	return hv, err
+}
+
+// GetHotels loads many hotels in one IN (...) query; unknown ids are simply absent.
+func (r *Repo) GetHotels(ctx context.Context, ids []int64, lang string) (_ []domain.HotelView, err error) {
+	defer classifyErr(&err)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, lang)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	// Deactivated hotels are reported as unknown in batches.
+	sqlStr := hotelViewSelectSQL + "WHERE p.id IN (" + placeholders(len(ids)) + ") AND p.deactivated_at IS NULL"
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.HotelView, 0, len(ids))
+	for rows.Next() {
+		hv, err := scanHotelView(rows, lang)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, hv)
+	}
+	return out, rows.Err()
+}
 
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanHotelView scans one hotelViewSelectSQL row; deactivated hotels yield ErrGone.
+func scanHotelView(row rowScanner, lang string) (domain.HotelView, error) {
 	var hv domain.HotelView
-	var brandID sql.NullInt64 // present in the SELECT, but not used directly in the view here
+	var brandID sql.NullInt64
+	var brandName sql.NullString
 	var stars sql.NullInt64
 	var lat, lon sql.NullFloat64
 	var country, city sql.NullString
 	var amenitiesJSON, imagesJSON []byte
 	var name, desc, pol sql.NullString
 	var baseAddr, i18nAddr sql.NullString
+	var amenityCodes []byte
+	var mainPhoto sql.NullString
+	var deactivated bool
 
 	if err := row.Scan(
 		&hv.ID,
@@ -134,12 +612,16 @@ func (r *Repo) GetHotel(ctx context.Context, id int64, lang string) (domain.Hote
 		&amenitiesJSON, &imagesJSON,
 		&name, &desc, &pol,
 		&i18nAddr,
+		&amenityCodes,
+		&mainPhoto,
+		&brandName,
+		&deactivated,
 	); err != nil {
-		if err == sql.ErrNoRows {
-			return domain.HotelView{}, domain.ErrNotFound
-		}
 		return domain.HotelView{}, err
 	}
+	if deactivated {
+		return domain.HotelView{}, domain.ErrGone
+	}
 
 	if stars.Valid {
 		s := int(stars.Int64)
@@ -168,6 +650,12 @@ func (r *Repo) GetHotel(ctx context.Context, id int64, lang string) (domain.Hote
 
 	_ = json.Unmarshal(amenitiesJSON, &hv.Amenities)
 	_ = json.Unmarshal(imagesJSON, &hv.Images)
+	var codes []string
+	_ = json.Unmarshal(amenityCodes, &codes)
+	sort.Strings(codes)
+	for _, c := range codes {
+		hv.CanonicalAmenities = append(hv.CanonicalAmenities, domain.Amenity{Code: c}) // labels are added by the app
+	}
 	if name.Valid {
 		ns := name.String
 		hv.Name = &ns
@@ -180,17 +668,44 @@ func (r *Repo) GetHotel(ctx context.Context, id int64, lang string) (domain.Hote
 		ps := pol.String
 		hv.Policies = &ps
 	}
+	hv.MainPhoto = nullStr(mainPhoto)
+	if brandID.Valid && brandID.Int64 > 0 {
+		hv.Brand = &domain.Brand{ID: brandID.Int64, Name: nullStr(brandName)}
+	}
 	hv.Language = lang
 	return hv, nil
 }
 
-func (r *Repo) ListHotels(ctx context.Context, q domain.HotelsQuery) (domain.HotelsPage, error) {
-	rows, err := r.db.QueryContext(ctx, `
-SELECT p.id, p.stars, p.lat, p.lon, p.country, p.city, i.name
-FROM properties p
-LEFT JOIN property_i18n i ON i.property_id = p.id AND i.lang = ?
-ORDER BY p.id
-LIMIT ?`, q.Lang, q.Limit)
+func nullStr(ns sql.NullString) *string {
+	if !ns.Valid {
+		return nil
+	}
+	s := ns.String
+	return &s
+}
+
+func (r *Repo) ListHotels(ctx context.Context, q domain.HotelsQuery) (_ domain.HotelsPage, err error) {
+	defer classifyErr(&err)
+	where, args := hotelsFilter(q)
+	if q.Cursor != nil && *q.Cursor != "" {
+		var c hotelsCursor
+		if err := decodeCursor(*q.Cursor, &c); err != nil {
+			return domain.HotelsPage{}, err
+		}
+		where = append(where, "p.id > ?")
+		args = append(args, c.ID)
+	}
+
+	sqlStr := listHotelsSQL
+	if len(where) > 0 {
+		sqlStr += "WHERE " + strings.Join(where, " AND ") + "\n"
+	}
+	// Fetch one extra row to know whether another page exists.
+	sqlStr += "ORDER BY p.id\nLIMIT ?"
+	args = append([]any{q.Lang}, args...)
+	args = append(args, q.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
 	if err != nil {
 		return domain.HotelsPage{}, err
 	}
@@ -201,10 +716,11 @@ LIMIT ?`, q.Lang, q.Limit)
 		var hv domain.HotelView
 		var stars sql.NullInt64
 		var lat, lon sql.NullFloat64
-		var country, city, name sql.NullString
-		if err := rows.Scan(&hv.ID, &stars, &lat, &lon, &country, &city, &name); err != nil {
+		var country, city, name, mainPhoto sql.NullString
+		if err := rows.Scan(&hv.ID, &stars, &lat, &lon, &country, &city, &name, &mainPhoto); err != nil {
 			return domain.HotelsPage{}, err
 		}
+		hv.MainPhoto = nullStr(mainPhoto)
 		if stars.Valid {
 			s := int(stars.Int64)
 			hv.Stars = &s
@@ -224,107 +740,856 @@ LIMIT ?`, q.Lang, q.Limit)
 			ns := name.String
 			hv.Name = &ns
 		}
+		hv.Language = q.Lang
 		out = append(out, hv)
 	}
-	return domain.HotelsPage{Items: out}, nil
-}
-
-func (r *Repo) ListReviews(ctx context.Context, id int64, pg domain.PageQuery) (domain.ReviewsPage, error) {
-	rows, err := r.db.QueryContext(ctx,
-		`SELECT
-		   id,
-		   property_id,
-		   source_id,
-		   author,
-		   rating,
-		   lang,
-		   title,
-		   text,
-		   aspects,
-		   created_at,
-		   source,
-		   raw
-		 FROM reviews
-		 WHERE property_id=?
-		 ORDER BY created_at DESC, id DESC
-		 LIMIT ?`,
-		id, pg.Limit,
-	)
+	if err := rows.Err(); err != nil {
+		return domain.HotelsPage{}, err
+	}
+
+	page := domain.HotelsPage{Items: out}
+	if len(out) > q.Limit {
+		page.Items = out[:q.Limit]
+		page.NextCursor = encodeCursor(hotelsCursor{ID: page.Items[q.Limit-1].ID})
+	}
+	return page, nil
+}
+
+func (r *Repo) SearchHotels(ctx context.Context, q domain.SearchQuery) (_ domain.SearchPage, err error) {
+	defer classifyErr(&err)
+	var c searchCursor
+	if q.Cursor != nil && *q.Cursor != "" {
+		if err := decodeCursor(*q.Cursor, &c); err != nil {
+			return domain.SearchPage{}, err
+		}
+		if c.Offset < 0 {
+			return domain.SearchPage{}, domain.ErrInvalidCursor
+		}
+	}
+
+	modifier := "IN NATURAL LANGUAGE MODE"
+	if q.Mode == domain.SearchBoolean {
+		modifier = "IN BOOLEAN MODE"
+	}
+	sqlStr := fmt.Sprintf(searchHotelsSQL, modifier)
+	args := []any{q.Q, q.Lang, q.Q}
+
+	where, fargs := hotelsFilter(domain.HotelsQuery{Country: q.Country, City: q.City, Stars: q.Stars})
+	for _, w := range where {
+		sqlStr += "  AND " + w + "\n"
+	}
+	args = append(args, fargs...)
+	sqlStr += "ORDER BY score DESC, p.id\nLIMIT ? OFFSET ?"
+	args = append(args, q.Limit+1, c.Offset)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
 	if err != nil {
-		return domain.ReviewsPage{}, err
+		return domain.SearchPage{}, err
 	}
 	defer rows.Close()
 
-	var out []domain.Review
+	var out []domain.SearchHit
 	for rows.Next() {
-		var rv domain.Review
-		var (
-			sourceID         sql.NullString
-			author           sql.NullString
-			rating           sql.NullFloat64
-			lang             sql.NullString
-			title            sql.NullString
-			text             sql.NullString
-			aspectsRaw, rawB sql.RawBytes
-			createdAt        sql.NullTime
-			source           sql.NullString
-		)
-		if err := rows.Scan(
-			&rv.ID,
-			&rv.PropertyID,
-			&sourceID,
-			&author,
-			&rating,
-			&lang,
-			&title,
-			&text,
-			&aspectsRaw,
-			&createdAt, // ignored if your domain.Review has no CreatedAt field
-			&source,
-			&rawB,
-		); err != nil {
-			return domain.ReviewsPage{}, err
+		var hit domain.SearchHit
+		var stars sql.NullInt64
+		var lat, lon sql.NullFloat64
+		var country, city, name, desc, mainPhoto sql.NullString
+		if err := rows.Scan(&hit.Hotel.ID, &stars, &lat, &lon, &country, &city, &name, &desc, &hit.Score, &mainPhoto); err != nil {
+			return domain.SearchPage{}, err
+		}
+		hv := &hit.Hotel
+		hv.MainPhoto = nullStr(mainPhoto)
+		if stars.Valid {
+			s := int(stars.Int64)
+			hv.Stars = &s
+		}
+		if lat.Valid && lon.Valid {
+			hv.Coords = &domain.Coords{Lat: lat.Float64, Lon: lon.Float64}
+		}
+		if country.Valid {
+			cs := country.String
+			hv.Country = &cs
+		}
+		if city.Valid {
+			cy := city.String
+			hv.City = &cy
+		}
+		if name.Valid {
+			ns := name.String
+			hv.Name = &ns
+		}
+		if desc.Valid {
+			ds := desc.String
+			hv.Description = &ds
+		}
+		hv.Language = q.Lang
+		out = append(out, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return domain.SearchPage{}, err
+	}
+
+	page := domain.SearchPage{Items: out}
+	if len(out) > q.Limit {
+		page.Items = out[:q.Limit]
+		page.NextCursor = encodeCursor(searchCursor{Offset: c.Offset + q.Limit})
+	}
+	return page, nil
+}
+
+func (r *Repo) NearbyHotels(ctx context.Context, q domain.NearbyQuery) (_ domain.NearbyPage, err error) {
+	defer classifyErr(&err)
+	var boxes []domain.BBox
+	if q.BBox != nil {
+		boxes = []domain.BBox{*q.BBox}
+	} else {
+		boxes = radiusBBoxes(q.Lat, q.Lon, *q.RadiusKm)
+	}
+	ref := pointWKT(q.Lat, q.Lon)
+
+	within, wargs := withinSQL(boxes)
+	sqlStr := nearbyHotelsSQL + "  AND " + within + "\n"
+	args := append([]any{ref, q.Lang}, wargs...)
+	if q.RadiusKm != nil {
+		sqlStr += "  AND ST_Distance_Sphere(p.geo, ST_PointFromText(?, 4326)) <= ?\n"
+		args = append(args, ref, *q.RadiusKm*1000)
+	}
+	sqlStr += "ORDER BY dist_m, p.id\nLIMIT ?"
+	args = append(args, q.Limit)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return domain.NearbyPage{}, err
+	}
+	defer rows.Close()
+
+	var out []domain.NearbyHit
+	for rows.Next() {
+		var hit domain.NearbyHit
+		var stars sql.NullInt64
+		var lat, lon sql.NullFloat64
+		var country, city, name sql.NullString
+		var distM float64
+		var mainPhoto sql.NullString
+		if err := rows.Scan(&hit.Hotel.ID, &stars, &lat, &lon, &country, &city, &name, &distM, &mainPhoto); err != nil {
+			return domain.NearbyPage{}, err
+		}
+		hit.Hotel.MainPhoto = nullStr(mainPhoto)
+		hv := &hit.Hotel
+		if stars.Valid {
+			s := int(stars.Int64)
+			hv.Stars = &s
+		}
+		if lat.Valid && lon.Valid {
+			hv.Coords = &domain.Coords{Lat: lat.Float64, Lon: lon.Float64}
+		}
+		if country.Valid {
+			cs := country.String
+			hv.Country = &cs
+		}
+		if city.Valid {
+			cy := city.String
+			hv.City = &cy
+		}
+		if name.Valid {
+			ns := name.String
+			hv.Name = &ns
 		}
+		hv.Language = q.Lang
+		hit.DistanceKm = distM / 1000
+		out = append(out, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return domain.NearbyPage{}, err
+	}
+	return domain.NearbyPage{Items: out}, nil
+}
+
+// SimilarCandidates scopes candidates to the reference hotel's city, or to a
+// radius around it (spatial index prefilter, exact distance check), and pages
+// through them by id. A reference without the needed city/coordinates has no
+// candidates.
+func (r *Repo) SimilarCandidates(ctx context.Context, q domain.SimilarQuery, afterID int64, max int) (_ []domain.SimilarCandidate, err error) {
+	defer classifyErr(&err)
+	var lat, lon sql.NullFloat64
+	var city sql.NullString
+	var deactivated bool
+	err = r.db.QueryRowContext(ctx, similarRefSQL, q.ID).Scan(&lat, &lon, &city, &deactivated)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deactivated {
+		return nil, domain.ErrGone
+	}
 
-		if sourceID.Valid {
-			s := sourceID.String
-			rv.SourceID = &s
+	var ref any // NULL reference point -> NULL distances
+	if lat.Valid && lon.Valid {
+		ref = pointWKT(lat.Float64, lon.Float64)
+	}
+	sqlStr := similarCandidatesSQL
+	args := []any{ref, q.Lang, q.ID, afterID}
+	switch {
+	case q.RadiusKm != nil:
+		if ref == nil {
+			return []domain.SimilarCandidate{}, nil
 		}
-		if author.Valid {
-			s := author.String
-			rv.Author = &s
+		within, wargs := withinSQL(radiusBBoxes(lat.Float64, lon.Float64, *q.RadiusKm))
+		sqlStr += "  AND p.lat IS NOT NULL AND p.lon IS NOT NULL\n" +
+			"  AND " + within + "\n" +
+			"  AND ST_Distance_Sphere(p.geo, ST_PointFromText(?, 4326)) <= ?\n"
+		args = append(args, wargs...)
+		args = append(args, ref, *q.RadiusKm*1000)
+	default:
+		if !city.Valid || city.String == "" {
+			return []domain.SimilarCandidate{}, nil
+		}
+		sqlStr += "  AND p.city = ?\n"
+		args = append(args, city.String)
+	}
+	sqlStr += "ORDER BY p.id\nLIMIT ?"
+	args = append(args, max)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.SimilarCandidate{}
+	for rows.Next() {
+		var c domain.SimilarCandidate
+		var stars sql.NullInt64
+		var clat, clon, rating, distM sql.NullFloat64
+		var country, ccity, name, mainPhoto sql.NullString
+		var amenityCodes []byte
+		if err := rows.Scan(&c.Hotel.ID, &stars, &clat, &clon, &country, &ccity, &name,
+			&mainPhoto, &amenityCodes, &rating, &distM); err != nil {
+			return nil, err
 		}
+		hv := &c.Hotel
+		if stars.Valid {
+			s := int(stars.Int64)
+			hv.Stars = &s
+		}
+		if clat.Valid && clon.Valid {
+			hv.Coords = &domain.Coords{Lat: clat.Float64, Lon: clon.Float64}
+		}
+		hv.Country = nullStr(country)
+		hv.City = nullStr(ccity)
+		hv.Name = nullStr(name)
+		hv.MainPhoto = nullStr(mainPhoto)
+		var codes []string
+		_ = json.Unmarshal(amenityCodes, &codes)
+		sort.Strings(codes)
+		for _, code := range codes {
+			hv.CanonicalAmenities = append(hv.CanonicalAmenities, domain.Amenity{Code: code})
+		}
+		hv.Language = q.Lang
 		if rating.Valid {
-			f := rating.Float64
-			rv.Rating = &f
+			v := rating.Float64
+			c.Rating = &v
 		}
-		if lang.Valid {
-			s := lang.String
-			rv.Lang = &s
+		if distM.Valid {
+			km := distM.Float64 / 1000
+			c.DistanceKm = &km
 		}
-		if title.Valid {
-			s := title.String
-			rv.Title = &s
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// facetDims describes each facet: its value expression, an optional extra join,
+// and how to drop its own filter from the query (disjunctive counts).
+var facetDims = []struct {
+	expr, join string
+	clear      func(*domain.HotelsQuery)
+	dst        func(*domain.HotelFacets) *[]domain.FacetCount
+}{
+	{"p.country", "", func(q *domain.HotelsQuery) { q.Country = nil },
+		func(f *domain.HotelFacets) *[]domain.FacetCount { return &f.Country }},
+	{"p.city", "", func(q *domain.HotelsQuery) { q.City = nil },
+		func(f *domain.HotelFacets) *[]domain.FacetCount { return &f.City }},
+	{"p.stars", "", func(q *domain.HotelsQuery) { q.Stars = nil },
+		func(f *domain.HotelFacets) *[]domain.FacetCount { return &f.Stars }},
+	{"pa.code", "JOIN property_amenities pa ON pa.property_id = p.id",
+		func(q *domain.HotelsQuery) { q.Amenities = nil },
+		func(f *domain.HotelFacets) *[]domain.FacetCount { return &f.Amenity }},
+}
+
+func (r *Repo) HotelFacets(ctx context.Context, q domain.HotelsQuery) (_ domain.HotelFacets, err error) {
+	defer classifyErr(&err)
+	var out domain.HotelFacets
+	for _, d := range facetDims {
+		dq := q
+		d.clear(&dq)
+		where, args := hotelsFilter(dq)
+		var cond string
+		if len(where) > 0 {
+			cond = " AND " + strings.Join(where, " AND ")
 		}
-		if text.Valid {
-			s := text.String
-			rv.Text = &s
+		args = append([]any{q.Lang}, args...)
+		args = append(args, q.Limit)
+
+		rows, err := r.db.QueryContext(ctx, fmt.Sprintf(hotelFacetSQL, d.expr, d.join, cond), args...)
+		if err != nil {
+			return domain.HotelFacets{}, err
 		}
-		if len(aspectsRaw) > 0 {
-			rv.AspectsJSON = append([]byte(nil), aspectsRaw...)
+		buckets := []domain.FacetCount{}
+		for rows.Next() {
+			var fc domain.FacetCount
+			if err := rows.Scan(&fc.Value, &fc.Count); err != nil {
+				rows.Close()
+				return domain.HotelFacets{}, err
+			}
+			buckets = append(buckets, fc)
 		}
-		if source.Valid {
-			s := source.String
-			rv.Source = &s
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return domain.HotelFacets{}, err
 		}
-		if len(rawB) > 0 {
-			rv.RawJSON = append([]byte(nil), rawB...)
+		*d.dst(&out) = buckets
+	}
+	return out, nil
+}
+
+// hotelsFilter translates HotelsQuery filters into WHERE predicates over
+// properties p / property_i18n i. The cursor is handled by the caller.
+func hotelsFilter(q domain.HotelsQuery) ([]string, []any) {
+	where := []string{"p.deactivated_at IS NULL"}
+	var args []any
+	if q.Q != nil && strings.TrimSpace(*q.Q) != "" {
+		where = append(where, "i.name LIKE ?")
+		args = append(args, "%"+escapeLike(strings.TrimSpace(*q.Q))+"%")
+	}
+	if q.Country != nil && *q.Country != "" {
+		where = append(where, "p.country = ?")
+		args = append(args, *q.Country)
+	}
+	if q.City != nil && *q.City != "" {
+		where = append(where, "p.city = ?")
+		args = append(args, *q.City)
+	}
+	if q.Stars != nil {
+		where = append(where, "p.stars = ?")
+		args = append(args, *q.Stars)
+	}
+	if q.BrandID != nil {
+		where = append(where, "p.brand_id = ?")
+		args = append(args, *q.BrandID)
+	}
+	if len(q.Amenities) > 0 {
+		if q.AmenityMatch == domain.AmenityMatchAny {
+			where = append(where, "EXISTS (SELECT 1 FROM property_amenities pa WHERE pa.property_id = p.id AND pa.code IN ("+placeholders(len(q.Amenities))+"))")
+			for _, c := range q.Amenities {
+				args = append(args, c)
+			}
+		} else {
+			// One probe per code on the (property_id, code) primary key.
+			for _, c := range q.Amenities {
+				where = append(where, "EXISTS (SELECT 1 FROM property_amenities pa WHERE pa.property_id = p.id AND pa.code = ?)")
+				args = append(args, c)
+			}
 		}
+	}
+	return where, args
+}
+
+// placeholders returns "?,?,...,?" with n markers.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
 
+func (r *Repo) ListReviews(ctx context.Context, id int64, pg domain.PageQuery) (_ domain.ReviewsPage, err error) {
+	defer classifyErr(&err)
+	if err := r.checkProperty(ctx, id); err != nil {
+		return domain.ReviewsPage{}, err
+	}
+	sort := pg.Sort
+	if sort == "" {
+		sort = domain.SortNewest
+	}
+	order, ok := reviewOrders[sort]
+	if !ok {
+		return domain.ReviewsPage{}, fmt.Errorf("unsupported review sort %q", sort)
+	}
+
+	where := []string{"property_id = ?"}
+	args := []any{id}
+	if pg.Lang != nil {
+		where = append(where, "lang = ?")
+		args = append(args, *pg.Lang)
+	}
+	if pg.Source != nil {
+		where = append(where, "source = ?")
+		args = append(args, *pg.Source)
+	}
+	if pg.MinRating != nil {
+		where = append(where, "rating >= ?")
+		args = append(args, *pg.MinRating)
+	}
+	if pg.Cursor != nil && *pg.Cursor != "" {
+		var c reviewsCursor
+		if err := decodeCursor(*pg.Cursor, &c); err != nil {
+			return domain.ReviewsPage{}, err
+		}
+		if c.Sort != sort {
+			return domain.ReviewsPage{}, domain.ErrInvalidCursor
+		}
+		// (key, id) strictly after the cursor in the chosen direction.
+		where = append(where, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", order.key, order.cmp))
+		if order.byRating {
+			args = append(args, c.Rating, c.Rating, c.ID)
+		} else {
+			args = append(args, c.CreatedAt, c.CreatedAt, c.ID)
+		}
+	}
+
+	sqlStr := listReviewsSQL + "WHERE " + strings.Join(where, " AND ") + "\n" +
+		"ORDER BY " + order.key + " " + order.dir + ", id " + order.dir + "\nLIMIT ?"
+	// Fetch one extra row to know whether another page exists.
+	args = append(args, pg.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return domain.ReviewsPage{}, err
+	}
+	defer rows.Close()
+
+	var out []domain.Review
+	for rows.Next() {
+		rv, err := scanReview(rows)
+		if err != nil {
+			return domain.ReviewsPage{}, err
+		}
 		out = append(out, rv)
 	}
 	if err := rows.Err(); err != nil {
 		return domain.ReviewsPage{}, err
 	}
-	return domain.ReviewsPage{Items: out}, nil
+
+	page := domain.ReviewsPage{Items: out}
+	if len(out) > pg.Limit {
+		page.Items = out[:pg.Limit]
+		last := page.Items[pg.Limit-1]
+		c := reviewsCursor{Sort: sort, ID: last.ID}
+		if order.byRating {
+			c.Rating = -1
+			if last.Rating != nil {
+				c.Rating = *last.Rating
+			}
+		} else {
+			c.CreatedAt = last.CreatedAt
+		}
+		page.NextCursor = encodeCursor(c)
+	}
+	return page, nil
+}
+
+// scanReview scans one listReviewsSQL row; raw bytes are copied out of the driver buffer.
+func scanReview(rows *sql.Rows) (domain.Review, error) {
+	var rv domain.Review
+	var (
+		sourceID         sql.NullString
+		author           sql.NullString
+		rating           sql.NullFloat64
+		lang             sql.NullString
+		title            sql.NullString
+		text             sql.NullString
+		aspectsRaw, rawB sql.RawBytes
+		createdAt        sql.NullTime
+		source           sql.NullString
+	)
+	if err := rows.Scan(
+		&rv.ID,
+		&rv.PropertyID,
+		&sourceID,
+		&author,
+		&rating,
+		&lang,
+		&title,
+		&text,
+		&aspectsRaw,
+		&createdAt,
+		&source,
+		&rawB,
+	); err != nil {
+		return domain.Review{}, err
+	}
+
+	if sourceID.Valid {
+		s := sourceID.String
+		rv.SourceID = &s
+	}
+	if author.Valid {
+		s := author.String
+		rv.Author = &s
+	}
+	if rating.Valid {
+		f := rating.Float64
+		rv.Rating = &f
+	}
+	if lang.Valid {
+		s := lang.String
+		rv.Lang = &s
+	}
+	if title.Valid {
+		s := title.String
+		rv.Title = &s
+	}
+	if text.Valid {
+		s := text.String
+		rv.Text = &s
+	}
+	if len(aspectsRaw) > 0 {
+		rv.AspectsJSON = append([]byte(nil), aspectsRaw...)
+	}
+	if source.Valid {
+		s := source.String
+		rv.Source = &s
+	}
+	if createdAt.Valid {
+		rv.CreatedAt = createdAt.Time
+	}
+	if len(rawB) > 0 {
+		rv.RawJSON = append([]byte(nil), rawB...)
+	}
+	return rv, nil
+}
+
+// ExportHotels streams active hotels in q.Lang (no fallback) in id order.
+// The driver reads rows off the connection as fn consumes them, so memory
+// stays flat however large the catalogue is.
+func (r *Repo) ExportHotels(ctx context.Context, q domain.ExportQuery, fn func(domain.HotelView) error) (_ *string, err error) {
+	defer classifyErr(&err)
+	after, err := exportAfter(q, "hotels")
+	if err != nil {
+		return nil, err
+	}
+	sqlStr := exportHotelsSQL
+	args := []any{q.Lang, after}
+	if q.UpdatedSince != nil {
+		// A translation edit counts as an update of the hotel.
+		sqlStr += "AND (p.updated_at >= ? OR i.updated_at >= ?)\n"
+		args = append(args, *q.UpdatedSince, *q.UpdatedSince)
+	}
+	sqlStr, args = exportPage(sqlStr+"ORDER BY p.id\n", args, q.Limit)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	n, last := 0, int64(0)
+	for rows.Next() {
+		if q.Limit > 0 && n == q.Limit {
+			return encodeCursor(exportCursor{Kind: "hotels", ID: last}), nil
+		}
+		hv, err := scanHotelView(rows, q.Lang)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(hv); err != nil {
+			return nil, err
+		}
+		n, last = n+1, hv.ID
+	}
+	return nil, rows.Err()
+}
+
+// ExportReviews streams reviews of active hotels in id order, optionally
+// only those written in q.Lang.
+func (r *Repo) ExportReviews(ctx context.Context, q domain.ExportQuery, fn func(domain.Review) error) (_ *string, err error) {
+	defer classifyErr(&err)
+	after, err := exportAfter(q, "reviews")
+	if err != nil {
+		return nil, err
+	}
+	sqlStr := exportReviewsSQL
+	args := []any{after}
+	if q.Lang != "" {
+		sqlStr += "AND lang = ?\n"
+		args = append(args, q.Lang)
+	}
+	if q.UpdatedSince != nil {
+		sqlStr += "AND updated_at >= ?\n"
+		args = append(args, *q.UpdatedSince)
+	}
+	sqlStr, args = exportPage(sqlStr+"ORDER BY id\n", args, q.Limit)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	n, last := 0, int64(0)
+	for rows.Next() {
+		if q.Limit > 0 && n == q.Limit {
+			return encodeCursor(exportCursor{Kind: "reviews", ID: last}), nil
+		}
+		rv, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(rv); err != nil {
+			return nil, err
+		}
+		n, last = n+1, rv.ID
+	}
+	return nil, rows.Err()
+}
+
+// exportAfter is the last id already exported: q.AfterID, or the one a
+// continuation token carries.
+func exportAfter(q domain.ExportQuery, kind string) (int64, error) {
+	if q.Cursor == nil || *q.Cursor == "" {
+		return q.AfterID, nil
+	}
+	var c exportCursor
+	if err := decodeCursor(*q.Cursor, &c); err != nil {
+		return 0, err
+	}
+	if c.Kind != kind {
+		return 0, domain.ErrInvalidCursor
+	}
+	return c.ID, nil
+}
+
+// exportPage appends LIMIT when the export is paged, fetching one extra row
+// to know whether a continuation token is needed.
+func exportPage(sqlStr string, args []any, limit int) (string, []any) {
+	if limit <= 0 {
+		return sqlStr, args
+	}
+	return sqlStr + "LIMIT ?", append(args, limit+1)
+}
+
+// HotelVersion folds the property payload hash, the brand name and the
+// translation timestamps of langs into one tag; Modified is the latest of
+// those writes (brand renames only change the tag).
+func (r *Repo) HotelVersion(ctx context.Context, id int64, langs []string) (_ domain.Version, err error) {
+	defer classifyErr(&err)
+	var (
+		deactivated bool
+		hash, brand string
+		modified    time.Time
+	)
+	err = r.db.QueryRowContext(ctx, propertyVersionSQL, id).Scan(&deactivated, &hash, &modified, &brand)
+	switch {
+	case err == sql.ErrNoRows:
+		return domain.Version{}, domain.ErrNotFound
+	case err != nil:
+		return domain.Version{}, err
+	case deactivated:
+		return domain.Version{}, domain.ErrGone
+	}
+
+	tag := sha1.New()
+	fmt.Fprintf(tag, "p:%s:%d|b:%s", hash, modified.Unix(), brand)
+	if len(langs) > 0 {
+		args := []any{id}
+		for _, l := range langs {
+			args = append(args, l)
+		}
+		rows, err := r.db.QueryContext(ctx, fmt.Sprintf(i18nVersionsSQL, placeholders(len(langs))), args...)
+		if err != nil {
+			return domain.Version{}, err
+		}
+		defer rows.Close()
+		has := map[string][]bool{}
+		for rows.Next() {
+			var lang string
+			var at time.Time
+			fields := make([]bool, 3) // name, description, policies
+			if err := rows.Scan(&lang, &at, &fields[0], &fields[1], &fields[2]); err != nil {
+				return domain.Version{}, err
+			}
+			has[lang] = fields
+			fmt.Fprintf(tag, "|%s:%d", lang, at.Unix())
+			if at.After(modified) {
+				modified = at
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return domain.Version{}, err
+		}
+		return domain.Version{Tag: hex.EncodeToString(tag.Sum(nil)), Modified: modified.UTC(), Language: servedLanguages(langs, has)}, nil
+	}
+	return domain.Version{Tag: hex.EncodeToString(tag.Sum(nil)), Modified: modified.UTC()}, nil
+}
+
+// servedLanguages names the languages a hotel's translated fields are served
+// in: each comes from the first language of the chain that has it, as the
+// query service's fallback fills them. Empty when no language has any.
+func servedLanguages(chain []string, has map[string][]bool) string {
+	var out []string
+	for field := range 3 {
+		for _, l := range chain {
+			if f, ok := has[l]; ok && f[field] {
+				if !slices.Contains(out, l) {
+					out = append(out, l)
+				}
+				break
+			}
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// ReviewsVersion derives the version of a hotel's reviews from their count
+// and latest write, so inserts and edits both change it.
+func (r *Repo) ReviewsVersion(ctx context.Context, id int64) (_ domain.Version, err error) {
+	defer classifyErr(&err)
+	if err := r.checkProperty(ctx, id); err != nil {
+		return domain.Version{}, err
+	}
+	var n int64
+	var last sql.NullTime
+	if err := r.db.QueryRowContext(ctx, reviewsVersionSQL, id).Scan(&n, &last); err != nil {
+		return domain.Version{}, err
+	}
+	v := domain.Version{Tag: fmt.Sprintf("r:%d:%d", n, last.Time.Unix())}
+	if last.Valid {
+		v.Modified = last.Time.UTC()
+	}
+	return v, nil
+}
+
+func (r *Repo) ReviewSummary(ctx context.Context, id int64) (_ domain.ReviewSummary, err error) {
+	defer classifyErr(&err)
+	if err := r.checkProperty(ctx, id); err != nil {
+		return domain.ReviewSummary{}, err
+	}
+	out := domain.ReviewSummary{PropertyID: id}
+
+	rows, err := r.db.QueryContext(ctx, reviewRatingDistSQL, id)
+	if err != nil {
+		return domain.ReviewSummary{}, err
+	}
+	defer rows.Close()
+
+	// Distribution is ordered by rating, so the median can be read off it.
+	type bucket struct {
+		rating float64
+		n      int
+	}
+	var dist []bucket
+	var sum float64
+	for rows.Next() {
+		var rating sql.NullFloat64
+		var n int
+		if err := rows.Scan(&rating, &n); err != nil {
+			return domain.ReviewSummary{}, err
+		}
+		out.Count += n
+		if !rating.Valid {
+			continue
+		}
+		out.RatedCount += n
+		sum += rating.Float64 * float64(n)
+		dist = append(dist, bucket{rating.Float64, n})
+
+		b := int(rating.Float64)
+		if k := len(out.Histogram); k > 0 && out.Histogram[k-1].Rating == b {
+			out.Histogram[k-1].Count += n
+		} else {
+			out.Histogram = append(out.Histogram, domain.RatingBucket{Rating: b, Count: n})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return domain.ReviewSummary{}, err
+	}
+
+	if out.RatedCount > 0 {
+		mean := sum / float64(out.RatedCount)
+		out.MeanRating = &mean
+
+		// nth(i) returns the i-th (0-based) rating in ascending order.
+		nth := func(i int) float64 {
+			for _, b := range dist {
+				if i < b.n {
+					return b.rating
+				}
+				i -= b.n
+			}
+			return dist[len(dist)-1].rating
+		}
+		median := nth(out.RatedCount / 2)
+		if out.RatedCount%2 == 0 {
+			median = (nth(out.RatedCount/2-1) + median) / 2
+		}
+		out.MedianRating = &median
+	}
+
+	if out.ByLang, err = r.countBy(ctx, reviewLangCountsSQL, id); err != nil {
+		return domain.ReviewSummary{}, err
+	}
+	if out.BySource, err = r.countBy(ctx, reviewSourceCountsSQL, id); err != nil {
+		return domain.ReviewSummary{}, err
+	}
+	return out, nil
+}
+
+func (r *Repo) ReviewAspects(ctx context.Context, id int64) (_ [][]byte, err error) {
+	defer classifyErr(&err)
+	rows, err := r.db.QueryContext(ctx, reviewAspectsSQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out [][]byte
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// countBy runs a "SELECT key, COUNT(*) ... GROUP BY key" query for one property.
+func (r *Repo) countBy(ctx context.Context, query string, id int64) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]int{}
+	for rows.Next() {
+		var k string
+		var n int
+		if err := rows.Scan(&k, &n); err != nil {
+			return nil, err
+		}
+		out[k] += n
+	}
+	return out, rows.Err()
+}
+
+// reviewOrder describes one supported review ordering.
+type reviewOrder struct {
+	key      string // sort expression; NULL ratings sort as -1
+	dir      string // ASC|DESC
+	cmp      string // keyset comparison matching dir
+	byRating bool
+}
+
+var reviewOrders = map[string]reviewOrder{
+	domain.SortNewest:     {key: "created_at", dir: "DESC", cmp: "<"},
+	domain.SortOldest:     {key: "created_at", dir: "ASC", cmp: ">"},
+	domain.SortRatingDesc: {key: "COALESCE(rating, -1)", dir: "DESC", cmp: "<", byRating: true},
+	domain.SortRatingAsc:  {key: "COALESCE(rating, -1)", dir: "ASC", cmp: ">", byRating: true},
 }
@@ -1,22 +1,103 @@
 package mysql
 
+// updated_at only moves when the payload hash changes (it backs Last-Modified),
+// so it must be assigned before content_hash.
 const upsertPropertySQL = `
 INSERT INTO properties
-  (id, brand_id, stars, lat, lon, country, city, address_raw, amenities, images, raw)
+  (id, brand_id, stars, lat, lon, geo, country, city, address_raw, amenities, images, raw, content_hash)
 VALUES
-  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  (?, ?, ?, ?, ?, ST_PointFromText(?, 4326), ?, ?, ?, ?, ?, ?, ?)
 ON DUPLICATE KEY UPDATE
   brand_id    = VALUES(brand_id),
   stars       = VALUES(stars),
   lat         = VALUES(lat),
   lon         = VALUES(lon),
+  geo         = VALUES(geo),
   country     = VALUES(country),
   city        = VALUES(city),
   address_raw = VALUES(address_raw),
   amenities   = VALUES(amenities),
   images      = VALUES(images),
   raw         = VALUES(raw),
-  updated_at  = CURRENT_TIMESTAMP
+  deactivated_at = NULL,
+  updated_at  = IF(content_hash <=> VALUES(content_hash), updated_at, CURRENT_TIMESTAMP),
+  content_hash = VALUES(content_hash)
+`
+
+// Keeps the first deactivation time when Cupid keeps reporting the property missing.
+const deactivatePropertySQL = `
+UPDATE properties SET deactivated_at = COALESCE(deactivated_at, CURRENT_TIMESTAMP) WHERE id = ?
+`
+
+// Chain names only move forward: a payload without a name keeps the stored one.
+const upsertBrandSQL = `
+INSERT INTO brands (id, name) VALUES (?, ?)
+ON DUPLICATE KEY UPDATE name = COALESCE(VALUES(name), name)
+`
+
+const listBrandsSQL = `
+SELECT b.id, b.name, COUNT(p.id) AS hotel_count
+FROM brands b
+LEFT JOIN properties p ON p.brand_id = b.id AND p.deactivated_at IS NULL
+GROUP BY b.id, b.name
+ORDER BY hotel_count DESC, b.name, b.id
+`
+
+const getBrandSQL = `
+SELECT b.id, b.name, (SELECT COUNT(*) FROM properties p WHERE p.brand_id = b.id AND p.deactivated_at IS NULL) AS hotel_count
+FROM brands b
+WHERE b.id = ?
+`
+
+const deletePropertyAmenitiesSQL = `DELETE FROM property_amenities WHERE property_id = ?`
+
+// Links only codes present in the taxonomy; %s is the IN placeholder list.
+const insertPropertyAmenitiesSQL = `
+INSERT INTO property_amenities (property_id, code)
+SELECT ?, a.code FROM amenities a WHERE a.code IN (%s)
+`
+
+const deletePropertyImagesSQL = `DELETE FROM property_images WHERE property_id = ?`
+
+// Bulk insert prefix; the repo appends one (?, ?, ?, ?, ?, ?, ?, ?) group per image.
+const insertPropertyImagesPrefix = `
+INSERT INTO property_images
+  (property_id, sort_order, url, caption, category, width, height, is_primary)
+VALUES `
+
+const listImagesSQL = `
+SELECT url, caption, category, width, height, sort_order, is_primary
+FROM property_images
+WHERE property_id = ?
+ORDER BY is_primary DESC, sort_order
+`
+
+// No row: unknown hotel; 1: deactivated.
+const propertyStateSQL = `SELECT deactivated_at IS NOT NULL FROM properties WHERE id = ?`
+
+// The brand name is part of the hotel view but not of the payload hash.
+const propertyVersionSQL = `
+SELECT p.deactivated_at IS NOT NULL, COALESCE(p.content_hash, ''), p.updated_at, COALESCE(b.name, '')
+FROM properties p
+LEFT JOIN brands b ON b.id = p.brand_id
+WHERE p.id = ?
+`
+
+// %s is a placeholder list of languages.
+const i18nVersionsSQL = `
+SELECT lang, updated_at, name IS NOT NULL, description IS NOT NULL, policies IS NOT NULL
+FROM property_i18n
+WHERE property_id = ? AND lang IN (%s)
+ORDER BY lang
+`
+
+const reviewsVersionSQL = `SELECT COUNT(*), MAX(updated_at) FROM reviews WHERE property_id = ?`
+
+const amenityTaxonomySQL = `
+SELECT a.code, l.lang, l.label
+FROM amenities a
+LEFT JOIN amenity_i18n l ON l.code = a.code
+ORDER BY a.sort_order, a.code, l.lang
 `
 
 const upsertI18nSQL = `
@@ -36,6 +117,8 @@ ON DUPLICATE KEY UPDATE
 const insertReviewsPrefix = "INSERT INTO reviews\n  (property_id, source_id, author, rating, lang, title, `text`, aspects, created_at, source, raw)\nVALUES "
 
 // Use VALUES(col) for broad compatibility; COALESCE keeps old value if new is NULL.
+// created_at is left alone: it is the first-seen time, so re-ingesting an
+// unchanged review is a no-op (and stays out of the change feed).
 const insertReviewsOnDup = " ON DUPLICATE KEY UPDATE\n" +
 	"  author     = COALESCE(VALUES(author), reviews.author),\n" +
 	"  rating     = COALESCE(VALUES(rating), reviews.rating),\n" +
@@ -43,10 +126,34 @@ const insertReviewsOnDup = " ON DUPLICATE KEY UPDATE\n" +
 	"  title      = COALESCE(VALUES(title), reviews.title),\n" +
 	"  `text`     = COALESCE(VALUES(`text`), reviews.`text`),\n" +
 	"  aspects    = COALESCE(VALUES(aspects), reviews.aspects),\n" +
-	"  created_at = COALESCE(VALUES(created_at), reviews.created_at),\n" +
 	"  source     = COALESCE(VALUES(source), reviews.source),\n" +
 	"  raw        = COALESCE(VALUES(raw), reviews.raw)\n"
 
+// Bumps the change sequence by n; LAST_INSERT_ID(expr) hands the new value
+// back in the OK packet. The row lock is held until the transaction ends.
+const bumpChangeSeqSQL = `UPDATE change_seq SET seq = LAST_INSERT_ID(seq + ?) WHERE id = 1`
+
+const insertChangeSQL = `
+INSERT INTO changes (seq, entity, op, property_id, lang) VALUES (?, ?, ?, ?, ?)
+`
+
+const insertOutboxSQL = `
+INSERT INTO webhook_outbox (event_type, property_id, lang) VALUES (?, ?, ?)
+`
+
+// Reviews per property; %s is the IN placeholder list.
+const countReviewsSQL = `
+SELECT property_id, COUNT(*) FROM reviews WHERE property_id IN (%s) GROUP BY property_id
+`
+
+const listChangesSQL = `
+SELECT seq, entity, op, property_id, lang, changed_at
+FROM changes
+WHERE seq > ?
+ORDER BY seq
+LIMIT ?
+`
+
 const insertMissSQL = `
 INSERT INTO ingest_misses (id, http_status, reason)
 VALUES (?, ?, ?)
@@ -57,10 +164,10 @@ ON DUPLICATE KEY UPDATE seen_at = CURRENT_TIMESTAMP
 // READ QUERIES
 // -----------------------------------------------------------------------------
 
-// Returns a single property joined with i18n for the requested lang.
+// Property joined with i18n for the requested lang (first placeholder).
 // We include BOTH i.address (localized) and p.address_raw (base); in the repo,
 // prefer i.address when not NULL, else fallback to p.address_raw.
-const getHotelSQL = `
+const hotelViewSelectSQL = `
 SELECT
   p.id,
   p.brand_id,
@@ -75,9 +182,332 @@ SELECT
   i.name,
   i.description,
   i.policies,
-  i.address               -- localized address (preferred when not NULL)
+  i.address,              -- localized address (preferred when not NULL)
+  (SELECT JSON_ARRAYAGG(pa.code) FROM property_amenities pa WHERE pa.property_id = p.id) AS amenity_codes,
+  ` + mainPhotoSQL + ` AS main_photo,
+  b.name AS brand_name,
+  p.deactivated_at IS NOT NULL AS deactivated
 FROM properties p
+LEFT JOIN brands b ON b.id = p.brand_id
 LEFT JOIN property_i18n i
   ON i.property_id = p.id AND i.lang = ?
-WHERE p.id = ?
 `
+
+// Bulk export of hotels in id order; WHERE/LIMIT are appended by the repo.
+const exportHotelsSQL = hotelViewSelectSQL + `WHERE p.deactivated_at IS NULL AND p.id > ?
+`
+
+// Bulk export of reviews in id order; reviews of deactivated hotels are skipped.
+const exportReviewsSQL = listReviewsSQL + `WHERE id > ?
+  AND EXISTS (SELECT 1 FROM properties p WHERE p.id = reviews.property_id AND p.deactivated_at IS NULL)
+`
+
+// Returns a single property joined with i18n for the requested lang.
+const getHotelSQL = hotelViewSelectSQL + `WHERE p.id = ?
+`
+
+// mainPhotoSQL picks the thumbnail for list results: the primary image, else the first.
+const mainPhotoSQL = `(SELECT pi.url FROM property_images pi WHERE pi.property_id = p.id
+   ORDER BY pi.is_primary DESC, pi.sort_order LIMIT 1)`
+
+// Base SELECT for hotel listings; WHERE/ORDER/LIMIT are appended by the repo
+// depending on which HotelsQuery filters are set.
+const listHotelsSQL = `
+SELECT p.id, p.stars, p.lat, p.lon, p.country, p.city, i.name,
+  ` + mainPhotoSQL + ` AS main_photo
+FROM properties p
+LEFT JOIN property_i18n i
+  ON i.property_id = p.id AND i.lang = ?
+`
+
+// Facet buckets for one dimension: %[1]s is the value expression, %[2]s an
+// optional extra join (property_amenities for amenity codes), %[3]s the
+// appended filters.
+// Both come from a fixed set in the repo, never user input.
+const hotelFacetSQL = `
+SELECT %[1]s AS v, COUNT(DISTINCT p.id) AS n
+FROM properties p
+LEFT JOIN property_i18n i
+  ON i.property_id = p.id AND i.lang = ?
+%[2]s
+WHERE %[1]s IS NOT NULL%[3]s
+GROUP BY v
+ORDER BY n DESC, v
+LIMIT ?
+`
+
+// Full-text search over the requested language; %[1]s is the AGAINST modifier
+// (chosen from a fixed set, never user input). Filters are appended by the repo.
+const searchHotelsSQL = `
+SELECT p.id, p.stars, p.lat, p.lon, p.country, p.city, i.name, i.description,
+  MATCH(i.name, i.description) AGAINST (? %[1]s) AS score,
+  ` + mainPhotoSQL + ` AS main_photo
+FROM property_i18n i
+JOIN properties p ON p.id = i.property_id
+WHERE i.lang = ?
+  AND MATCH(i.name, i.description) AGAINST (? %[1]s)
+`
+
+// Hotels inside a window polygon (uses the SPATIAL index via MBRContains),
+// ordered by great-circle distance from a reference point. The optional
+// radius predicate is appended by the repo.
+const nearbyHotelsSQL = `
+SELECT p.id, p.stars, p.lat, p.lon, p.country, p.city, i.name,
+  ST_Distance_Sphere(p.geo, ST_PointFromText(?, 4326)) AS dist_m,
+  ` + mainPhotoSQL + ` AS main_photo
+FROM properties p
+LEFT JOIN property_i18n i
+  ON i.property_id = p.id AND i.lang = ?
+WHERE p.lat IS NOT NULL AND p.lon IS NOT NULL
+  AND p.deactivated_at IS NULL
+`
+
+const similarRefSQL = `SELECT lat, lon, city, deactivated_at IS NOT NULL FROM properties WHERE id = ?`
+
+// Candidates for similar-hotel ranking, with the signals the app blends:
+// amenity codes, mean review rating and distance to the reference point
+// (NULL when either side has no coordinates). The scope predicate is appended
+// by the repo.
+const similarCandidatesSQL = `
+SELECT p.id, p.stars, p.lat, p.lon, p.country, p.city, i.name,
+  ` + mainPhotoSQL + ` AS main_photo,
+  (SELECT JSON_ARRAYAGG(pa.code) FROM property_amenities pa WHERE pa.property_id = p.id) AS amenity_codes,
+  (SELECT AVG(rv.rating) FROM reviews rv WHERE rv.property_id = p.id) AS rating,
+  CASE WHEN p.lat IS NOT NULL AND p.lon IS NOT NULL
+    THEN ST_Distance_Sphere(p.geo, ST_PointFromText(?, 4326)) END AS dist_m
+FROM properties p
+LEFT JOIN property_i18n i
+  ON i.property_id = p.id AND i.lang = ?
+WHERE p.id <> ? AND p.id > ? AND p.deactivated_at IS NULL
+`
+
+// Base SELECT for a hotel's reviews; WHERE/ORDER/LIMIT are appended by the
+// repo from the PageQuery (filters, sort, keyset cursor).
+const listReviewsSQL = `
+SELECT
+  id,
+  property_id,
+  source_id,
+  author,
+  rating,
+  lang,
+  title,
+  ` + "`text`" + `,
+  aspects,
+  created_at,
+  source,
+  raw
+FROM reviews
+`
+
+// Review aggregates for the summary endpoint (one round-trip each, all served
+// by the property_id prefix of idx_reviews_prop_created / uq_reviews_natural).
+const reviewRatingDistSQL = `
+SELECT rating, COUNT(*) FROM reviews WHERE property_id = ? GROUP BY rating ORDER BY rating
+`
+
+const reviewLangCountsSQL = `
+SELECT COALESCE(lang, ''), COUNT(*) FROM reviews WHERE property_id = ? GROUP BY lang
+`
+
+const reviewSourceCountsSQL = `
+SELECT COALESCE(source, ''), COUNT(*) FROM reviews WHERE property_id = ? GROUP BY source
+`
+
+const reviewAspectsSQL = `
+SELECT aspects FROM reviews WHERE property_id = ? AND aspects IS NOT NULL AND aspects <> ''
+`
+
+// -----------------------------------------------------------------------------
+// WEBHOOKS
+// -----------------------------------------------------------------------------
+
+const webhookColumnsSQL = `id, url, secret, events, consecutive_failures, disabled_at, disabled_reason, created_at`
+
+const insertWebhookSQL = `
+INSERT INTO webhook_subscriptions (url, secret, events) VALUES (?, ?, ?)
+`
+
+const listWebhooksSQL = `SELECT ` + webhookColumnsSQL + ` FROM webhook_subscriptions ORDER BY id`
+
+const getWebhookSQL = `SELECT ` + webhookColumnsSQL + ` FROM webhook_subscriptions WHERE id = ?`
+
+const deleteWebhookSQL = `DELETE FROM webhook_subscriptions WHERE id = ?`
+
+const enableWebhookSQL = `
+UPDATE webhook_subscriptions
+SET disabled_at = NULL, disabled_reason = NULL, consecutive_failures = 0
+WHERE id = ?
+`
+
+// Newest first; served by idx_attempts_subscription.
+const listWebhookAttemptsSQL = `
+SELECT a.delivery_id, a.subscription_id, d.event_id, o.event_type, a.attempt,
+       a.status_code, a.error, a.duration_ms, a.attempted_at
+FROM webhook_attempts a
+JOIN webhook_deliveries d ON d.id = a.delivery_id
+JOIN webhook_outbox o ON o.id = d.event_id
+WHERE a.subscription_id = ?
+ORDER BY a.id DESC
+LIMIT ?
+`
+
+// SKIP LOCKED lets concurrent dispatchers take disjoint batches.
+const claimOutboxSQL = `
+SELECT id FROM webhook_outbox
+WHERE dispatched_at IS NULL
+ORDER BY id
+LIMIT ?
+FOR UPDATE SKIP LOCKED
+`
+
+// One delivery per (enabled subscription, event) whose type it asked for;
+// %s is the IN placeholder list of event ids.
+const fanOutSQL = `
+INSERT IGNORE INTO webhook_deliveries (subscription_id, event_id)
+SELECT s.id, o.id
+FROM webhook_outbox o
+JOIN webhook_subscriptions s
+  ON s.disabled_at IS NULL AND JSON_CONTAINS(s.events, JSON_QUOTE(o.event_type))
+WHERE o.id IN (%s)
+`
+
+const markDispatchedSQL = `UPDATE webhook_outbox SET dispatched_at = CURRENT_TIMESTAMP WHERE id IN (%s)`
+
+const claimDeliveriesSQL = `
+SELECT id FROM webhook_deliveries
+WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP(3)
+ORDER BY next_attempt_at
+LIMIT ?
+FOR UPDATE SKIP LOCKED
+`
+
+// Pushes claimed deliveries past the lease so no other dispatcher picks them
+// up while they are in flight; the first placeholder is the lease in µs.
+const leaseDeliveriesSQL = `
+UPDATE webhook_deliveries
+SET next_attempt_at = CURRENT_TIMESTAMP(3) + INTERVAL ? MICROSECOND
+WHERE id IN (%s)
+`
+
+const deliveryDetailsSQL = `
+SELECT d.id, d.attempts,
+       s.id, s.url, s.secret, s.consecutive_failures,
+       o.id, o.event_type, o.property_id, o.lang, o.created_at
+FROM webhook_deliveries d
+JOIN webhook_subscriptions s ON s.id = d.subscription_id
+JOIN webhook_outbox o ON o.id = d.event_id
+WHERE d.id IN (%s)
+ORDER BY d.id
+`
+
+const insertWebhookAttemptSQL = `
+INSERT INTO webhook_attempts (delivery_id, subscription_id, attempt, status_code, error, duration_ms)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+const deliveredSQL = `
+UPDATE webhook_deliveries
+SET status = 'delivered', attempts = attempts + 1, delivered_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+// The first placeholder is the retry delay in µs.
+const retryDeliverySQL = `
+UPDATE webhook_deliveries
+SET attempts = attempts + 1, next_attempt_at = CURRENT_TIMESTAMP(3) + INTERVAL ? MICROSECOND
+WHERE id = ?
+`
+
+const giveUpDeliverySQL = `
+UPDATE webhook_deliveries SET status = 'failed', attempts = attempts + 1 WHERE id = ?
+`
+
+const webhookSucceededSQL = `UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = ?`
+
+const webhookFailedSQL = `
+UPDATE webhook_subscriptions SET consecutive_failures = consecutive_failures + 1 WHERE id = ?
+`
+
+const webhookFailuresSQL = `SELECT consecutive_failures FROM webhook_subscriptions WHERE id = ?`
+
+const disableWebhookSQL = `
+UPDATE webhook_subscriptions
+SET disabled_at = CURRENT_TIMESTAMP, disabled_reason = ?
+WHERE id = ? AND disabled_at IS NULL
+`
+
+// Pending deliveries of a disabled subscription are given up.
+const failPendingDeliveriesSQL = `
+UPDATE webhook_deliveries SET status = 'failed' WHERE subscription_id = ? AND status = 'pending'
+`
+
+// -----------------------------------------------------------------------------
+// API KEYS
+// -----------------------------------------------------------------------------
+
+const apiKeyColumnsSQL = `id, name, prefix, scopes, rate_per_sec, burst, created_at, revoked_at`
+
+const insertAPIKeySQL = `
+INSERT INTO api_keys (name, prefix, key_hash, scopes, rate_per_sec, burst) VALUES (?, ?, ?, ?, ?, ?)
+`
+
+const apiKeyByHashSQL = `SELECT ` + apiKeyColumnsSQL + ` FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL`
+
+const apiKeyByIDSQL = `SELECT ` + apiKeyColumnsSQL + ` FROM api_keys WHERE id = ?`
+
+const listAPIKeysSQL = `SELECT ` + apiKeyColumnsSQL + ` FROM api_keys ORDER BY id`
+
+// Keeps the first revocation time.
+const revokeAPIKeySQL = `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE id = ?`
+
+// -----------------------------------------------------------------------------
+// INGEST JOBS
+// -----------------------------------------------------------------------------
+
+const insertIngestJobSQL = `
+INSERT INTO ingest_jobs (id, status, property_ids, options, reports, created_at) VALUES (?, ?, ?, ?, ?, ?)
+`
+
+const ingestJobByIDSQL = `
+SELECT id, status, property_ids, options, reports, error, created_at, started_at, finished_at, cancel_requested_at
+FROM ingest_jobs
+WHERE id = ?
+`
+
+// Every progress write is also the job's heartbeat.
+const updateIngestJobSQL = `
+UPDATE ingest_jobs
+SET status = ?, error = ?, started_at = ?, finished_at = ?, heartbeat_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+const insertIngestJobReportsPrefix = "INSERT INTO ingest_job_reports (job_id, property_id, report) VALUES "
+
+// A retried write may repeat reports already stored.
+const insertIngestJobReportsOnDup = " ON DUPLICATE KEY UPDATE report = VALUES(report)"
+
+const ingestJobReportsSQL = `SELECT report FROM ingest_job_reports WHERE job_id = ? ORDER BY id`
+
+// Unfinished jobs whose replica stopped writing progress; a job that never
+// wrote any counts from its creation. Args: new status, error, the
+// unfinished statuses, the staleness in seconds.
+const interruptStaleIngestJobsSQL = `
+UPDATE ingest_jobs
+SET status = ?, error = ?, finished_at = CURRENT_TIMESTAMP
+WHERE status IN (?, ?)
+  AND COALESCE(heartbeat_at, created_at) < CURRENT_TIMESTAMP - INTERVAL ? SECOND
+`
+
+// Keeps the first request.
+const cancelIngestJobSQL = `
+UPDATE ingest_jobs SET cancel_requested_at = COALESCE(cancel_requested_at, CURRENT_TIMESTAMP) WHERE id = ?
+`
+
+const ingestJobCancelRequestedSQL = `SELECT cancel_requested_at IS NOT NULL FROM ingest_jobs WHERE id = ?`
+
+// -----------------------------------------------------------------------------
+// SCHEMA
+// -----------------------------------------------------------------------------
+
+const schemaVersionsSQL = `SELECT version FROM schema_migrations`
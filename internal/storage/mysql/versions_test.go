@@ -0,0 +1,24 @@
+package mysql
+
+import "testing"
+
+func TestServedLanguages_FollowsChainPerField(t *testing.T) {
+	has := map[string][]bool{
+		"fr": {true, false, false}, // name only
+		"en": {true, true, false},
+	}
+	cases := []struct {
+		chain []string
+		want  string
+	}{
+		{[]string{"fr", "en"}, "fr, en"}, // description falls back
+		{[]string{"en"}, "en"},
+		{[]string{"es", "fr"}, "fr"},
+		{[]string{"es"}, ""},
+	}
+	for _, tc := range cases {
+		if got := servedLanguages(tc.chain, has); got != tc.want {
+			t.Errorf("chain %v: %q, want %q", tc.chain, got, tc.want)
+		}
+	}
+}
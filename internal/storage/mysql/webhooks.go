@@ -0,0 +1,286 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"cupid_hotel/internal/domain"
+)
+
+func (r *Repo) CreateWebhook(ctx context.Context, s domain.WebhookSubscription) (_ domain.WebhookSubscription, err error) {
+	defer classifyErr(&err)
+	events, _ := json.Marshal(s.Events)
+	res, err := r.db.ExecContext(ctx, insertWebhookSQL, s.URL, s.Secret, string(events))
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	return r.GetWebhook(ctx, id)
+}
+
+func (r *Repo) ListWebhooks(ctx context.Context) (_ []domain.WebhookSubscription, err error) {
+	defer classifyErr(&err)
+	rows, err := r.db.QueryContext(ctx, listWebhooksSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.WebhookSubscription{}
+	for rows.Next() {
+		s, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repo) GetWebhook(ctx context.Context, id int64) (_ domain.WebhookSubscription, err error) {
+	defer classifyErr(&err)
+	s, err := scanWebhook(r.db.QueryRowContext(ctx, getWebhookSQL, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.WebhookSubscription{}, domain.ErrNotFound
+	}
+	return s, err
+}
+
+func scanWebhook(row rowScanner) (domain.WebhookSubscription, error) {
+	var s domain.WebhookSubscription
+	var events []byte
+	var disabledAt sql.NullTime
+	var reason sql.NullString
+	if err := row.Scan(&s.ID, &s.URL, &s.Secret, &events, &s.ConsecutiveFailures, &disabledAt, &reason, &s.CreatedAt); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	if err := json.Unmarshal(events, &s.Events); err != nil {
+		return domain.WebhookSubscription{}, fmt.Errorf("webhook %d events: %w", s.ID, err)
+	}
+	if disabledAt.Valid {
+		s.DisabledAt = &disabledAt.Time
+	}
+	s.DisabledReason = nullStr(reason)
+	return s, nil
+}
+
+// DeleteWebhook removes a subscription with its deliveries and their log.
+func (r *Repo) DeleteWebhook(ctx context.Context, id int64) (err error) {
+	defer classifyErr(&err)
+	res, err := r.db.ExecContext(ctx, deleteWebhookSQL, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repo) EnableWebhook(ctx context.Context, id int64) (err error) {
+	defer classifyErr(&err)
+	if _, err := r.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, enableWebhookSQL, id)
+	return err
+}
+
+// ListWebhookAttempts returns the newest attempts of a subscription first.
+func (r *Repo) ListWebhookAttempts(ctx context.Context, id int64, limit int) (_ []domain.WebhookAttempt, err error) {
+	defer classifyErr(&err)
+	if _, err := r.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, listWebhookAttemptsSQL, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.WebhookAttempt{}
+	for rows.Next() {
+		var a domain.WebhookAttempt
+		var status sql.NullInt64
+		var msg sql.NullString
+		var ms int64
+		if err := rows.Scan(&a.DeliveryID, &a.SubscriptionID, &a.EventID, &a.EventType, &a.Attempt,
+			&status, &msg, &ms, &a.At); err != nil {
+			return nil, err
+		}
+		a.StatusCode = int(status.Int64)
+		a.Error = msg.String
+		a.Duration = time.Duration(ms) * time.Millisecond
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// FanOutEvents claims up to max undispatched outbox events, creates their
+// deliveries and marks them dispatched, all in one transaction.
+func (r *Repo) FanOutEvents(ctx context.Context, max int) (_ int, err error) {
+	defer classifyErr(&err)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	ids, err := claimIDs(ctx, tx, claimOutboxSQL, max)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	in := placeholders(len(ids))
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(fanOutSQL, in), ids...); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(markDispatchedSQL, in), ids...); err != nil {
+		return 0, err
+	}
+	return len(ids), tx.Commit()
+}
+
+// ClaimDeliveries leases up to max due deliveries: their next attempt moves
+// lease ahead, so a dispatcher that dies mid-flight only delays them.
+func (r *Repo) ClaimDeliveries(ctx context.Context, max int, lease time.Duration) (_ []domain.WebhookDelivery, err error) {
+	defer classifyErr(&err)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	ids, err := claimIDs(ctx, tx, claimDeliveriesSQL, max)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	in := placeholders(len(ids))
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(leaseDeliveriesSQL, in), append([]any{lease.Microseconds()}, ids...)...); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(deliveryDetailsSQL, in), ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.WebhookDelivery
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		var lang sql.NullString
+		if err := rows.Scan(&d.ID, &d.Attempts,
+			&d.Subscription.ID, &d.Subscription.URL, &d.Subscription.Secret, &d.Subscription.ConsecutiveFailures,
+			&d.Event.ID, &d.Event.Type, &d.Event.PropertyID, &lang, &d.Event.At); err != nil {
+			return nil, err
+		}
+		d.Event.Lang = nullStr(lang)
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, tx.Commit()
+}
+
+// claimIDs locks the ids selected by query (a SELECT id ... LIMIT ? FOR
+// UPDATE SKIP LOCKED) for the rest of tx.
+func claimIDs(ctx context.Context, tx *sql.Tx, query string, max int) ([]any, error) {
+	rows, err := tx.QueryContext(ctx, query, max)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []any
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// RecordAttempt logs an attempt and moves its delivery and subscription on.
+// A failed attempt that reaches o.DisableAfter consecutive failures disables
+// the subscription and gives up its pending deliveries.
+func (r *Repo) RecordAttempt(ctx context.Context, a domain.WebhookAttempt, o domain.WebhookOutcome) (_ bool, err error) {
+	defer classifyErr(&err)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	var status, msg any
+	if a.StatusCode > 0 {
+		status = a.StatusCode
+	}
+	if a.Error != "" {
+		msg = truncate(a.Error, 512)
+	}
+	if _, err := tx.ExecContext(ctx, insertWebhookAttemptSQL,
+		a.DeliveryID, a.SubscriptionID, a.Attempt, status, msg, a.Duration.Milliseconds()); err != nil {
+		return false, err
+	}
+
+	switch {
+	case o.Delivered:
+		if _, err := tx.ExecContext(ctx, deliveredSQL, a.DeliveryID); err != nil {
+			return false, err
+		}
+		if _, err := tx.ExecContext(ctx, webhookSucceededSQL, a.SubscriptionID); err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	case o.RetryIn > 0:
+		_, err = tx.ExecContext(ctx, retryDeliverySQL, o.RetryIn.Microseconds(), a.DeliveryID)
+	default:
+		_, err = tx.ExecContext(ctx, giveUpDeliverySQL, a.DeliveryID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, webhookFailedSQL, a.SubscriptionID); err != nil {
+		return false, err
+	}
+	var failures int
+	if err := tx.QueryRowContext(ctx, webhookFailuresSQL, a.SubscriptionID).Scan(&failures); err != nil {
+		return false, err
+	}
+	disabled := false
+	if o.DisableAfter > 0 && failures >= o.DisableAfter {
+		reason := fmt.Sprintf("%d consecutive failed deliveries", failures)
+		res, err := tx.ExecContext(ctx, disableWebhookSQL, reason, a.SubscriptionID)
+		if err != nil {
+			return false, err
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			disabled = true
+			if _, err := tx.ExecContext(ctx, failPendingDeliveriesSQL, a.SubscriptionID); err != nil {
+				return false, err
+			}
+		}
+	}
+	return disabled, tx.Commit()
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}